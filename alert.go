@@ -0,0 +1,209 @@
+// 该模块实现了根据配置创建告警分发器。
+// @Author: Haart
+// @Created: 2026-10-18
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	_alert "com.cne/ai-tracking-monitor/alert"
+	_db "com.cne/ai-tracking-monitor/db"
+	_types "com.cne/ai-tracking-monitor/types"
+	_utils "com.cne/ai-tracking-monitor/utils"
+)
+
+var (
+	alertDispatcher *_alert.Dispatcher // 告警分发器。
+	alertSuppressor *_alert.Suppressor // 告警抑制器。
+)
+
+// 根据配置创建告警分发器。
+// ac 告警配置。
+// baseDir 配置文件所在的目录，用于计算模板文件的路径。
+// 返回新创建的告警分发器。
+func newAlertDispatcher(ac *AlertConfiguration, baseDir string) (*_alert.Dispatcher, error) {
+	if ac.Retries < 0 {
+		return nil, fmt.Errorf("Alert.Retries: retries of alert should not be negative")
+	}
+
+	notifiers := make([]_alert.Notifier, 0)
+
+	for i, wc := range ac.Webhooks {
+		path := fmt.Sprintf("Alert.Webhooks[%d]", i)
+		name := wc.Name
+		if name == "" {
+			name = fmt.Sprintf("webhook#%d", i)
+		}
+
+		tmpl := wc.Template
+		if wc.TemplateFile != "" {
+			if b, err := ioutil.ReadFile(_utils.ToAbsPath(baseDir, wc.TemplateFile)); err != nil {
+				return nil, fmt.Errorf("%s.TemplateFile: cannot read template file of webhook %s: %w", path, name, err)
+			} else {
+				tmpl = string(b)
+			}
+		}
+
+		if n, err := _alert.NewWebhookNotifier(name, wc.Url, wc.Headers, tmpl, ac.Timeout.Duration()); err != nil {
+			errPath := errorConfigPath(path, err)
+			if _types.ErrorField(err) == "Template" && wc.TemplateFile != "" {
+				// 模板来自模板文件。
+				errPath = joinConfigPath(path, "TemplateFile")
+			}
+			return nil, fmt.Errorf("%s: %w", errPath, err)
+		} else {
+			notifiers = append(notifiers, n)
+		}
+	}
+
+	for i, cc := range ac.Chats {
+		path := fmt.Sprintf("Alert.Chats[%d]", i)
+		name := cc.Name
+		if name == "" {
+			name = fmt.Sprintf("chat#%d", i)
+		}
+
+		if n, err := _alert.NewChatNotifier(name, cc.Format, cc.Url, cc.Secret, ac.Timeout.Duration()); err != nil {
+			return nil, fmt.Errorf("%s: %w", errorConfigPath(path, err), err)
+		} else {
+			notifiers = append(notifiers, n)
+		}
+	}
+
+	mailNotifiers := make([]*_alert.SmtpNotifier, len(ac.Mails))
+	for i, mc := range ac.Mails {
+		path := fmt.Sprintf("Alert.Mails[%d]", i)
+		name := mc.Name
+		if name == "" {
+			name = fmt.Sprintf("mail#%d", i)
+		}
+
+		if mc.Digest < 0 {
+			return nil, fmt.Errorf("%s.Digest: digest period of mail %s should not be negative", path, name)
+		}
+
+		if n, err := _alert.NewSmtpNotifier(name, mc.Host, mc.Port, mc.Security, mc.Username, mc.Password, mc.From, mc.To, ac.Timeout.Duration(), mc.InsecureSkipVerify); err != nil {
+			return nil, fmt.Errorf("%s: %w", errorConfigPath(path, err), err)
+		} else if mc.Digest == 0 {
+			notifiers = append(notifiers, n)
+		} else {
+			mailNotifiers[i] = n
+		}
+	}
+
+	d := _alert.NewDispatcher(notifiers, ac.Retries, ac.RetryInterval.Duration(), saveAlertDelivery)
+	for i, n := range mailNotifiers {
+		if n != nil {
+			d.AddDigest(n, ac.Mails[i].Digest.Duration())
+		}
+	}
+
+	return d, nil
+}
+
+// 记录告警通知的投递结果。
+// d 投递结果。
+func saveAlertDelivery(d *_alert.Delivery) {
+	defer _utils.RecoverPanic()
+
+	resultStatus := 0
+	resultNote := ""
+	if d.Err != nil {
+		resultStatus = 1
+		resultNote = _utils.AbbrText(d.Err.Error(), 255)
+	}
+
+	_db.SaveAlertLog(d.Event.CrawlerId, d.Event.Kind.String(), d.Notifier, resultStatus, d.Attempts, resultNote, d.Time)
+}
+
+// 根据配置创建告警抑制器。
+// ac 告警配置。
+// mcs 维护窗口配置。
+// 返回新创建的告警抑制器。
+func newAlertSuppressor(ac *AlertConfiguration, mcs []MaintenanceConfiguration) (*_alert.Suppressor, error) {
+	if ac.DedupWindow < 0 {
+		return nil, fmt.Errorf("Alert.DedupWindow: dedup window of alert should not be negative")
+	}
+
+	maintenances := make([]*_alert.Maintenance, 0, len(mcs))
+	for i, mc := range mcs {
+		path := fmt.Sprintf("Maintenances[%d]", i)
+		var startTime, endTime time.Time
+		if strings.TrimSpace(mc.Cron) == "" {
+			startTime = _utils.ParseTime(strings.TrimSpace(mc.Start))
+			endTime = _utils.ParseTime(strings.TrimSpace(mc.End))
+			if _utils.IsZeroTime(startTime) {
+				return nil, fmt.Errorf("%s.Start: illegal start time of maintenance", path)
+			}
+			if _utils.IsZeroTime(endTime) {
+				return nil, fmt.Errorf("%s.End: illegal end time of maintenance", path)
+			}
+		}
+
+		if m, err := _alert.NewMaintenance(mc.CarrierCodes, startTime, endTime, mc.Cron, mc.Duration.Duration(), mc.Reason, mc.FreezeHealth); err != nil {
+			return nil, fmt.Errorf("%s: illegal maintenance: %w", errorConfigPath(path, err), err)
+		} else {
+			maintenances = append(maintenances, m)
+		}
+	}
+
+	return _alert.NewSuppressor(ac.DedupWindow.Duration(), maintenances), nil
+}
+
+// 从数据库加载当前生效的静默规则。
+// s 告警抑制器。
+// now 当前时间。
+func refreshAlertSilences(s *_alert.Suppressor, now time.Time) {
+	silences := make([]*_alert.Silence, 0)
+	for _, po := range _db.QueryActiveAlertSilences(now) {
+		silences = append(silences, &_alert.Silence{
+			Id:           po.Id,
+			CrawlerId:    po.CrawlerId,
+			CarrierCode:  po.CarrierCode,
+			Reason:       po.Reason,
+			StartTime:    po.StartTime,
+			EndTime:      po.EndTime,
+			FreezeHealth: po.FreezeHealth,
+		})
+	}
+
+	s.SetSilences(silences)
+}
+
+// 添加静默规则。
+// target 静默的目标，整数表示爬虫ID，否则表示运输商编号。
+// duration 静默的时长。
+// reason 静默的原因。
+// freezeHealth 静默期间是否冻结爬虫的健康状态。
+// 返回新添加的静默规则ID。
+func doSilence(target string, duration time.Duration, reason string, freezeHealth bool) (int64, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return 0, fmt.Errorf("target of silence should not be empty")
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("duration of silence should be positive")
+	}
+	if strings.TrimSpace(reason) == "" {
+		return 0, fmt.Errorf("reason of silence should not be empty")
+	}
+
+	var crawlerId int64
+	carrierCode := ""
+	if id, err := strconv.ParseInt(target, 10, 64); err == nil {
+		crawlerId = id
+	} else {
+		carrierCode = target
+	}
+
+	now := time.Now()
+	id := _db.SaveAlertSilence(crawlerId, carrierCode, reason, now, now.Add(duration), freezeHealth)
+	log.Printf("[INFO] Silence %s for %s (id=%d): %s\n", target, duration, id, reason)
+
+	return id, nil
+}
@@ -0,0 +1,255 @@
+// 该模块实现了通过即时通讯工具的群机器人发送告警通知。
+// @Author: Haart
+// @Created: 2026-10-18
+package alert
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	_types "com.cne/ai-tracking-monitor/types"
+)
+
+// 表示群机器人消息的格式。
+type ChatFormat int
+
+const (
+	FormatDingTalk ChatFormat = 1 // 钉钉。
+	FormatWeCom    ChatFormat = 2 // 企业微信。
+	FormatFeishu   ChatFormat = 3 // 飞书/Lark。
+	FormatSlack    ChatFormat = 4 // Slack。
+)
+
+func (f *ChatFormat) String() string {
+	if *f == FormatDingTalk {
+		return "DINGTALK"
+	} else if *f == FormatWeCom {
+		return "WECOM"
+	} else if *f == FormatFeishu {
+		return "FEISHU"
+	} else if *f == FormatSlack {
+		return "SLACK"
+	} else {
+		return ""
+	}
+}
+
+// 将字符串解析为ChatFormat
+// s 待解析的字符串，会被自动去除首尾空格，然后变为大写。
+// 返回解析结果。
+func ParseChatFormat(s string) (ChatFormat, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+
+	if s == "DINGTALK" {
+		return FormatDingTalk, nil
+	} else if s == "WECOM" || s == "WEWORK" {
+		return FormatWeCom, nil
+	} else if s == "FEISHU" || s == "LARK" {
+		return FormatFeishu, nil
+	} else if s == "SLACK" {
+		return FormatSlack, nil
+	} else {
+		return 0, fmt.Errorf("unkown chat format: %s", s)
+	}
+}
+
+func (f *ChatFormat) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.String())
+}
+
+func (f *ChatFormat) UnmarshalJSON(b []byte) error {
+	s := ""
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	} else if ff, err := ParseChatFormat(s); err != nil {
+		return err
+	} else {
+		*f = ff
+		return nil
+	}
+}
+
+// 生成告警消息的标题和正文。
+// ev 告警事件。
+// markdown 正文是否使用markdown格式。
+// 返回标题和正文。
+func formatMessage(ev *Event, markdown bool) (string, string) {
+	title := ""
+	if ev.Kind == KindRecovered {
+		title = fmt.Sprintf("【爬虫恢复】%s(%s)", ev.CrawlerName, ev.CarrierCode)
+	} else {
+		title = fmt.Sprintf("【爬虫告警】%s(%s)", ev.CrawlerName, ev.CarrierCode)
+	}
+
+	lines := []string{
+		fmt.Sprintf("爬虫: %s (id=%d)", ev.CrawlerName, ev.CrawlerId),
+		fmt.Sprintf("运输商: %s", ev.CarrierCode),
+		fmt.Sprintf("监控单号: %s", ev.HeartBeatNo),
+		fmt.Sprintf("状态: %s → %s (%s)", ev.FromState, ev.ToState, ev.Reason),
+	}
+	if ev.ResultNote != "" {
+		lines = append(lines, fmt.Sprintf("结果: %s", ev.ResultNote))
+	}
+	lines = append(lines,
+		fmt.Sprintf("近期统计: 成功 %d 次, 失败 %d 次", ev.CountOfOk, ev.CountOfError),
+		fmt.Sprintf("时间: %s", ev.Time.Format("2006-01-02 15:04:05")),
+	)
+
+	if markdown {
+		return title, "### " + title + "\n\n- " + strings.Join(lines, "\n- ")
+	} else {
+		return title, title + "\n" + strings.Join(lines, "\n")
+	}
+}
+
+// 计算HMAC-SHA256签名，并使用Base64编码。
+func signHmacSHA256(key, message string) string {
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(message))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// 表示通过群机器人发送告警通知的渠道。
+type ChatNotifier struct {
+	name   string           // 通知渠道的名字。
+	format ChatFormat       // 消息的格式。
+	url    string           // 群机器人的Webhook地址。
+	secret string           // 群机器人的签名密钥，如果为空则不签名。
+	client *http.Client     // HTTP客户端。
+	now    func() time.Time // 获取当前时间，用于计算签名。
+}
+
+// 创建群机器人通知渠道。
+// name 通知渠道的名字。
+// format 消息的格式。
+// url 群机器人的Webhook地址。
+// secret 群机器人的签名密钥，仅钉钉和飞书支持，如果为空则不签名。
+// timeout 请求的超时时间。
+func NewChatNotifier(name string, format ChatFormat, url, secret string, timeout time.Duration) (*ChatNotifier, error) {
+	if strings.TrimSpace(url) == "" {
+		return nil, _types.NewFieldError("Url", "url of chat %s should not be empty", name)
+	}
+	if format.String() == "" {
+		return nil, _types.NewFieldError("Format", "format of chat %s should not be empty", name)
+	}
+	if secret != "" && format != FormatDingTalk && format != FormatFeishu {
+		return nil, _types.NewFieldError("Secret", "chat %s does not support signing", name)
+	}
+
+	return &ChatNotifier{
+		name:   name,
+		format: format,
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: timeout},
+		now:    time.Now,
+	}, nil
+}
+
+func (cn *ChatNotifier) Name() string {
+	return cn.name
+}
+
+func (cn *ChatNotifier) Notify(ev *Event) error {
+	targetUrl, payload, err := cn.build(ev)
+	if err != nil {
+		return err
+	}
+
+	if body, err := json.Marshal(payload); err != nil {
+		return err
+	} else if rspBody, err := postJson(cn.client, targetUrl, nil, body); err != nil {
+		return err
+	} else {
+		return cn.checkResponse(rspBody)
+	}
+}
+
+// 生成请求的地址和请求体。
+func (cn *ChatNotifier) build(ev *Event) (string, interface{}, error) {
+	switch cn.format {
+	case FormatDingTalk:
+		title, text := formatMessage(ev, true)
+		targetUrl := cn.url
+		if cn.secret != "" {
+			timestamp := strconv.FormatInt(cn.now().UnixMilli(), 10)
+			sign := signHmacSHA256(cn.secret, timestamp+"\n"+cn.secret)
+			if u, err := url.Parse(cn.url); err != nil {
+				return "", nil, err
+			} else {
+				q := u.Query()
+				q.Set("timestamp", timestamp)
+				q.Set("sign", sign)
+				u.RawQuery = q.Encode()
+				targetUrl = u.String()
+			}
+		}
+		return targetUrl, map[string]interface{}{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"title": title, "text": text},
+		}, nil
+	case FormatWeCom:
+		_, text := formatMessage(ev, true)
+		return cn.url, map[string]interface{}{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"content": text},
+		}, nil
+	case FormatFeishu:
+		_, text := formatMessage(ev, false)
+		payload := map[string]interface{}{
+			"msg_type": "text",
+			"content":  map[string]string{"text": text},
+		}
+		if cn.secret != "" {
+			// 飞书的签名使用时间戳和密钥作为HMAC的密钥，对空消息签名。
+			timestamp := strconv.FormatInt(cn.now().Unix(), 10)
+			payload["timestamp"] = timestamp
+			payload["sign"] = signHmacSHA256(timestamp+"\n"+cn.secret, "")
+		}
+		return cn.url, payload, nil
+	case FormatSlack:
+		_, text := formatMessage(ev, false)
+		return cn.url, map[string]interface{}{
+			"text": text,
+		}, nil
+	default:
+		return "", nil, fmt.Errorf("unkown chat format: %d", cn.format)
+	}
+}
+
+// 检查群机器人的响应。
+// 钉钉和企业微信返回`errcode`，飞书返回`code`（旧版本返回`StatusCode`），非0表示失败；Slack返回纯文本`ok`。
+func (cn *ChatNotifier) checkResponse(rspBody []byte) error {
+	if cn.format == FormatSlack {
+		return nil
+	}
+
+	rsp := struct {
+		ErrCode    *int   `json:"errcode"`
+		ErrMsg     string `json:"errmsg"`
+		Code       *int   `json:"code"`
+		Msg        string `json:"msg"`
+		StatusCode *int   `json:"StatusCode"`
+	}{}
+	if err := json.Unmarshal(rspBody, &rsp); err != nil {
+		return fmt.Errorf("cannot parse response of chat %s: %w", cn.name, err)
+	}
+
+	if rsp.ErrCode != nil && *rsp.ErrCode != 0 {
+		return fmt.Errorf("chat %s returns error %d: %s", cn.name, *rsp.ErrCode, rsp.ErrMsg)
+	} else if rsp.Code != nil && *rsp.Code != 0 {
+		return fmt.Errorf("chat %s returns error %d: %s", cn.name, *rsp.Code, rsp.Msg)
+	} else if rsp.StatusCode != nil && *rsp.StatusCode != 0 {
+		return fmt.Errorf("chat %s returns error %d", cn.name, *rsp.StatusCode)
+	} else {
+		return nil
+	}
+}
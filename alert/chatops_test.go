@@ -0,0 +1,225 @@
+package alert
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 记录群机器人收到的请求。
+type chatRequest struct {
+	query url.Values
+	body  map[string]interface{}
+}
+
+// 启动模拟群机器人的HTTP服务。
+// status 响应的状态码。
+// rspBody 响应体。
+func newChatServer(t *testing.T, status int, rspBody string) (*httptest.Server, chan *chatRequest) {
+	t.Helper()
+
+	requests := make(chan *chatRequest, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body := make(map[string]interface{})
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Errorf("request body is not json: %s", b)
+		}
+		if ct := r.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+			t.Errorf("unexpected content type: %s", ct)
+		}
+		requests <- &chatRequest{query: r.URL.Query(), body: body}
+
+		w.WriteHeader(status)
+		w.Write([]byte(rspBody))
+	}))
+	t.Cleanup(s.Close)
+
+	return s, requests
+}
+
+func newTestEvent() *Event {
+	return &Event{
+		Kind:         KindDown,
+		CrawlerId:    7,
+		CrawlerName:  "HB1",
+		CarrierCode:  "DHL",
+		HeartBeatNo:  "JD0001",
+		ResultNote:   "no tracking",
+		CountOfOk:    1,
+		CountOfError: 4,
+		FromState:    "OK",
+		ToState:      "DOWN",
+		Reason:       "3 consecutive failure(s)",
+		Time:         time.Date(2026, 10, 18, 8, 0, 0, 0, time.Local),
+	}
+}
+
+func hmacBase64(key, message string) string {
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(message))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func TestChatNotifierPayloads(t *testing.T) {
+	now := time.Date(2026, 10, 18, 8, 0, 1, 234000000, time.UTC)
+
+	cases := []struct {
+		name    string
+		format  ChatFormat
+		secret  string
+		rspBody string
+		check   func(t *testing.T, req *chatRequest)
+	}{
+		{
+			name:    "dingtalk",
+			format:  FormatDingTalk,
+			secret:  "SECdingtalk",
+			rspBody: `{"errcode":0,"errmsg":"ok"}`,
+			check: func(t *testing.T, req *chatRequest) {
+				timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+				if got := req.query.Get("timestamp"); got != timestamp {
+					t.Errorf("expected timestamp %s but got %s", timestamp, got)
+				}
+				if got, want := req.query.Get("sign"), hmacBase64("SECdingtalk", timestamp+"\nSECdingtalk"); got != want {
+					t.Errorf("expected sign %s but got %s", want, got)
+				}
+				if req.body["msgtype"] != "markdown" {
+					t.Errorf("unexpected msgtype: %v", req.body["msgtype"])
+				}
+				md := req.body["markdown"].(map[string]interface{})
+				if !strings.Contains(md["title"].(string), "HB1(DHL)") || !strings.Contains(md["text"].(string), "OK → DOWN") {
+					t.Errorf("unexpected markdown: %v", md)
+				}
+			},
+		},
+		{
+			name:    "dingtalk without secret",
+			format:  FormatDingTalk,
+			rspBody: `{"errcode":0,"errmsg":"ok"}`,
+			check: func(t *testing.T, req *chatRequest) {
+				if req.query.Get("sign") != "" || req.query.Get("timestamp") != "" {
+					t.Errorf("unexpected signing: %v", req.query)
+				}
+			},
+		},
+		{
+			name:    "feishu",
+			format:  FormatFeishu,
+			secret:  "feishu-secret",
+			rspBody: `{"code":0,"msg":"success"}`,
+			check: func(t *testing.T, req *chatRequest) {
+				timestamp := strconv.FormatInt(now.Unix(), 10)
+				if got := req.body["timestamp"]; got != timestamp {
+					t.Errorf("expected timestamp %s but got %v", timestamp, got)
+				}
+				if got, want := req.body["sign"], hmacBase64(timestamp+"\nfeishu-secret", ""); got != want {
+					t.Errorf("expected sign %s but got %v", want, got)
+				}
+				if req.body["msg_type"] != "text" {
+					t.Errorf("unexpected msg_type: %v", req.body["msg_type"])
+				}
+				content := req.body["content"].(map[string]interface{})
+				if !strings.Contains(content["text"].(string), "监控单号: JD0001") {
+					t.Errorf("unexpected content: %v", content)
+				}
+			},
+		},
+		{
+			name:    "wecom",
+			format:  FormatWeCom,
+			rspBody: `{"errcode":0,"errmsg":"ok"}`,
+			check: func(t *testing.T, req *chatRequest) {
+				if req.body["msgtype"] != "markdown" {
+					t.Errorf("unexpected msgtype: %v", req.body["msgtype"])
+				}
+				md := req.body["markdown"].(map[string]interface{})
+				if !strings.Contains(md["content"].(string), "### 【爬虫告警】HB1(DHL)") {
+					t.Errorf("unexpected markdown: %v", md)
+				}
+			},
+		},
+		{
+			name:    "slack",
+			format:  FormatSlack,
+			rspBody: "ok",
+			check: func(t *testing.T, req *chatRequest) {
+				if text, _ := req.body["text"].(string); !strings.HasPrefix(text, "【爬虫告警】HB1(DHL)\n") {
+					t.Errorf("unexpected text: %q", text)
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			s, requests := newChatServer(t, http.StatusOK, c.rspBody)
+
+			cn, err := NewChatNotifier(c.name, c.format, s.URL+"/robot/send?access_token=abc", c.secret, 5*time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cn.now = func() time.Time { return now }
+
+			if err := cn.Notify(newTestEvent()); err != nil {
+				t.Fatalf("notify failed: %s", err)
+			}
+
+			req := <-requests
+			if req.query.Get("access_token") != "abc" {
+				t.Errorf("access token is lost: %v", req.query)
+			}
+			c.check(t, req)
+		})
+	}
+}
+
+func TestChatNotifierErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		format  ChatFormat
+		status  int
+		rspBody string
+	}{
+		{"dingtalk errcode", FormatDingTalk, http.StatusOK, `{"errcode":310000,"errmsg":"sign not match"}`},
+		{"wecom errcode", FormatWeCom, http.StatusOK, `{"errcode":93000,"errmsg":"invalid webhook url"}`},
+		{"feishu code", FormatFeishu, http.StatusOK, `{"code":19021,"msg":"sign match fail"}`},
+		{"feishu legacy status code", FormatFeishu, http.StatusOK, `{"StatusCode":1,"StatusMessage":"fail"}`},
+		{"not json", FormatDingTalk, http.StatusOK, `<html></html>`},
+		{"slack invalid token", FormatSlack, http.StatusForbidden, "invalid_token"},
+		{"server error", FormatWeCom, http.StatusInternalServerError, "oops"},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			s, requests := newChatServer(t, c.status, c.rspBody)
+
+			cn, err := NewChatNotifier(c.name, c.format, s.URL, "", 5*time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := cn.Notify(newTestEvent()); err == nil {
+				t.Fatalf("expected error for response %d %s", c.status, c.rspBody)
+			}
+			<-requests
+		})
+	}
+}
+
+func TestChatNotifierRejectsUnsupportedSigning(t *testing.T) {
+	if _, err := NewChatNotifier("wecom", FormatWeCom, "http://localhost", "secret", time.Second); err == nil {
+		t.Fatal("expected error for signing wecom")
+	}
+}
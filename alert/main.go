@@ -0,0 +1,266 @@
+// 该模块定义了告警事件和告警分发器。
+// @Author: Haart
+// @Created: 2026-10-18
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 表示告警的类别。
+type Kind int
+
+const (
+	KindDown      Kind = 1 // 爬虫变为不可用。
+	KindRecovered Kind = 2 // 爬虫恢复可用。
+)
+
+func (k Kind) String() string {
+	if k == KindDown {
+		return "DOWN"
+	} else if k == KindRecovered {
+		return "RECOVERED"
+	} else {
+		return ""
+	}
+}
+
+// 将字符串解析为Kind
+// s 待解析的字符串，会被自动去除首尾空格，然后变为大写。
+// 返回解析结果。
+func ParseKind(s string) (Kind, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+
+	if s == "DOWN" {
+		return KindDown, nil
+	} else if s == "RECOVERED" {
+		return KindRecovered, nil
+	} else {
+		return 0, fmt.Errorf("unkown alert kind: %s", s)
+	}
+}
+
+func (k Kind) MarshalJSON() ([]byte, error) {
+	return json.Marshal(k.String())
+}
+
+// 表示一个告警事件，对应爬虫可用性的一次变化。
+type Event struct {
+	Kind         Kind      // 告警类别。
+	CrawlerId    int64     // 爬虫ID。
+	CrawlerName  string    // 爬虫名称。
+	CarrierCode  string    // 运输商编号。
+	HeartBeatNo  string    // 监控单号。
+	ResultNote   string    // 最近一次监控结果的说明。
+	CountOfOk    int       // 时间窗口内成功的次数。
+	CountOfError int       // 时间窗口内失败的次数。
+	FromState    string    // 迁移前的健康状态。
+	ToState      string    // 迁移后的健康状态。
+	Reason       string    // 迁移的原因。
+	Time         time.Time // 发生的时间。
+}
+
+// 表示告警通知渠道。
+type Notifier interface {
+	// 获取通知渠道的名字。
+	Name() string
+
+	// 发送告警通知。
+	// ev 告警事件。
+	Notify(ev *Event) error
+}
+
+// 表示可以将多个告警事件合并为一条通知的渠道。
+type BatchNotifier interface {
+	// 获取通知渠道的名字。
+	Name() string
+
+	// 将多个告警事件合并为一条通知发送。
+	// evs 告警事件。
+	NotifyBatch(evs []*Event) error
+}
+
+// 表示一次告警通知的投递结果。
+type Delivery struct {
+	Event    *Event    // 告警事件。
+	Notifier string    // 通知渠道的名字。
+	Attempts int       // 尝试的次数。
+	Err      error     // 最后一次尝试的错误，如果投递成功则为nil。
+	Time     time.Time // 投递结束的时间。
+}
+
+// 表示汇总通知，将一段时间内的告警事件合并为一条通知。
+type digest struct {
+	notifier BatchNotifier // 通知渠道。
+	period   time.Duration // 汇总的周期。
+	events   []*Event      // 尚未发送的告警事件。
+	lock     sync.Mutex    // 同步锁。
+}
+
+// 取出所有尚未发送的告警事件。
+func (dg *digest) take() []*Event {
+	dg.lock.Lock()
+	defer dg.lock.Unlock()
+
+	evs := dg.events
+	dg.events = nil
+	return evs
+}
+
+func (dg *digest) add(ev *Event) {
+	dg.lock.Lock()
+	defer dg.lock.Unlock()
+
+	dg.events = append(dg.events, ev)
+}
+
+// 表示告警分发器，将告警事件发送到所有通知渠道。
+type Dispatcher struct {
+	notifiers     []Notifier      // 逐条发送的通知渠道。
+	digests       []*digest       // 汇总发送的通知渠道。
+	retries       int             // 投递失败时的重试次数。
+	retryInterval time.Duration   // 重试的间隔。
+	onDelivered   func(*Delivery) // 投递结束时的回调，用于记录投递结果。
+	wg            sync.WaitGroup  // 用于等待所有投递结束。
+	digestWg      sync.WaitGroup  // 用于等待所有汇总routine退出。
+	quit          chan struct{}   // 用于通知汇总routine退出。
+	closeOnce     sync.Once       // 保证只关闭一次。
+}
+
+// 创建告警分发器。
+// notifiers 所有逐条发送的通知渠道。
+// retries 投递失败时的重试次数。
+// retryInterval 重试的间隔。
+// onDelivered 投递结束时的回调，可以为nil。
+func NewDispatcher(notifiers []Notifier, retries int, retryInterval time.Duration, onDelivered func(*Delivery)) *Dispatcher {
+	return &Dispatcher{
+		notifiers:     notifiers,
+		digests:       make([]*digest, 0),
+		retries:       retries,
+		retryInterval: retryInterval,
+		onDelivered:   onDelivered,
+		quit:          make(chan struct{}),
+	}
+}
+
+// 添加汇总发送的通知渠道，必须在 `Dispatch` 之前调用。
+// n 通知渠道。
+// period 汇总的周期，每个周期内的所有告警事件合并为一条通知。
+func (d *Dispatcher) AddDigest(n BatchNotifier, period time.Duration) {
+	dg := &digest{notifier: n, period: period}
+	d.digests = append(d.digests, dg)
+
+	d.digestWg.Add(1)
+	go func() {
+		defer d.digestWg.Done()
+
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-d.quit:
+				return
+			case <-ticker.C:
+				d.flushDigest(dg)
+			}
+		}
+	}()
+}
+
+// 异步分发告警事件。
+// ev 告警事件。
+func (d *Dispatcher) Dispatch(ev *Event) {
+	for _, n := range d.notifiers {
+		n := n
+		d.wg.Add(1)
+		go d.deliver(n.Name(), []*Event{ev}, func() error { return n.Notify(ev) })
+	}
+
+	for _, dg := range d.digests {
+		dg.add(ev)
+	}
+}
+
+// 立即发送所有汇总通知中尚未发送的告警事件，并等待所有投递结束。
+func (d *Dispatcher) Flush() {
+	for _, dg := range d.digests {
+		d.flushDigest(dg)
+	}
+
+	d.wg.Wait()
+}
+
+// 等待所有已分发的告警事件投递结束。
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// 关闭分发器，停止汇总routine，发送所有尚未发送的告警事件并等待投递结束。
+func (d *Dispatcher) Close() {
+	d.closeOnce.Do(func() {
+		close(d.quit)
+		d.digestWg.Wait()
+	})
+
+	d.Flush()
+}
+
+func (d *Dispatcher) flushDigest(dg *digest) {
+	evs := dg.take()
+	if len(evs) == 0 {
+		return
+	}
+
+	d.wg.Add(1)
+	go d.deliver(dg.notifier.Name(), evs, func() error { return dg.notifier.NotifyBatch(evs) })
+}
+
+// 投递告警通知，失败时重试。
+// name 通知渠道的名字。
+// evs 本次投递包含的告警事件。
+// send 执行投递。
+func (d *Dispatcher) deliver(name string, evs []*Event, send func() error) {
+	defer d.wg.Done()
+
+	attempts := 0
+	var err error
+	for {
+		attempts++
+		err = d.send(send)
+		if err == nil || attempts > d.retries {
+			break
+		}
+
+		log.Printf("[WARN] Cannot notify %d event(s) by %s (attempt %d): %s\n", len(evs), name, attempts, err)
+		time.Sleep(d.retryInterval)
+	}
+	doneTime := time.Now()
+
+	if err != nil {
+		log.Printf("[ERROR] Failed to notify %d event(s) by %s after %d attempt(s): %s\n", len(evs), name, attempts, err)
+	} else {
+		log.Printf("[INFO] Notified %d event(s) by %s\n", len(evs), name)
+	}
+
+	if d.onDelivered != nil {
+		for _, ev := range evs {
+			d.onDelivered(&Delivery{Event: ev, Notifier: name, Attempts: attempts, Err: err, Time: doneTime})
+		}
+	}
+}
+
+func (d *Dispatcher) send(send func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("notifier panic: %v", r)
+		}
+	}()
+
+	return send()
+}
@@ -0,0 +1,87 @@
+package alert
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 前若干次发送失败的通知渠道。
+type flakyNotifier struct {
+	failures int         // 失败的次数，小于0表示总是失败。
+	panics   bool        // 失败时是否引发异常，而不是返回错误。
+	lock     sync.Mutex  // 同步锁。
+	times    []time.Time // 每次发送的时间。
+}
+
+func (n *flakyNotifier) Name() string {
+	return "flaky"
+}
+
+func (n *flakyNotifier) Notify(ev *Event) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.times = append(n.times, time.Now())
+	if n.failures < 0 || len(n.times) <= n.failures {
+		if n.panics {
+			panic("broken notifier")
+		}
+		return fmt.Errorf("attempt %d failed", len(n.times))
+	}
+
+	return nil
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	const retryInterval = 20 * time.Millisecond
+
+	cases := []struct {
+		name     string
+		failures int
+		panics   bool
+		retries  int
+		attempts int
+		failed   bool
+	}{
+		{"success", 0, false, 2, 1, false},
+		{"recovered", 2, false, 2, 3, false},
+		{"exhausted", -1, false, 2, 3, true},
+		{"no retry", -1, false, 0, 1, true},
+		{"panic", 1, true, 1, 2, false},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			n := &flakyNotifier{failures: c.failures, panics: c.panics}
+			deliveries := make([]*Delivery, 0)
+			d := NewDispatcher([]Notifier{n}, c.retries, retryInterval, func(dl *Delivery) { deliveries = append(deliveries, dl) })
+
+			d.Dispatch(&Event{Kind: KindDown, CrawlerId: 1})
+			d.Wait()
+
+			if len(deliveries) != 1 {
+				t.Fatalf("expected 1 delivery, but got %d", len(deliveries))
+			}
+			dl := deliveries[0]
+			if dl.Attempts != c.attempts || len(n.times) != c.attempts {
+				t.Errorf("expected %d attempt(s), but got %d (notified %d)", c.attempts, dl.Attempts, len(n.times))
+			}
+			if (dl.Err != nil) != c.failed {
+				t.Errorf("delivery should be failed: %v, but got %v", c.failed, dl.Err)
+			}
+			if dl.Notifier != "flaky" || dl.Time.IsZero() {
+				t.Errorf("unexpected delivery %+v", dl)
+			}
+
+			// 两次尝试之间至少等待重试的间隔。
+			for i := 1; i < len(n.times); i++ {
+				if d := n.times[i].Sub(n.times[i-1]); d < retryInterval {
+					t.Errorf("attempt %d is only %s after the previous one", i+1, d)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+// 该模块实现了通过SMTP发送告警邮件。
+// @Author: Haart
+// @Created: 2026-10-18
+package alert
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	_types "com.cne/ai-tracking-monitor/types"
+)
+
+// 表示SMTP连接的加密方式。
+type SmtpSecurity int
+
+const (
+	SecurityNone     SmtpSecurity = 0 // 不加密。
+	SecurityStartTLS SmtpSecurity = 1 // 建立连接之后通过STARTTLS加密。
+	SecurityTLS      SmtpSecurity = 2 // 直接建立TLS连接。
+)
+
+func (s *SmtpSecurity) String() string {
+	if *s == SecurityNone {
+		return "NONE"
+	} else if *s == SecurityStartTLS {
+		return "STARTTLS"
+	} else if *s == SecurityTLS {
+		return "TLS"
+	} else {
+		return ""
+	}
+}
+
+// 将字符串解析为SmtpSecurity
+// s 待解析的字符串，会被自动去除首尾空格，然后变为大写。
+// 返回解析结果。
+func ParseSmtpSecurity(s string) (SmtpSecurity, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+
+	if s == "NONE" || s == "" {
+		return SecurityNone, nil
+	} else if s == "STARTTLS" {
+		return SecurityStartTLS, nil
+	} else if s == "TLS" || s == "SSL" {
+		return SecurityTLS, nil
+	} else {
+		return 0, fmt.Errorf("unkown smtp security: %s", s)
+	}
+}
+
+func (s *SmtpSecurity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *SmtpSecurity) UnmarshalJSON(b []byte) error {
+	ss := ""
+	if err := json.Unmarshal(b, &ss); err != nil {
+		return err
+	} else if v, err := ParseSmtpSecurity(ss); err != nil {
+		return err
+	} else {
+		*s = v
+		return nil
+	}
+}
+
+var (
+	mailHtmlTemplate = template.Must(template.New("mail").Parse(`<html>
+<body>
+<h3>{{.Title}}</h3>
+<table border="1" cellspacing="0" cellpadding="4">
+<tr><th>时间</th><th>告警</th><th>爬虫</th><th>运输商</th><th>监控单号</th><th>状态</th><th>结果</th><th>成功/失败</th></tr>
+{{range .Events}}<tr>
+<td>{{.Time.Format "2006-01-02 15:04:05"}}</td>
+<td>{{.Kind}}</td>
+<td>{{.CrawlerName}} (id={{.CrawlerId}})</td>
+<td>{{.CarrierCode}}</td>
+<td>{{.HeartBeatNo}}</td>
+<td>{{.FromState}} &rarr; {{.ToState}}<br/>{{.Reason}}</td>
+<td>{{.ResultNote}}</td>
+<td>{{.CountOfOk}} / {{.CountOfError}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+)
+
+// 表示通过SMTP发送告警邮件的渠道。
+type SmtpNotifier struct {
+	name               string        // 通知渠道的名字。
+	host               string        // SMTP服务器地址。
+	port               int           // SMTP服务器端口号。
+	security           SmtpSecurity  // 连接的加密方式。
+	username           string        // 登录用户名，如果为空则不登录。
+	password           string        // 登录口令。
+	from               string        // 发件人。
+	to                 []string      // 收件人。
+	timeout            time.Duration // 发送邮件的超时时间。
+	insecureSkipVerify bool          // 是否跳过服务器证书校验。
+}
+
+// 创建SMTP通知渠道。
+// name 通知渠道的名字。
+// host SMTP服务器地址。
+// port SMTP服务器端口号。
+// security 连接的加密方式。
+// username 登录用户名，如果为空则不登录。
+// password 登录口令。
+// from 发件人。
+// to 收件人。
+// timeout 发送邮件的超时时间。
+// insecureSkipVerify 是否跳过服务器证书校验。
+func NewSmtpNotifier(name, host string, port int, security SmtpSecurity, username, password, from string, to []string, timeout time.Duration, insecureSkipVerify bool) (*SmtpNotifier, error) {
+	if strings.TrimSpace(host) == "" {
+		return nil, _types.NewFieldError("Host", "host of smtp %s should not be empty", name)
+	}
+	if port <= 0 {
+		return nil, _types.NewFieldError("Port", "port of smtp %s should be positive", name)
+	}
+	if strings.TrimSpace(from) == "" {
+		return nil, _types.NewFieldError("From", "sender of smtp %s should not be empty", name)
+	}
+	if len(to) == 0 {
+		return nil, _types.NewFieldError("To", "recipients of smtp %s should not be empty", name)
+	}
+
+	return &SmtpNotifier{
+		name:               name,
+		host:               host,
+		port:               port,
+		security:           security,
+		username:           username,
+		password:           password,
+		from:               from,
+		to:                 to,
+		timeout:            timeout,
+		insecureSkipVerify: insecureSkipVerify,
+	}, nil
+}
+
+func (sn *SmtpNotifier) Name() string {
+	return sn.name
+}
+
+func (sn *SmtpNotifier) Notify(ev *Event) error {
+	title, text := formatMessage(ev, false)
+	return sn.send(title, text, []*Event{ev})
+}
+
+func (sn *SmtpNotifier) NotifyBatch(evs []*Event) error {
+	countOfDown := 0
+	countOfRecovered := 0
+	texts := make([]string, 0, len(evs))
+	for _, ev := range evs {
+		if ev.Kind == KindRecovered {
+			countOfRecovered++
+		} else {
+			countOfDown++
+		}
+
+		_, text := formatMessage(ev, false)
+		texts = append(texts, text)
+	}
+
+	title := fmt.Sprintf("【爬虫告警汇总】%d 个爬虫不可用, %d 个爬虫恢复", countOfDown, countOfRecovered)
+	return sn.send(title, title+"\n\n"+strings.Join(texts, "\n\n"), evs)
+}
+
+// 生成并发送邮件。
+// title 邮件标题。
+// text 纯文本正文。
+// evs 告警事件，用于生成HTML正文。
+func (sn *SmtpNotifier) send(title, text string, evs []*Event) error {
+	htmlBuf := bytes.Buffer{}
+	if err := mailHtmlTemplate.Execute(&htmlBuf, map[string]interface{}{"Title": title, "Events": evs}); err != nil {
+		return err
+	}
+
+	if msg, err := buildMail(sn.from, sn.to, title, text, htmlBuf.String()); err != nil {
+		return err
+	} else {
+		return sn.sendMail(msg)
+	}
+}
+
+func (sn *SmtpNotifier) sendMail(msg []byte) error {
+	addr := net.JoinHostPort(sn.host, strconv.Itoa(sn.port))
+	tlsConfig := &tls.Config{ServerName: sn.host, InsecureSkipVerify: sn.insecureSkipVerify}
+	dialer := &net.Dialer{Timeout: sn.timeout}
+
+	var conn net.Conn
+	var err error
+	if sn.security == SecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if sn.timeout > 0 {
+		conn.SetDeadline(time.Now().Add(sn.timeout))
+	}
+
+	c, err := smtp.NewClient(conn, sn.host)
+	if err != nil {
+		return err
+	}
+
+	defer c.Close()
+
+	if sn.security == SecurityStartTLS {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if sn.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", sn.username, sn.password, sn.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(sn.from); err != nil {
+		return err
+	}
+	for _, to := range sn.to {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	if w, err := c.Data(); err != nil {
+		return err
+	} else if _, err := w.Write(msg); err != nil {
+		return err
+	} else if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// 生成同时包含纯文本和HTML正文的邮件。
+// from 发件人。
+// to 收件人。
+// subject 邮件标题。
+// text 纯文本正文。
+// html HTML正文。
+// 返回邮件的完整内容。
+func buildMail(from string, to []string, subject, text, html string) ([]byte, error) {
+	boundaryBytes := make([]byte, 16)
+	if _, err := rand.Read(boundaryBytes); err != nil {
+		return nil, err
+	}
+	boundary := hex.EncodeToString(boundaryBytes)
+
+	buf := bytes.Buffer{}
+	buf.WriteString("From: " + from + "\r\n")
+	buf.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	buf.WriteString("Subject: " + mime.BEncoding.Encode("utf-8", subject) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: multipart/alternative; boundary=" + boundary + "\r\n")
+	buf.WriteString("\r\n")
+
+	writePart := func(contentType, body string) {
+		buf.WriteString("--" + boundary + "\r\n")
+		buf.WriteString("Content-Type: " + contentType + "; charset=utf-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: base64\r\n")
+		buf.WriteString("\r\n")
+
+		encoded := base64.StdEncoding.EncodeToString([]byte(body))
+		for len(encoded) > 76 {
+			buf.WriteString(encoded[:76] + "\r\n")
+			encoded = encoded[76:]
+		}
+		buf.WriteString(encoded + "\r\n")
+	}
+
+	writePart("text/plain", text)
+	writePart("text/html", html)
+	buf.WriteString("--" + boundary + "--\r\n")
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,420 @@
+package alert
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io/ioutil"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 表示模拟SMTP服务器收到的一封邮件。
+type fakeMail struct {
+	tls      bool     // 发送DATA时连接是否已经加密。
+	auth     string   // AUTH PLAIN的凭证，格式是`username:password`。
+	from     string   // MAIL FROM的地址。
+	rcpt     []string // RCPT TO的地址。
+	data     string   // 邮件的原始内容。
+	startTLS bool     // 是否执行了STARTTLS。
+}
+
+// 模拟SMTP服务器，只实现发送告警邮件需要的命令。
+type fakeSmtpServer struct {
+	listener net.Listener
+	security SmtpSecurity
+	config   *tls.Config
+	mails    chan *fakeMail
+	wg       sync.WaitGroup
+}
+
+func newFakeSmtpServer(t *testing.T, security SmtpSecurity) *fakeSmtpServer {
+	t.Helper()
+
+	config := &tls.Config{Certificates: []tls.Certificate{newSelfSignedCert(t)}}
+
+	var listener net.Listener
+	var err error
+	if security == SecurityTLS {
+		listener, err = tls.Listen("tcp", "127.0.0.1:0", config)
+	} else {
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &fakeSmtpServer{listener: listener, security: security, config: config, mails: make(chan *fakeMail, 16)}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(func() {
+		listener.Close()
+		s.wg.Wait()
+	})
+
+	return s
+}
+
+func (s *fakeSmtpServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSmtpServer) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *fakeSmtpServer) handle(conn net.Conn) {
+	m := &fakeMail{tls: s.security == SecurityTLS}
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n"))
+	}
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch cmd {
+		case "EHLO", "HELO":
+			if s.security == SecurityStartTLS && !m.startTLS {
+				reply("250-fake")
+				reply("250-STARTTLS")
+				reply("250 AUTH PLAIN")
+			} else {
+				reply("250-fake")
+				reply("250 AUTH PLAIN")
+			}
+		case "STARTTLS":
+			reply("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.config)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			r = bufio.NewReader(conn)
+			m.startTLS = true
+			m.tls = true
+		case "AUTH":
+			// AUTH PLAIN base64(identity\0username\0password)
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				reply("501 syntax error")
+				continue
+			}
+			b, _ := base64.StdEncoding.DecodeString(fields[2])
+			parts := strings.Split(string(b), "\x00")
+			if len(parts) != 3 || parts[2] != "pa55" {
+				reply("535 authentication failed")
+				continue
+			}
+			m.auth = parts[1] + ":" + parts[2]
+			reply("235 authenticated")
+		case "MAIL":
+			m.from = strings.Trim(strings.TrimPrefix(line[5:], "FROM:"), "<>")
+			reply("250 ok")
+		case "RCPT":
+			m.rcpt = append(m.rcpt, strings.Trim(strings.TrimPrefix(line[5:], "TO:"), "<>"))
+			reply("250 ok")
+		case "DATA":
+			reply("354 end with <CRLF>.<CRLF>")
+			sb := strings.Builder{}
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				sb.WriteString(strings.TrimPrefix(l, "."))
+			}
+			m.data = sb.String()
+			reply("250 queued")
+			s.mails <- m
+			m = &fakeMail{tls: m.tls, startTLS: m.startTLS, auth: m.auth}
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// 等待下一封邮件。
+func (s *fakeSmtpServer) next(t *testing.T) *fakeMail {
+	t.Helper()
+
+	select {
+	case m := <-s.mails:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mail received")
+		return nil
+	}
+}
+
+func newSelfSignedCert(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// 解析邮件，返回解码之后的标题、纯文本正文和HTML正文。
+func parseFakeMail(t *testing.T, data string) (string, string, string) {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("unexpected content type: %s", msg.Header.Get("Content-Type"))
+	}
+
+	bodies := make(map[string]string)
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		if p.Header.Get("Content-Transfer-Encoding") != "base64" {
+			t.Errorf("unexpected transfer encoding: %s", p.Header.Get("Content-Transfer-Encoding"))
+		}
+		ct, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		b, err := ioutil.ReadAll(base64.NewDecoder(base64.StdEncoding, p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies[ct] = string(b)
+	}
+
+	if bodies["text/plain"] == "" || bodies["text/html"] == "" {
+		t.Fatalf("both text and html parts expected, but got %v", bodies)
+	}
+
+	return subject, bodies["text/plain"], bodies["text/html"]
+}
+
+func TestSmtpNotifier(t *testing.T) {
+	cases := []struct {
+		name     string
+		security SmtpSecurity
+		username string
+	}{
+		{"plain without auth", SecurityNone, ""},
+		{"plain with auth", SecurityNone, "monitor"},
+		{"starttls with auth", SecurityStartTLS, "monitor"},
+		{"tls with auth", SecurityTLS, "monitor"},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			s := newFakeSmtpServer(t, c.security)
+
+			sn, err := NewSmtpNotifier("mail", "127.0.0.1", s.port(), c.security, c.username, "pa55", "monitor@example.com", []string{"ops@example.com", "dev@example.com"}, 5*time.Second, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := sn.Notify(newTestEvent()); err != nil {
+				t.Fatalf("notify failed: %s", err)
+			}
+
+			m := s.next(t)
+			if m.tls != (c.security != SecurityNone) {
+				t.Errorf("expected tls %v but got %v", c.security != SecurityNone, m.tls)
+			}
+			if m.startTLS != (c.security == SecurityStartTLS) {
+				t.Errorf("expected starttls %v but got %v", c.security == SecurityStartTLS, m.startTLS)
+			}
+			if c.username != "" && m.auth != "monitor:pa55" {
+				t.Errorf("unexpected auth: %q", m.auth)
+			} else if c.username == "" && m.auth != "" {
+				t.Errorf("unexpected auth: %q", m.auth)
+			}
+			if m.from != "monitor@example.com" || strings.Join(m.rcpt, ",") != "ops@example.com,dev@example.com" {
+				t.Errorf("unexpected envelope: %s -> %v", m.from, m.rcpt)
+			}
+
+			subject, text, html := parseFakeMail(t, m.data)
+			if subject != "【爬虫告警】HB1(DHL)" {
+				t.Errorf("unexpected subject: %s", subject)
+			}
+			if !strings.Contains(text, "状态: OK → DOWN (3 consecutive failure(s))") {
+				t.Errorf("unexpected text: %s", text)
+			}
+			if !strings.Contains(html, "<td>HB1 (id=7)</td>") || !strings.Contains(html, "<td>1 / 4</td>") {
+				t.Errorf("unexpected html: %s", html)
+			}
+		})
+	}
+}
+
+func TestSmtpNotifierRejectedAuth(t *testing.T) {
+	s := newFakeSmtpServer(t, SecurityStartTLS)
+
+	sn, err := NewSmtpNotifier("mail", "127.0.0.1", s.port(), SecurityStartTLS, "monitor", "wrong", "monitor@example.com", []string{"ops@example.com"}, 5*time.Second, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sn.Notify(newTestEvent()); err == nil {
+		t.Fatal("expected error for rejected auth")
+	}
+}
+
+func TestSmtpNotifierVerifiesCertificate(t *testing.T) {
+	s := newFakeSmtpServer(t, SecurityTLS)
+
+	sn, err := NewSmtpNotifier("mail", "127.0.0.1", s.port(), SecurityTLS, "", "", "monitor@example.com", []string{"ops@example.com"}, 5*time.Second, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sn.Notify(newTestEvent()); err == nil {
+		t.Fatal("expected error for self signed certificate")
+	}
+}
+
+func TestDigestSendsEventsAsOneMail(t *testing.T) {
+	s := newFakeSmtpServer(t, SecurityNone)
+
+	sn, err := NewSmtpNotifier("digest", "127.0.0.1", s.port(), SecurityNone, "", "", "monitor@example.com", []string{"ops@example.com"}, 5*time.Second, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deliveries := make(chan *Delivery, 16)
+	d := NewDispatcher(nil, 0, 0, func(dl *Delivery) { deliveries <- dl })
+	d.AddDigest(sn, time.Hour)
+
+	const n = 3
+	for i := 0; i < n; i++ {
+		ev := newTestEvent()
+		ev.CrawlerId = int64(100 + i)
+		if i == n-1 {
+			ev.Kind = KindRecovered
+		}
+		d.Dispatch(ev)
+	}
+
+	// 周期尚未结束，关闭时发送所有尚未发送的告警事件。
+	select {
+	case <-s.mails:
+		t.Fatal("digest should not be sent before period or close")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	d.Close()
+
+	m := s.next(t)
+	subject, text, html := parseFakeMail(t, m.data)
+	if subject != "【爬虫告警汇总】2 个爬虫不可用, 1 个爬虫恢复" {
+		t.Errorf("unexpected subject: %s", subject)
+	}
+	for i := 0; i < n; i++ {
+		if !strings.Contains(text, "(id=10"+string(rune('0'+i))+")") || !strings.Contains(html, "(id=10"+string(rune('0'+i))+")") {
+			t.Errorf("event %d is missing in digest", i)
+		}
+	}
+
+	select {
+	case <-s.mails:
+		t.Fatal("expected exactly one mail")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(deliveries)
+	count := 0
+	for dl := range deliveries {
+		if dl.Err != nil || dl.Notifier != "digest" || dl.Attempts != 1 {
+			t.Errorf("unexpected delivery: %+v", dl)
+		}
+		count++
+	}
+	if count != n {
+		t.Errorf("expected %d deliveries but got %d", n, count)
+	}
+}
+
+func TestDigestFlushesEachPeriod(t *testing.T) {
+	s := newFakeSmtpServer(t, SecurityNone)
+
+	sn, err := NewSmtpNotifier("digest", "127.0.0.1", s.port(), SecurityNone, "", "", "monitor@example.com", []string{"ops@example.com"}, 5*time.Second, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDispatcher(nil, 0, 0, nil)
+	d.AddDigest(sn, 50*time.Millisecond)
+	defer d.Close()
+
+	d.Dispatch(newTestEvent())
+	d.Dispatch(newTestEvent())
+
+	subject, _, _ := parseFakeMail(t, s.next(t).data)
+	if subject != "【爬虫告警汇总】2 个爬虫不可用, 0 个爬虫恢复" {
+		t.Errorf("unexpected subject: %s", subject)
+	}
+}
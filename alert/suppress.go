@@ -0,0 +1,217 @@
+// 该模块实现了告警的抑制规则，包括去重、静默和维护窗口。
+// @Author: Haart
+// @Created: 2026-10-18
+package alert
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	_scheduler "com.cne/ai-tracking-monitor/scheduler"
+	_types "com.cne/ai-tracking-monitor/types"
+)
+
+// 表示静默规则，在指定的时间内不发送告警。
+type Silence struct {
+	Id           int64     // 静默规则的ID。
+	CrawlerId    int64     // 适用的爬虫ID，如果为0则不限爬虫。
+	CarrierCode  string    // 适用的运输商编号，如果为空则不限运输商。
+	Reason       string    // 静默的原因。
+	StartTime    time.Time // 开始时间。
+	EndTime      time.Time // 结束时间。
+	FreezeHealth bool      // 静默期间是否冻结爬虫的健康状态。
+}
+
+// 判断静默规则是否适用于指定的爬虫。
+// crawlerId 爬虫ID。
+// carrierCode 爬虫对应的运输商编号。
+// now 当前时间。
+func (s *Silence) Matches(crawlerId int64, carrierCode string, now time.Time) bool {
+	if now.Before(s.StartTime) || !now.Before(s.EndTime) {
+		return false
+	}
+	if s.CrawlerId != 0 && s.CrawlerId != crawlerId {
+		return false
+	}
+	if s.CarrierCode != "" && !strings.EqualFold(s.CarrierCode, carrierCode) {
+		return false
+	}
+
+	return true
+}
+
+// 表示维护窗口，已知运输商网站不可用的时间段。
+// 维护窗口可以是固定的时间段，也可以是按照cron表达式重复的时间段。
+type Maintenance struct {
+	CarrierCodes []string           // 适用的运输商编号。
+	StartTime    time.Time          // 固定时间段的开始时间。
+	EndTime      time.Time          // 固定时间段的结束时间。
+	Trigger      _scheduler.Trigger // 重复时间段的触发器，如果不为nil则忽略固定时间段。
+	Duration     time.Duration      // 重复时间段的长度。
+	Reason       string             // 维护的原因。
+	FreezeHealth bool               // 维护期间是否冻结爬虫的健康状态。
+}
+
+// 创建维护窗口。
+// carrierCodes 适用的运输商编号。
+// startTime 固定时间段的开始时间。
+// endTime 固定时间段的结束时间。
+// cronExpr 重复时间段的cron表达式，如果不为空则忽略固定时间段。
+// duration 重复时间段的长度。
+// reason 维护的原因。
+// freezeHealth 维护期间是否冻结爬虫的健康状态。
+func NewMaintenance(carrierCodes []string, startTime, endTime time.Time, cronExpr string, duration time.Duration, reason string, freezeHealth bool) (*Maintenance, error) {
+	if len(carrierCodes) == 0 {
+		return nil, _types.NewFieldError("CarrierCodes", "carrier codes of maintenance should not be empty")
+	}
+
+	m := &Maintenance{
+		CarrierCodes: carrierCodes,
+		Reason:       reason,
+		FreezeHealth: freezeHealth,
+	}
+
+	if strings.TrimSpace(cronExpr) != "" {
+		if duration <= 0 {
+			return nil, _types.NewFieldError("Duration", "duration of maintenance should be positive")
+		} else if trigger, err := _scheduler.NewTrigger(0, cronExpr); err != nil {
+			return nil, &_types.FieldError{Field: "Cron", Err: err}
+		} else {
+			m.Trigger = trigger
+			m.Duration = duration
+		}
+	} else if !startTime.Before(endTime) {
+		return nil, _types.NewFieldError("End", "start time of maintenance should be before end time")
+	} else {
+		m.StartTime = startTime
+		m.EndTime = endTime
+	}
+
+	return m, nil
+}
+
+// 判断维护窗口是否适用于指定的运输商。
+// carrierCode 运输商编号。
+// now 当前时间。
+func (m *Maintenance) Matches(carrierCode string, now time.Time) bool {
+	found := false
+	for _, cc := range m.CarrierCodes {
+		if strings.EqualFold(strings.TrimSpace(cc), carrierCode) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false
+	}
+
+	if m.Trigger != nil {
+		// 如果从 `now - Duration` 开始的下一次触发时间不晚于当前时间，说明当前时间位于某个重复时间段内。
+		return !m.Trigger.Next(now.Add(-m.Duration)).After(now)
+	} else {
+		return !now.Before(m.StartTime) && now.Before(m.EndTime)
+	}
+}
+
+// 表示抑制的结果。
+type Suppression struct {
+	Reason       string // 抑制的原因。
+	FreezeHealth bool   // 是否冻结爬虫的健康状态。
+}
+
+// 表示告警抑制器。
+type Suppressor struct {
+	dedupWindow  time.Duration          // 去重的时间窗口，同一爬虫的同类告警在此时间内只发送一次。
+	maintenances []*Maintenance         // 所有维护窗口。
+	silences     []*Silence             // 所有静默规则。
+	lastSent     map[alertKey]time.Time // 每个爬虫每类告警最后的发送时间。
+	lock         sync.Mutex             // 同步锁。
+}
+
+type alertKey struct {
+	crawlerId int64 // 爬虫ID。
+	kind      Kind  // 告警类别。
+}
+
+// 创建告警抑制器。
+// dedupWindow 去重的时间窗口，如果为0则不去重。
+// maintenances 所有维护窗口。
+func NewSuppressor(dedupWindow time.Duration, maintenances []*Maintenance) *Suppressor {
+	return &Suppressor{
+		dedupWindow:  dedupWindow,
+		maintenances: maintenances,
+		silences:     make([]*Silence, 0),
+		lastSent:     make(map[alertKey]time.Time),
+	}
+}
+
+// 采用另一个抑制器的去重时间窗口和维护窗口，保留当前的静默规则和发送记录，通常在重新加载配置时调用。
+// other 另一个抑制器。
+func (s *Suppressor) Adopt(other *Suppressor) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.dedupWindow = other.dedupWindow
+	s.maintenances = other.maintenances
+}
+
+// 替换所有静默规则。
+// silences 新的静默规则。
+func (s *Suppressor) SetSilences(silences []*Silence) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.silences = silences
+}
+
+// 查找适用于指定爬虫的静默规则或者维护窗口。
+// crawlerId 爬虫ID。
+// carrierCode 爬虫对应的运输商编号。
+// now 当前时间。
+// 返回抑制的结果，如果没有适用的静默规则或者维护窗口则返回nil。
+func (s *Suppressor) Match(crawlerId int64, carrierCode string, now time.Time) *Suppression {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var result *Suppression
+	for _, si := range s.silences {
+		if si.Matches(crawlerId, carrierCode, now) {
+			if result == nil {
+				result = &Suppression{Reason: fmt.Sprintf("silenced until %s: %s", si.EndTime.Format("2006-01-02 15:04:05"), si.Reason)}
+			}
+			result.FreezeHealth = result.FreezeHealth || si.FreezeHealth
+		}
+	}
+	for _, m := range s.maintenances {
+		if m.Matches(carrierCode, now) {
+			if result == nil {
+				result = &Suppression{Reason: fmt.Sprintf("in maintenance: %s", m.Reason)}
+			}
+			result.FreezeHealth = result.FreezeHealth || m.FreezeHealth
+		}
+	}
+
+	return result
+}
+
+// 判断是否应当发送告警，如果应当发送则记录发送时间，用于去重。
+// ev 告警事件。
+// 返回是否应当发送，以及不发送的原因。
+func (s *Suppressor) Allow(ev *Event) (bool, string) {
+	if sp := s.Match(ev.CrawlerId, ev.CarrierCode, ev.Time); sp != nil {
+		return false, sp.Reason
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := alertKey{crawlerId: ev.CrawlerId, kind: ev.Kind}
+	if lastTime, ok := s.lastSent[key]; ok && s.dedupWindow > 0 && ev.Time.Sub(lastTime) < s.dedupWindow {
+		return false, fmt.Sprintf("duplicated with alert at %s", lastTime.Format("2006-01-02 15:04:05"))
+	}
+
+	s.lastSent[key] = ev.Time
+	return true, ""
+}
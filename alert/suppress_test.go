@@ -0,0 +1,142 @@
+package alert
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSuppressorDedupWindow(t *testing.T) {
+	base := time.Date(2026, 10, 18, 8, 0, 0, 0, time.Local)
+	s := NewSuppressor(10*time.Minute, nil)
+
+	cases := []struct {
+		kind      Kind
+		crawlerId int64
+		offset    time.Duration
+		allowed   bool
+	}{
+		{KindDown, 1, 0, true},
+		{KindDown, 1, 5 * time.Minute, false},     // 时间窗口内重复。
+		{KindDown, 2, 5 * time.Minute, true},      // 不同的爬虫。
+		{KindRecovered, 1, 6 * time.Minute, true}, // 不同的告警类别。
+		{KindDown, 1, 10 * time.Minute, true},     // 超过时间窗口。
+		{KindDown, 1, 15 * time.Minute, false},    // 从最后的发送时间重新计算时间窗口。
+	}
+
+	for i, c := range cases {
+		allowed, reason := s.Allow(&Event{Kind: c.kind, CrawlerId: c.crawlerId, CarrierCode: "DHL", Time: base.Add(c.offset)})
+		if allowed != c.allowed {
+			t.Errorf("#%d should be allowed: %v, but got %v (%s)", i, c.allowed, allowed, reason)
+		} else if !allowed && !strings.HasPrefix(reason, "duplicated with alert at ") {
+			t.Errorf("#%d unexpected reason: %s", i, reason)
+		}
+	}
+}
+
+func TestSuppressorWithoutDedupWindow(t *testing.T) {
+	s := NewSuppressor(0, nil)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if allowed, reason := s.Allow(&Event{Kind: KindDown, CrawlerId: 1, Time: now}); !allowed {
+			t.Errorf("alert #%d should be allowed without dedup window, but %s", i, reason)
+		}
+	}
+}
+
+func TestSilenceExpiry(t *testing.T) {
+	start := time.Date(2026, 10, 18, 8, 0, 0, 0, time.Local)
+	end := start.Add(time.Hour)
+	s := NewSuppressor(0, nil)
+	s.SetSilences([]*Silence{
+		{Id: 1, CarrierCode: "dhl", Reason: "site upgrade", StartTime: start, EndTime: end, FreezeHealth: true},
+		{Id: 2, CrawlerId: 7, Reason: "known issue", StartTime: start, EndTime: end.Add(time.Hour)},
+	})
+
+	cases := []struct {
+		crawlerId   int64
+		carrierCode string
+		now         time.Time
+		silenced    bool
+		freeze      bool
+	}{
+		{1, "DHL", start.Add(-time.Second), false, false}, // 尚未开始。
+		{1, "DHL", start, true, true},                     // 包含开始时间。
+		{1, "DHL", end.Add(-time.Second), true, true},
+		{1, "DHL", end, false, false}, // 不包含结束时间。
+		{1, "UPS", start, false, false},
+		{7, "UPS", end, true, false}, // 按照爬虫静默，结束时间更晚。
+		{7, "DHL", start, true, true},
+		{7, "UPS", end.Add(time.Hour), false, false},
+	}
+
+	for i, c := range cases {
+		sp := s.Match(c.crawlerId, c.carrierCode, c.now)
+		if (sp != nil) != c.silenced {
+			t.Errorf("#%d should be silenced: %v, but got %+v", i, c.silenced, sp)
+		} else if sp != nil && sp.FreezeHealth != c.freeze {
+			t.Errorf("#%d should freeze health: %v, but got %v", i, c.freeze, sp.FreezeHealth)
+		}
+
+		allowed, _ := s.Allow(&Event{Kind: KindDown, CrawlerId: c.crawlerId, CarrierCode: c.carrierCode, Time: c.now})
+		if allowed == c.silenced {
+			t.Errorf("#%d should be allowed: %v, but got %v", i, !c.silenced, allowed)
+		}
+	}
+}
+
+func TestCronMaintenance(t *testing.T) {
+	// 每天2点开始，持续30分钟。
+	m, err := NewMaintenance([]string{"DHL", " ups "}, time.Time{}, time.Time{}, "0 2 * * *", 30*time.Minute, "nightly", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2026, 10, 18, 0, 0, 0, 0, time.Local)
+	cases := []struct {
+		carrierCode string
+		now         time.Time
+		matched     bool
+	}{
+		{"DHL", day.Add(2*time.Hour - time.Second), false},
+		{"DHL", day.Add(2 * time.Hour), true},
+		{"DHL", day.Add(2*time.Hour + 29*time.Minute), true},
+		{"DHL", day.Add(2*time.Hour + 31*time.Minute), false},
+		{"UPS", day.AddDate(0, 0, 1).Add(2*time.Hour + 10*time.Minute), true}, // 第二天重复。
+		{"FEDEX", day.Add(2*time.Hour + 10*time.Minute), false},
+	}
+
+	for i, c := range cases {
+		if matched := m.Matches(c.carrierCode, c.now); matched != c.matched {
+			t.Errorf("#%d %s at %s should be matched: %v, but got %v", i, c.carrierCode, c.now.Format(time.RFC3339), c.matched, matched)
+		}
+	}
+
+	s := NewSuppressor(0, []*Maintenance{m})
+	if sp := s.Match(1, "DHL", day.Add(2*time.Hour+time.Minute)); sp == nil || !sp.FreezeHealth || sp.Reason != "in maintenance: nightly" {
+		t.Errorf("unexpected suppression %+v", sp)
+	}
+}
+
+func TestNewMaintenanceRejectsIllegalWindows(t *testing.T) {
+	start := time.Date(2026, 10, 18, 8, 0, 0, 0, time.Local)
+
+	cases := []struct {
+		carrierCodes []string
+		start, end   time.Time
+		cron         string
+		duration     time.Duration
+	}{
+		{nil, start, start.Add(time.Hour), "", 0},
+		{[]string{"DHL"}, start, start, "", 0},
+		{[]string{"DHL"}, time.Time{}, time.Time{}, "0 2 * * *", 0},
+		{[]string{"DHL"}, time.Time{}, time.Time{}, "at two", time.Hour},
+	}
+
+	for i, c := range cases {
+		if _, err := NewMaintenance(c.carrierCodes, c.start, c.end, c.cron, c.duration, "", false); err == nil {
+			t.Errorf("#%d should be rejected", i)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// 该模块实现了通过HTTP Webhook发送告警通知。
+// @Author: Haart
+// @Created: 2026-10-18
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	_types "com.cne/ai-tracking-monitor/types"
+)
+
+const (
+	// 默认的告警通知模板，生成JSON格式的请求体。
+	DefaultWebhookTemplate string = `{
+	"kind": {{json .Kind}},
+	"crawlerId": {{.CrawlerId}},
+	"crawlerName": {{json .CrawlerName}},
+	"carrierCode": {{json .CarrierCode}},
+	"heartBeatNo": {{json .HeartBeatNo}},
+	"resultNote": {{json .ResultNote}},
+	"countOfOk": {{.CountOfOk}},
+	"countOfError": {{.CountOfError}},
+	"fromState": {{json .FromState}},
+	"toState": {{json .ToState}},
+	"reason": {{json .Reason}},
+	"time": {{json .Time}}
+}`
+)
+
+var (
+	templateFuncs = template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			if b, err := json.Marshal(v); err != nil {
+				return "", err
+			} else {
+				return string(b), nil
+			}
+		},
+		"time": func(t time.Time) string {
+			return t.Format("2006-01-02 15:04:05")
+		},
+	}
+)
+
+// 解析告警通知模板。
+// name 模板的名字。
+// text 模板的内容，可以使用 `json` 和 `time` 函数。
+// 返回解析后的模板。
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// 使用模板渲染告警事件。
+// t 模板。
+// ev 告警事件。
+// 返回渲染的结果。
+func render(t *template.Template, ev *Event) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := t.Execute(&buf, ev); err != nil {
+		return nil, err
+	} else {
+		return buf.Bytes(), nil
+	}
+}
+
+// 发送JSON格式的请求。
+// client HTTP客户端。
+// url 请求的地址。
+// headers 附加的请求头。
+// body 请求体。
+// 返回响应体。
+func postJson(client *http.Client, url string, headers map[string]string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	if rsp, err := client.Do(req); err != nil {
+		return nil, err
+	} else {
+		defer rsp.Body.Close()
+
+		rspBody, _ := ioutil.ReadAll(io.LimitReader(rsp.Body, 64*1024))
+		if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+			return rspBody, fmt.Errorf("unexpected status %d: %s", rsp.StatusCode, strings.TrimSpace(string(rspBody)))
+		} else {
+			return rspBody, nil
+		}
+	}
+}
+
+// 表示通过HTTP Webhook发送告警通知的渠道。
+type WebhookNotifier struct {
+	name     string             // 通知渠道的名字。
+	url      string             // Webhook的地址。
+	headers  map[string]string  // 附加的请求头。
+	template *template.Template // 请求体的模板。
+	client   *http.Client       // HTTP客户端。
+}
+
+// 创建Webhook通知渠道。
+// name 通知渠道的名字。
+// url Webhook的地址。
+// headers 附加的请求头。
+// tmpl 请求体的模板，如果为空则使用 `DefaultWebhookTemplate`。
+// timeout 请求的超时时间。
+func NewWebhookNotifier(name, url string, headers map[string]string, tmpl string, timeout time.Duration) (*WebhookNotifier, error) {
+	if strings.TrimSpace(url) == "" {
+		return nil, _types.NewFieldError("Url", "url of webhook %s should not be empty", name)
+	}
+	if tmpl == "" {
+		tmpl = DefaultWebhookTemplate
+	}
+
+	if t, err := ParseTemplate(name, tmpl); err != nil {
+		return nil, _types.NewFieldError("Template", "illegal template of webhook %s: %w", name, err)
+	} else {
+		return &WebhookNotifier{
+			name:     name,
+			url:      url,
+			headers:  headers,
+			template: t,
+			client:   &http.Client{Timeout: timeout},
+		}, nil
+	}
+}
+
+func (wn *WebhookNotifier) Name() string {
+	return wn.name
+}
+
+func (wn *WebhookNotifier) Notify(ev *Event) error {
+	if body, err := render(wn.template, ev); err != nil {
+		return err
+	} else if !json.Valid(body) {
+		return fmt.Errorf("rendered payload is not valid json: %s", string(body))
+	} else {
+		_, err := postJson(wn.client, wn.url, wn.headers, body)
+		return err
+	}
+}
@@ -0,0 +1,390 @@
+// 该模块实现了查询爬虫健康状态的HTTP接口。
+// @Author: Haart
+// @Created: 2026-10-18
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	_db "com.cne/ai-tracking-monitor/db"
+	_metrics "com.cne/ai-tracking-monitor/metrics"
+	_utils "com.cne/ai-tracking-monitor/utils"
+)
+
+const (
+	defaultHistoryLimit int = 100  // 默认返回的监控日志条数。
+	maxHistoryLimit     int = 1000 // 最多返回的监控日志条数。
+)
+
+var (
+	httpServer *http.Server // HTTP服务。
+)
+
+// 表示爬虫的当前状态。
+type crawlerStatusVo struct {
+	Id             int64      `json:"id"`             // 爬虫ID。
+	Name           string     `json:"name"`           // 爬虫名称。
+	CarrierCode    string     `json:"carrierCode"`    // 运输商编号。
+	CarrierType    string     `json:"carrierType"`    // 运输商类别。
+	HeartBeatNo    string     `json:"heartBeatNo"`    // 监控单号。
+	State          string     `json:"state"`          // 健康状态。
+	Healthy        bool       `json:"healthy"`        // 是否可用。
+	LastCheckTime  *time.Time `json:"lastCheckTime"`  // 最近一次监控的时间。
+	LastTiming     int        `json:"lastTiming"`     // 最近一次监控的耗时（毫秒）。
+	LastOk         bool       `json:"lastOk"`         // 最近一次监控是否成功。
+	LastResultNote string     `json:"lastResultNote"` // 最近一次监控结果的说明。
+	Suppression    string     `json:"suppression"`    // 当前生效的静默规则或者维护窗口。
+	CountOfOk      int        `json:"countOfOk"`      // 健康评估时间窗口内成功的次数。
+	CountOfError   int        `json:"countOfError"`   // 健康评估时间窗口内失败的次数。
+	TimingP50      int        `json:"timingP50"`      // 健康评估时间窗口内耗时的中位数（毫秒）。
+	TimingP95      int        `json:"timingP95"`      // 健康评估时间窗口内耗时的95分位数（毫秒）。
+}
+
+// 表示一条监控日志。
+type healthLogVo struct {
+	CheckTime       time.Time `json:"checkTime"`                 // 监控时间。
+	TrackingNo      string    `json:"trackingNo"`                // 监控单号。
+	Timing          int       `json:"timing"`                    // 耗时（毫秒）。
+	Ok              bool      `json:"ok"`                        // 是否成功。
+	ResultNote      string    `json:"resultNote"`                // 监控结果的说明。
+	CrawlerRespBody string    `json:"crawlerRespBody,omitempty"` // 爬虫返回的原始文本。
+}
+
+// 表示一个时间段内的监控汇总。
+type healthStatVo struct {
+	Period       string    `json:"period,omitempty"` // 汇总的周期，只在和监控日志一起返回时输出。
+	StartTime    time.Time `json:"startTime"`        // 时间段的开始时间。
+	CountOfOk    int       `json:"countOfOk"`        // 成功的次数。
+	CountOfError int       `json:"countOfError"`     // 失败的次数。
+	TimingP50    int       `json:"timingP50"`        // 耗时的中位数（毫秒）。
+	TimingP95    int       `json:"timingP95"`        // 耗时的95分位数（毫秒）。
+}
+
+// 启动HTTP服务。
+// hc HTTP服务配置。
+func startHttpServer(hc *HttpConfiguration) error {
+	if strings.TrimSpace(hc.Addr) == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/crawlers", handleApi(guardConnections(apiListCrawlers)))
+	mux.HandleFunc("/api/crawlers/", handleApi(guardConnections(apiCrawler)))
+	mux.HandleFunc("/api/check", handleApi(requireToken(hc.Token, guardConnections(apiCheckNow))))
+	mux.HandleFunc("/api/reload", handleApi(requireToken(hc.Token, apiReload)))
+	mux.Handle("/metrics", _metrics.Handler())
+
+	listener, err := net.Listen("tcp", hc.Addr)
+	if err != nil {
+		return err
+	}
+
+	httpServer = &http.Server{
+		Handler:      mux,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 5 * time.Minute,
+	}
+
+	go func() {
+		defer _utils.RecoverPanic()
+
+		fmt.Printf("Listening on %s ...\n", listener.Addr())
+		if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("[ERROR] HTTP server stopped: %s\n", err)
+		}
+	}()
+
+	return nil
+}
+
+// 停止HTTP服务，等待正在处理的请求结束。
+// ctx 上下文，如果超时则强制关闭所有连接。
+func stopHttpServer(ctx context.Context) error {
+	if httpServer == nil {
+		return nil
+	}
+
+	if err := httpServer.Shutdown(ctx); err != nil {
+		httpServer.Close()
+		return err
+	}
+
+	return nil
+}
+
+// 表示HTTP接口的错误，包含HTTP状态码。
+type apiError struct {
+	status  int    // HTTP状态码。
+	message string // 错误消息。
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func newApiError(status int, format string, args ...interface{}) *apiError {
+	return &apiError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+// 包装HTTP接口，将返回值序列化为JSON，并将错误和异常转换为对应的HTTP状态码。
+func handleApi(f func(*http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Printf("[ERROR] Failed to serve %s %s from %s: %s\n", r.Method, r.URL, _utils.GetRemoteAddr(r), err)
+				writeJson(w, http.StatusInternalServerError, map[string]interface{}{"message": fmt.Sprintf("%v", err)})
+			}
+		}()
+
+		if result, err := f(r); err != nil {
+			status := http.StatusInternalServerError
+			if ae, ok := err.(*apiError); ok {
+				status = ae.status
+			}
+			writeJson(w, status, map[string]interface{}{"message": err.Error()})
+		} else {
+			writeJson(w, http.StatusOK, result)
+		}
+	}
+}
+
+// 包装修改状态的HTTP接口，要求请求携带配置的Bearer令牌，如果没有配置令牌则只接受来自本机的请求。
+// 不根据`X-Forwarded-For`判断请求的来源，因为它可以被客户端伪造。
+// token 配置的令牌。
+// f 被包装的HTTP接口。
+func requireToken(token string, f func(*http.Request) (interface{}, error)) func(*http.Request) (interface{}, error) {
+	return func(r *http.Request) (interface{}, error) {
+		if token == "" {
+			if !isLoopback(r.RemoteAddr) {
+				return nil, newApiError(http.StatusForbidden, "only local requests are allowed if no token is configured")
+			}
+		} else if auth := r.Header.Get("Authorization"); !strings.HasPrefix(auth, "Bearer ") ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) != 1 {
+			log.Printf("[WARN] Unauthorized request %s %s from %s\n", r.Method, r.URL, r.RemoteAddr)
+			return nil, newApiError(http.StatusUnauthorized, "illegal bearer token")
+		}
+
+		return f(r)
+	}
+}
+
+// 判断连接的远端地址是否是本机地址。
+// remoteAddr 远端地址，格式是`host:port`。
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+}
+
+// GET /api/crawlers
+// 列出所有爬虫的当前状态。
+func apiListCrawlers(r *http.Request) (interface{}, error) {
+	if r.Method != http.MethodGet {
+		return nil, newApiError(http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+	}
+
+	now := time.Now()
+
+	latestLogs := make(map[int64]*_db.CrawlerHealthLogPo)
+	for _, po := range _db.QueryLatestHealthLogs() {
+		latestLogs[po.CrawlerId] = po
+	}
+
+	resolver := currentHealthPolicies()
+	result := make([]*crawlerStatusVo, 0)
+	for _, crawlerInfo := range _db.QueryAllCrawlerInfos(now) {
+		state := healthMachine.State(crawlerInfo.Id)
+		st := healthCounter.Stats(crawlerInfo.Id, resolver.Resolve(crawlerInfo.Id, crawlerInfo.CarrierType).Window(), now)
+		vo := &crawlerStatusVo{
+			Id:           crawlerInfo.Id,
+			Name:         crawlerInfo.Name,
+			CarrierCode:  crawlerInfo.CarrierCode,
+			CarrierType:  crawlerInfo.CarrierType.String(),
+			HeartBeatNo:  crawlerInfo.HeartBeatNo,
+			State:        state.String(),
+			Healthy:      state.IsHealthy(),
+			CountOfOk:    st.CountOfOk,
+			CountOfError: st.CountOfError,
+			TimingP50:    st.TimingP50,
+			TimingP95:    st.TimingP95,
+		}
+
+		if po := latestLogs[crawlerInfo.Id]; po != nil {
+			lastCheckTime := po.CreateTime
+			vo.LastCheckTime = &lastCheckTime
+			vo.LastTiming = po.Timing
+			vo.LastOk = po.ResultStatus == 0
+			vo.LastResultNote = po.ResultNote
+		}
+
+		if sp := alertSuppressor.Match(crawlerInfo.Id, crawlerInfo.CarrierCode, now); sp != nil {
+			vo.Suppression = sp.Reason
+		}
+
+		result = append(result, vo)
+	}
+
+	return result, nil
+}
+
+// /api/crawlers/{id}/...
+// 根据路径分派到爬虫的子资源。
+func apiCrawler(r *http.Request) (interface{}, error) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/crawlers/"), "/"), "/")
+	if len(parts) != 2 {
+		return nil, newApiError(http.StatusNotFound, "not found: %s", r.URL.Path)
+	}
+
+	crawlerId, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, newApiError(http.StatusBadRequest, "illegal crawler id: %s", parts[0])
+	}
+
+	switch parts[1] {
+	case "history":
+		return apiCrawlerHistory(r, crawlerId)
+	case "stats":
+		return apiCrawlerStats(r, crawlerId)
+	default:
+		return nil, newApiError(http.StatusNotFound, "not found: %s", r.URL.Path)
+	}
+}
+
+// 解析查询参数中的时间范围和条数。
+// q 查询参数。
+// defaultSince 默认的时间范围。
+func parseSinceAndLimit(q url.Values, defaultSince time.Duration) (time.Duration, int, error) {
+	since := defaultSince
+	if s := q.Get("since"); s != "" {
+		if d, err := time.ParseDuration(s); err != nil || d <= 0 {
+			return 0, 0, newApiError(http.StatusBadRequest, "illegal since: %s", s)
+		} else {
+			since = d
+		}
+	}
+
+	limit := _utils.ParseInt(q.Get("limit"), defaultHistoryLimit)
+	if limit <= 0 || limit > maxHistoryLimit {
+		return 0, 0, newApiError(http.StatusBadRequest, "limit should be between 1 and %d", maxHistoryLimit)
+	}
+
+	return since, limit, nil
+}
+
+// GET /api/crawlers/{id}/history?since=48h&limit=100&body=true
+// 列出指定爬虫的监控日志，按照监控时间倒序排列，原始监控日志已经被清理的时间范围在`stats`中返回按小时、按天的汇总。
+func apiCrawlerHistory(r *http.Request, crawlerId int64) (interface{}, error) {
+	if r.Method != http.MethodGet {
+		return nil, newApiError(http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+	}
+
+	q := r.URL.Query()
+
+	since, limit, err := parseSinceAndLimit(q, currentConfiguration().Health.Window.Duration())
+	if err != nil {
+		return nil, err
+	}
+
+	withBody := _utils.AsBool(q.Get("body"))
+
+	startTime := time.Now().Add(-since)
+	result := make([]*healthLogVo, 0)
+	for _, po := range _db.QueryHealthLogsByCrawler(crawlerId, startTime, limit) {
+		vo := &healthLogVo{
+			CheckTime:  po.CreateTime,
+			TrackingNo: po.TrackingNo,
+			Timing:     po.Timing,
+			Ok:         po.ResultStatus == 0,
+			ResultNote: po.ResultNote,
+		}
+		if withBody {
+			vo.CrawlerRespBody = po.CrawlerRespBody
+		}
+
+		result = append(result, vo)
+	}
+
+	// 原始监控日志已经被清理的时间范围使用汇总。
+	stats := make([]*healthStatVo, 0)
+	for _, po := range queryHealthStatsBeforeRawLogs(crawlerId, startTime, limit-len(result)) {
+		stats = append(stats, &healthStatVo{
+			Period:       po.Period,
+			StartTime:    po.StartTime,
+			CountOfOk:    po.CountOfOk,
+			CountOfError: po.CountOfError,
+			TimingP50:    po.TimingP50,
+			TimingP95:    po.TimingP95,
+		})
+	}
+
+	return map[string]interface{}{
+		"crawlerId": crawlerId,
+		"state":     healthMachine.State(crawlerId).String(),
+		"items":     result,
+		"stats":     stats,
+	}, nil
+}
+
+// GET /api/crawlers/{id}/stats?period=hour&since=720h&limit=100
+// 列出指定爬虫的监控汇总，按照开始时间倒序排列。
+// period 可以是`hour`或者`day`，默认是`hour`。
+func apiCrawlerStats(r *http.Request, crawlerId int64) (interface{}, error) {
+	if r.Method != http.MethodGet {
+		return nil, newApiError(http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+	}
+
+	q := r.URL.Query()
+
+	period, err := parseStatPeriod(q.Get("period"))
+	if err != nil {
+		return nil, newApiError(http.StatusBadRequest, "%s", err)
+	}
+
+	since, limit, err := parseSinceAndLimit(q, time.Duration(currentConfiguration().Retention.HourlyDays)*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if since <= 0 {
+		return nil, newApiError(http.StatusBadRequest, "since should be specified")
+	}
+
+	result := make([]*healthStatVo, 0)
+	for _, po := range _db.QueryHealthStatsByCrawler(crawlerId, period, time.Now().Add(-since), time.Now(), limit) {
+		result = append(result, &healthStatVo{
+			StartTime:    po.StartTime,
+			CountOfOk:    po.CountOfOk,
+			CountOfError: po.CountOfError,
+			TimingP50:    po.TimingP50,
+			TimingP95:    po.TimingP95,
+		})
+	}
+
+	return map[string]interface{}{
+		"crawlerId": crawlerId,
+		"period":    period,
+		"items":     result,
+	}, nil
+}
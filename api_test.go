@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireToken(t *testing.T) {
+	cases := []struct {
+		name       string
+		token      string
+		remoteAddr string
+		headers    map[string]string
+		status     int
+	}{
+		{"local without token", "", "127.0.0.1:1234", nil, http.StatusOK},
+		{"local ipv6 without token", "", "[::1]:1234", nil, http.StatusOK},
+		{"remote without token", "", "192.0.2.1:1234", nil, http.StatusForbidden},
+		{"forwarded remote without token", "", "192.0.2.1:1234", map[string]string{"X-Forwarded-For": "127.0.0.1"}, http.StatusForbidden},
+		{"remote with token", "s3cret", "192.0.2.1:1234", map[string]string{"Authorization": "Bearer s3cret"}, http.StatusOK},
+		{"missing token", "s3cret", "192.0.2.1:1234", nil, http.StatusUnauthorized},
+		{"local missing token", "s3cret", "127.0.0.1:1234", nil, http.StatusUnauthorized},
+		{"wrong token", "s3cret", "192.0.2.1:1234", map[string]string{"Authorization": "Bearer s3cre"}, http.StatusUnauthorized},
+		{"not bearer", "s3cret", "192.0.2.1:1234", map[string]string{"Authorization": "Basic s3cret"}, http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			called := false
+			h := handleApi(requireToken(c.token, func(r *http.Request) (interface{}, error) {
+				called = true
+				return "ok", nil
+			}))
+
+			r := httptest.NewRequest(http.MethodPost, "/api/check", nil)
+			r.RemoteAddr = c.remoteAddr
+			for k, v := range c.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			h(w, r)
+
+			if w.Code != c.status {
+				t.Errorf("status should be %d, but got %d: %s", c.status, w.Code, w.Body)
+			}
+			if called != (c.status == http.StatusOK) {
+				t.Errorf("handler should be called: %v, but got %v", c.status == http.StatusOK, called)
+			}
+		})
+	}
+}
@@ -5,53 +5,92 @@ package cache
 
 import (
 	"context"
-	"fmt"
-	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
-var (
-	redisHost     string
-	redisPort     int
-	redisPassword string
-	redisDB       int
-	redisClient   *redis.Client
-	redisCtx      context.Context
+const (
+	DriverRedis  string = "redis"  // 基于Redis的缓存。
+	DriverMemory string = "memory" // 基于进程内存的缓存，用于测试和单机运行。
+
+	Nil = redis.Nil // 表示缓存不存在。
 )
 
-func init() {
-	redisCtx = context.Background()
+// 表示缓存，每个缓存项包含若干个字段。
+// 所有的方法都在 `ctx` 被取消时尽快返回 `ctx.Err()`。
+type Cache interface {
+	// 保存指定的值到缓存，并设置过期时间。
+	SetAndExpire(ctx context.Context, key string, fields map[string]interface{}, expiration time.Duration) error
+
+	// 更新缓存的部分字段，不改变过期时间。
+	Update(ctx context.Context, key string, fields map[string]interface{}) error
+
+	// 仅当缓存存在时更新缓存的部分字段，不改变过期时间，检查和更新是原子的，避免重新创建已过期的缓存。
+	// 返回是否已经更新。
+	UpdateIfExists(ctx context.Context, key string, fields map[string]interface{}) (bool, error)
+
+	// 获取缓存内容，如果缓存不存在或者所有字段都不存在则返回`Nil`。
+	Get(ctx context.Context, key string, fields ...string) ([]interface{}, error)
+
+	// 删除缓存，返回实际删除的个数。
+	Del(ctx context.Context, key string) (int64, error)
+
+	// 获取并删除缓存内容。
+	Take(ctx context.Context, key string, fields ...string) ([]interface{}, error)
+
+	// 获取缓存内容并延长过期时间。
+	GetAndExpire(ctx context.Context, key string, expiration time.Duration, fields ...string) ([]interface{}, error)
+
+	// 关闭缓存，释放连接。
+	Close() error
 }
 
-// 初始化Redis队列配置。
+var (
+	cache     Cache        // 当前使用的缓存。
+	cacheLock sync.RWMutex // 保护当前使用的缓存，重新加载配置时会被替换。
+)
+
+// 初始化Redis缓存配置。
 // host Redis主机。
 // port Redis端口号。
 // password Redis口令。
-// db Redis队列使用的数据库。
+// db Redis缓存使用的数据库。
 func InitRedisCache(host string, port int, password string, db int) error {
-	redisHost = host
-	redisPort = port
-	redisPassword = password
-	redisDB = db
-
-	client := redis.NewClient(&redis.Options{
-		Addr:     redisHost + ":" + strconv.Itoa(redisPort),
-		Password: redisPassword,
-		DB:       redisDB,
-	})
-	if client == nil {
-		return fmt.Errorf("cannot create redis client")
-	}
-	if _, err := client.Ping(redisCtx).Result(); err != nil {
+	if c, err := NewRedisCache(host, port, password, db); err != nil {
 		return err
 	} else {
-		redisClient = client
+		SetDefault(c)
 		return nil
 	}
 }
 
+// 初始化进程内存缓存。
+func InitMemoryCache() {
+	SetDefault(NewMemoryCache())
+}
+
+// 获取当前使用的缓存。
+func Default() Cache {
+	cacheLock.RLock()
+	defer cacheLock.RUnlock()
+
+	return cache
+}
+
+// 替换当前使用的缓存。
+// c 新的缓存。
+// 返回被替换的缓存，调用者负责关闭，如果之前没有初始化则返回nil。
+func SetDefault(c Cache) Cache {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+
+	old := cache
+	cache = c
+	return old
+}
+
 // Deprecated 此方法会清除之前设置的过期时间。
 // func Set(key string, fields map[string]interface{}) error {
 // 	_, err := redisClient.HMSet(key, fields).Result()
@@ -59,95 +98,71 @@ func InitRedisCache(host string, port int, password string, db int) error {
 // }
 
 // 保存指定的值到缓存，并设置过期时间。
+// ctx 上下文。
 // key 缓存的键。
 // fields 缓存的内容。
 // expiration 缓存过期的时间。
-func SetAndExpire(key string, fields map[string]interface{}, expiration time.Duration) error {
-	p := redisClient.TxPipeline()
-
-	p.HMSet(redisCtx, key, fields)
-	p.Expire(redisCtx, key, expiration)
-
-	if _, err := p.Exec(redisCtx); err != nil {
-		return err
-	} else {
-		return nil
-	}
+func SetAndExpire(ctx context.Context, key string, fields map[string]interface{}, expiration time.Duration) error {
+	return Default().SetAndExpire(ctx, key, fields, expiration)
 }
 
-func Update(key string, fields map[string]interface{}) error {
-	p := redisClient.Pipeline()
-
-	for hk, hv := range fields {
-		p.HSet(redisCtx, key, hk, hv)
-	}
+// 更新缓存的部分字段。
+// ctx 上下文。
+// key 缓存的键。
+// fields 待更新的内容。
+func Update(ctx context.Context, key string, fields map[string]interface{}) error {
+	return Default().Update(ctx, key, fields)
+}
 
-	if _, err := p.Exec(redisCtx); err != nil {
-		return err
-	} else {
-		return nil
-	}
+// 仅当缓存存在时更新缓存的部分字段。
+// ctx 上下文。
+// key 缓存的键。
+// fields 待更新的内容。
+// 返回是否已经更新。
+func UpdateIfExists(ctx context.Context, key string, fields map[string]interface{}) (bool, error) {
+	return Default().UpdateIfExists(ctx, key, fields)
 }
 
 // 获取缓存内容。
+// ctx 上下文。
 // key 缓存的键。
 // fields 缓存内容的名字。
 // 返回被缓存的内容。
-func Get(key string, fields ...string) ([]interface{}, error) {
-	if r, err := redisClient.HMGet(redisCtx, key, fields...).Result(); err != nil {
-		return nil, err
-	} else {
-		allNil := true
-		for _, o := range r {
-			if o != nil {
-				allNil = false
-			}
-		}
-		if allNil {
-			return nil, redis.Nil
-		} else {
-			return r, nil
-		}
-	}
+func Get(ctx context.Context, key string, fields ...string) ([]interface{}, error) {
+	return Default().Get(ctx, key, fields...)
 }
 
 // 删除缓存。
+// ctx 上下文。
 // key 缓存的键。
-func Del(key string) (int64, error) {
-	return redisClient.Del(redisCtx, key).Result()
+func Del(ctx context.Context, key string) (int64, error) {
+	return Default().Del(ctx, key)
 }
 
 // 获取并删除缓存内容。
+// ctx 上下文。
 // key 缓存的键。
 // fields 缓存内容的名字。
 // 返回被缓存的内容。
-func Take(key string, fields ...string) ([]interface{}, error) {
-	p := redisClient.Pipeline()
-
-	p.HMGet(redisCtx, key, fields...)
-	p.Del(redisCtx, key)
-
-	if cc, err := p.Exec(redisCtx); err != nil {
-		return nil, err
-	} else {
-		return cc[0].(*redis.SliceCmd).Result()
-	}
+func Take(ctx context.Context, key string, fields ...string) ([]interface{}, error) {
+	return Default().Take(ctx, key, fields...)
 }
 
 // 获取缓存内容并延长过期时间。
+// ctx 上下文。
 // key 缓存的键。
 // expiration 缓存延长的过期时间。
 // fields 缓存内容的名字。
 // 返回被缓存的内容。
-func GetAndExpire(key string, expiration time.Duration, fields ...string) ([]interface{}, error) {
-	p := redisClient.Pipeline()
-
-	p.HMGet(redisCtx, key, fields...).Result()
-	p.Expire(redisCtx, key, expiration)
+func GetAndExpire(ctx context.Context, key string, expiration time.Duration, fields ...string) ([]interface{}, error) {
+	return Default().GetAndExpire(ctx, key, expiration, fields...)
+}
 
-	if cc, err := p.Exec(redisCtx); err != nil {
-		return nil, err
+// 关闭当前使用的缓存。
+func Close() error {
+	if c := Default(); c == nil {
+		return nil
 	} else {
-		return cc[0].(*redis.SliceCmd).Result()
+		return c.Close()
 	}
 }
@@ -0,0 +1,265 @@
+// 该模块实现了基于进程内存的缓存，用于测试和单机运行。
+// @Author: Haart
+// @Created: 2026-10-18
+package cache
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	memorySweepInterval = time.Minute // 清理过期缓存项的间隔。
+)
+
+// 表示一个内存缓存项。
+type memoryItem struct {
+	fields   map[string]string // 缓存的内容，和Redis一样所有的值都保存为字符串。
+	expireAt time.Time         // 过期的时间，零值表示永不过期。
+}
+
+func (i *memoryItem) expired(now time.Time) bool {
+	return !i.expireAt.IsZero() && !now.Before(i.expireAt)
+}
+
+// 基于进程内存的缓存，语义和Redis的Hash保持一致。
+type memoryCache struct {
+	mu        sync.Mutex
+	items     map[string]*memoryItem
+	lastSweep time.Time
+}
+
+// 创建进程内存缓存。
+func NewMemoryCache() Cache {
+	return &memoryCache{items: make(map[string]*memoryItem), lastSweep: time.Now()}
+}
+
+// 获取未过期的缓存项，如果已过期则删除。调用者必须持有锁。
+func (c *memoryCache) lookup(key string, now time.Time) *memoryItem {
+	if item, ok := c.items[key]; !ok {
+		return nil
+	} else if item.expired(now) {
+		delete(c.items, key)
+		return nil
+	} else {
+		return item
+	}
+}
+
+// 定期删除所有已过期的缓存项，避免从未被再次访问的缓存项一直占用内存。调用者必须持有锁。
+func (c *memoryCache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < memorySweepInterval {
+		return
+	}
+
+	for key, item := range c.items {
+		if item.expired(now) {
+			delete(c.items, key)
+		}
+	}
+	c.lastSweep = now
+}
+
+// 更新缓存项的字段，如果缓存项不存在则创建。调用者必须持有锁。
+func (c *memoryCache) set(key string, fields map[string]interface{}, now time.Time) *memoryItem {
+	item := c.lookup(key, now)
+	if item == nil {
+		item = &memoryItem{fields: make(map[string]string)}
+		c.items[key] = item
+	}
+
+	for hk, hv := range fields {
+		item.fields[hk] = formatValue(hv)
+	}
+
+	return item
+}
+
+// 读取缓存项的字段，不存在的字段对应nil。
+func (i *memoryItem) values(fields []string) []interface{} {
+	result := make([]interface{}, len(fields))
+	if i == nil {
+		return result
+	}
+
+	for n, f := range fields {
+		if v, ok := i.fields[f]; ok {
+			result[n] = v
+		}
+	}
+
+	return result
+}
+
+func (c *memoryCache) SetAndExpire(ctx context.Context, key string, fields map[string]interface{}, expiration time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.sweep(now)
+
+	item := c.set(key, fields, now)
+	if expiration > 0 {
+		item.expireAt = now.Add(expiration)
+	} else {
+		// 和Redis一样，非正数的过期时间会立即删除缓存。
+		delete(c.items, key)
+	}
+
+	return nil
+}
+
+func (c *memoryCache) Update(ctx context.Context, key string, fields map[string]interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(key, fields, time.Now())
+
+	return nil
+}
+
+func (c *memoryCache) UpdateIfExists(ctx context.Context, key string, fields map[string]interface{}) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.lookup(key, now) == nil {
+		return false, nil
+	}
+	c.set(key, fields, now)
+
+	return true, nil
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string, fields ...string) ([]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if item := c.lookup(key, time.Now()); item == nil {
+		return nil, Nil
+	} else {
+		r := item.values(fields)
+		for _, o := range r {
+			if o != nil {
+				return r, nil
+			}
+		}
+		return nil, Nil
+	}
+}
+
+func (c *memoryCache) Del(ctx context.Context, key string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if item := c.lookup(key, time.Now()); item == nil {
+		return 0, nil
+	} else {
+		delete(c.items, key)
+		return 1, nil
+	}
+}
+
+func (c *memoryCache) Take(ctx context.Context, key string, fields ...string) ([]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item := c.lookup(key, time.Now())
+	delete(c.items, key)
+
+	return item.values(fields), nil
+}
+
+func (c *memoryCache) GetAndExpire(ctx context.Context, key string, expiration time.Duration, fields ...string) ([]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	item := c.lookup(key, now)
+	r := item.values(fields)
+	if item != nil {
+		if expiration > 0 {
+			item.expireAt = now.Add(expiration)
+		} else {
+			delete(c.items, key)
+		}
+	}
+
+	return r, nil
+}
+
+func (c *memoryCache) Close() error {
+	return nil
+}
+
+// 按照Redis客户端的规则将值格式化为字符串。
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int:
+		return strconv.FormatInt(int64(v), 10)
+	case int8:
+		return strconv.FormatInt(int64(v), 10)
+	case int16:
+		return strconv.FormatInt(int64(v), 10)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		} else {
+			return "0"
+		}
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case encoding.BinaryMarshaler:
+		if b, err := v.MarshalBinary(); err != nil {
+			return ""
+		} else {
+			return string(b)
+		}
+	default:
+		return fmt.Sprint(v)
+	}
+}
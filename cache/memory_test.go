@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheSetAndGet(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+
+	if err := c.SetAndExpire(ctx, "k", map[string]interface{}{"status": -1, "ok": true, "name": "HB1"}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	vs, err := c.Get(ctx, "k", "status", "ok", "name", "missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vs[0] != "-1" || vs[1] != "1" || vs[2] != "HB1" || vs[3] != nil {
+		t.Errorf("unexpected values: %#v", vs)
+	}
+
+	// 所有字段都不存在时和Redis一样返回Nil。
+	if _, err := c.Get(ctx, "k", "missing"); !errors.Is(err, Nil) {
+		t.Errorf("expected Nil for missing fields but got %v", err)
+	}
+	if _, err := c.Get(ctx, "missing", "status"); !errors.Is(err, Nil) {
+		t.Errorf("expected Nil for missing key but got %v", err)
+	}
+}
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+
+	if err := c.SetAndExpire(ctx, "k", map[string]interface{}{"status": -1}, 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	// 更新字段不改变过期时间。
+	if err := c.Update(ctx, "k", map[string]interface{}{"status": 0}); err != nil {
+		t.Fatal(err)
+	}
+	if vs, err := c.Get(ctx, "k", "status"); err != nil || vs[0] != "0" {
+		t.Fatalf("expected updated status but got %#v, %v", vs, err)
+	}
+
+	time.Sleep(80 * time.Millisecond)
+
+	if _, err := c.Get(ctx, "k", "status"); !errors.Is(err, Nil) {
+		t.Errorf("expected Nil after expiration but got %v", err)
+	}
+	if n, err := c.Del(ctx, "k"); err != nil || n != 0 {
+		t.Errorf("expected nothing deleted after expiration but got %d, %v", n, err)
+	}
+}
+
+func TestMemoryCacheNonPositiveExpiration(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+
+	if err := c.SetAndExpire(ctx, "k", map[string]interface{}{"status": -1}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "k", "status"); !errors.Is(err, Nil) {
+		t.Errorf("expected Nil for non-positive expiration but got %v", err)
+	}
+}
+
+func TestMemoryCacheGetAndExpire(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+
+	if err := c.SetAndExpire(ctx, "k", map[string]interface{}{"status": 1}, 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if vs, err := c.GetAndExpire(ctx, "k", time.Minute, "status"); err != nil || vs[0] != "1" {
+		t.Fatalf("unexpected values: %#v, %v", vs, err)
+	}
+
+	time.Sleep(80 * time.Millisecond)
+
+	if _, err := c.Get(ctx, "k", "status"); err != nil {
+		t.Errorf("expiration should be extended, but got %v", err)
+	}
+
+	// 缓存不存在时和Redis一样返回全部为nil的字段，并且不会创建缓存。
+	if vs, err := c.GetAndExpire(ctx, "missing", time.Minute, "status", "name"); err != nil || len(vs) != 2 || vs[0] != nil || vs[1] != nil {
+		t.Errorf("expected nil values for missing key but got %#v, %v", vs, err)
+	}
+	if _, err := c.Get(ctx, "missing", "status"); !errors.Is(err, Nil) {
+		t.Errorf("missing key should not be created, but got %v", err)
+	}
+}
+
+func TestMemoryCacheTake(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+
+	if err := c.SetAndExpire(ctx, "k", map[string]interface{}{"status": 1}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if vs, err := c.Take(ctx, "k", "status"); err != nil || vs[0] != "1" {
+		t.Fatalf("unexpected values: %#v, %v", vs, err)
+	}
+	if _, err := c.Get(ctx, "k", "status"); !errors.Is(err, Nil) {
+		t.Errorf("expected Nil after take but got %v", err)
+	}
+
+	if vs, err := c.Take(ctx, "missing", "status"); err != nil || len(vs) != 1 || vs[0] != nil {
+		t.Errorf("expected nil values for missing key but got %#v, %v", vs, err)
+	}
+}
+
+func TestMemoryCacheUpdateIfExists(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+
+	if ok, err := c.UpdateIfExists(ctx, "missing", map[string]interface{}{"status": 1}); err != nil || ok {
+		t.Fatalf("missing key should not be updated, but got %v, %v", ok, err)
+	}
+	if _, err := c.Get(ctx, "missing", "status"); !errors.Is(err, Nil) {
+		t.Errorf("missing key should not be created, but got %v", err)
+	}
+
+	if err := c.SetAndExpire(ctx, "k", map[string]interface{}{"status": -1}, 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := c.UpdateIfExists(ctx, "k", map[string]interface{}{"status": 0}); err != nil || !ok {
+		t.Fatalf("existing key should be updated, but got %v, %v", ok, err)
+	}
+	if vs, _ := c.Get(ctx, "k", "status"); vs[0] != "0" {
+		t.Errorf("expected updated status but got %#v", vs)
+	}
+
+	// 过期之后不会被重新创建。
+	time.Sleep(80 * time.Millisecond)
+	if ok, err := c.UpdateIfExists(ctx, "k", map[string]interface{}{"status": 1}); err != nil || ok {
+		t.Fatalf("expired key should not be updated, but got %v, %v", ok, err)
+	}
+	if _, err := c.Get(ctx, "k", "status"); !errors.Is(err, Nil) {
+		t.Errorf("expired key should not be created, but got %v", err)
+	}
+}
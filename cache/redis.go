@@ -0,0 +1,145 @@
+// 该模块实现了基于Redis的缓存。
+// @Author: Haart
+// @Created: 2026-10-18
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 仅当缓存存在时更新字段的脚本，`ARGV`依次是字段名和值。
+var updateIfExistsScript = redis.NewScript(`if redis.call('EXISTS', KEYS[1]) == 1 then
+	redis.call('HSET', KEYS[1], unpack(ARGV))
+	return 1
+end
+return 0`)
+
+// 基于Redis的缓存，每个缓存项保存为一个Hash。
+type redisCache struct {
+	client *redis.Client
+}
+
+// 创建Redis缓存。
+// host Redis主机。
+// port Redis端口号。
+// password Redis口令。
+// db Redis缓存使用的数据库。
+func NewRedisCache(host string, port int, password string, db int) (Cache, error) {
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{
+		Addr:     host + ":" + strconv.Itoa(port),
+		Password: password,
+		DB:       db,
+	})
+	if client == nil {
+		return nil, fmt.Errorf("cannot create redis client")
+	}
+	if _, err := client.Ping(ctx).Result(); err != nil {
+		client.Close()
+		return nil, err
+	} else {
+		return &redisCache{client: client}, nil
+	}
+}
+
+func (c *redisCache) SetAndExpire(ctx context.Context, key string, fields map[string]interface{}, expiration time.Duration) error {
+	p := c.client.TxPipeline()
+
+	p.HMSet(ctx, key, fields)
+	p.Expire(ctx, key, expiration)
+
+	if _, err := p.Exec(ctx); err != nil {
+		return err
+	} else {
+		return nil
+	}
+}
+
+func (c *redisCache) Update(ctx context.Context, key string, fields map[string]interface{}) error {
+	p := c.client.Pipeline()
+
+	for hk, hv := range fields {
+		p.HSet(ctx, key, hk, hv)
+	}
+
+	if _, err := p.Exec(ctx); err != nil {
+		return err
+	} else {
+		return nil
+	}
+}
+
+func (c *redisCache) UpdateIfExists(ctx context.Context, key string, fields map[string]interface{}) (bool, error) {
+	if len(fields) == 0 {
+		n, err := c.client.Exists(ctx, key).Result()
+		return n > 0, err
+	}
+
+	args := make([]interface{}, 0, len(fields)*2)
+	for hk, hv := range fields {
+		args = append(args, hk, hv)
+	}
+
+	if r, err := updateIfExistsScript.Run(ctx, c.client, []string{key}, args...).Int(); err != nil {
+		return false, err
+	} else {
+		return r == 1, nil
+	}
+}
+
+func (c *redisCache) Get(ctx context.Context, key string, fields ...string) ([]interface{}, error) {
+	if r, err := c.client.HMGet(ctx, key, fields...).Result(); err != nil {
+		return nil, err
+	} else {
+		allNil := true
+		for _, o := range r {
+			if o != nil {
+				allNil = false
+			}
+		}
+		if allNil {
+			return nil, Nil
+		} else {
+			return r, nil
+		}
+	}
+}
+
+func (c *redisCache) Del(ctx context.Context, key string) (int64, error) {
+	return c.client.Del(ctx, key).Result()
+}
+
+func (c *redisCache) Take(ctx context.Context, key string, fields ...string) ([]interface{}, error) {
+	p := c.client.Pipeline()
+
+	p.HMGet(ctx, key, fields...)
+	p.Del(ctx, key)
+
+	if cc, err := p.Exec(ctx); err != nil {
+		return nil, err
+	} else {
+		return cc[0].(*redis.SliceCmd).Result()
+	}
+}
+
+func (c *redisCache) GetAndExpire(ctx context.Context, key string, expiration time.Duration, fields ...string) ([]interface{}, error) {
+	p := c.client.Pipeline()
+
+	p.HMGet(ctx, key, fields...)
+	p.Expire(ctx, key, expiration)
+
+	if cc, err := p.Exec(ctx); err != nil {
+		return nil, err
+	} else {
+		return cc[0].(*redis.SliceCmd).Result()
+	}
+}
+
+func (c *redisCache) Close() error {
+	return c.client.Close()
+}
@@ -1,51 +1,52 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"sync"
 	"time"
 
 	_agent "com.cne/ai-tracking-monitor/agent"
+	_alert "com.cne/ai-tracking-monitor/alert"
 	_db "com.cne/ai-tracking-monitor/db"
+	_health "com.cne/ai-tracking-monitor/health"
+	_metrics "com.cne/ai-tracking-monitor/metrics"
 	_rpcclient "com.cne/ai-tracking-monitor/rpcclient"
 	_types "com.cne/ai-tracking-monitor/types"
 	_utils "com.cne/ai-tracking-monitor/utils"
 )
 
-func doCheck() {
-	now := time.Now()
-
-	crawlerInfoList := _db.QueryAllCrawlerInfos(now)
-	log.Printf("[INFO] %d active crawler(s) found\n", len(crawlerInfoList))
-
-	findCrawlerInfo_ := func(carrierCode string) *_db.CrawlerInfoPo {
-		for _, ci := range crawlerInfoList {
-			if ci.CarrierCode == carrierCode {
-				return ci
-			}
-		}
-
-		return nil
-	}
-
-	findCrawlerInfo2_ := func(crawlerId int64) *_db.CrawlerInfoPo {
-		for _, ci := range crawlerInfoList {
-			if ci.Id == crawlerId {
-				return ci
-			}
-		}
+var (
+	healthUpdates sync.WaitGroup // 用于退出时等待正在更新的健康状态。
+)
 
-		return nil
-	}
+// 表示对一个爬虫的一次监控结果。
+type probeResult struct {
+	CrawlerInfo    *_db.CrawlerInfoPo         // 爬虫。
+	TrackingSearch *_rpcclient.TrackingSearch // 查询对象，包含查询结果。
+	ResultStatus   int                        // 监控结果，0表示成功，1表示失败。
+	ResultNote     string                     // 监控结果的说明。
+	Timing         int64                      // 耗时（毫秒）。
+	EndTime        time.Time                  // 查询代理返回的时间。
+}
 
+// 对指定的爬虫执行一次监控。
+// 为每个爬虫生成一个查询对象，推送到最高优先级的队列，然后从缓存拉取查询结果。
+// ctx 上下文，拉取查询结果的超时时间取自其截止时间，如果被取消则放弃拉取到的结果。
+// crawlerInfoList 需要监控的爬虫。
+// 返回所有已返回的监控结果，缓存已消失的查询对象不包含在内。
+func probeCrawlers(ctx context.Context, crawlerInfoList []*_db.CrawlerInfoPo) ([]*probeResult, error) {
 	trackingSearchList := make([]*_rpcclient.TrackingSearch, 0)
+	crawlerInfoBySeqNo := make(map[string]*_db.CrawlerInfoPo)
 
 	reqTime := time.Now()
 	for _, crawlerInfo := range crawlerInfoList {
 		// 为每个爬虫发送一个查询请求。
 		if seqNo, err := _utils.NewSeqNo(); err != nil {
-			panic(err)
+			return nil, err
 		} else {
-			// fmt.Printf("%v\n", seqNo)
+			crawlerInfoBySeqNo[seqNo] = crawlerInfo
 			trackingSearchList = append(trackingSearchList, &_rpcclient.TrackingSearch{
 				ReqTime:     reqTime,
 				SeqNo:       seqNo,
@@ -57,88 +58,226 @@ func doCheck() {
 	}
 
 	// 监控请求使用最高优先级。
-	if keys, err := _rpcclient.PushTrackingSearchToQueue(_types.PriorityHighest, trackingSearchList); err != nil {
+	keys, err := _rpcclient.PushTrackingSearchToQueue(ctx, _types.PriorityHighest, trackingSearchList)
+	if err != nil {
 		// 推送查询对象到任务队列失败，放弃轮询缓存和拉取查询对象。
-	} else {
-		// 从缓存拉取查询对象（以及查询结果）。
-		if trackingSearchList, err := _rpcclient.PullTrackingSearchFromCache(_types.PriorityHighest, keys); err != nil {
-			panic(err)
-		} else {
-			for _, ts := range trackingSearchList {
-				// 注意：此处规则和接口查询不同，result_status=0表示成功；result_status=1表示失败！！！！
-				resultStatus := 1
-				resultNote := ""
-				if ts.AgentCode == _agent.AcSuccess || ts.AgentCode == _agent.AcSuccess2 {
-					resultStatus = 0
-				} else if ts.AgentCode == _agent.AcNoTracking {
-					resultStatus = 0
-					resultNote = "未查询到单号"
-				} else if ts.AgentCode == _agent.AcParseFailed {
-					resultNote = "无法解析目标网站页面"
-				} else if ts.AgentCode == _agent.AcTimeout {
-					resultNote = "查询目标网站超时"
-				} else {
-					resultNote = "未知错误"
-				}
-
-				if resultStatus == 1 && ts.Err != "" {
-					resultNote = resultNote + ": " + ts.Err
-				}
-
-				var timing int64
-				var endTime time.Time
-				if !_utils.IsZeroTime(ts.AgentEndTime) {
-					endTime = ts.AgentEndTime
-				} else {
-					endTime = time.Now()
-				}
-
-				timing = endTime.Sub(ts.ReqTime).Milliseconds()
-
-				if crawlerInfo := findCrawlerInfo_(ts.CarrierCode); crawlerInfo == nil {
-					continue
-				} else {
-					if resultStatus == 0 {
-						log.Printf("[INFO] Crawler %s(id=%d, carrier-code=%s, tracking-no=%s) is OK\n", crawlerInfo.Name, crawlerInfo.Id, crawlerInfo.CarrierCode, crawlerInfo.HeartBeatNo)
-					} else {
-						log.Printf("[WARN] Crawler %s(id=%d, carrier-code=%s, tracking-no=%s) has ERROR\n", crawlerInfo.Name, crawlerInfo.Id, crawlerInfo.CarrierCode, crawlerInfo.HeartBeatNo)
-					}
-
-					_db.SaveHealthLog(crawlerInfo.Id, ts.TrackingNo, int(timing), resultStatus, endTime, ts.AgentRawText, resultNote)
-				}
-			}
+		return nil, err
+	}
 
-			datePoint := time.Now().Add(-48 * time.Hour)
-			passingRatio := float32(.89)
-			go func() {
-				defer _utils.RecoverPanic()
-
-				// 每次执行爬虫监控之后，更新爬虫的当前状态。
-				for _, rc := range _db.CountHealthLogByResultStatus(datePoint) {
-					if crawlerInfo := findCrawlerInfo2_(rc.Id); crawlerInfo == nil {
-						continue
-					} else {
-						if isPassed(rc.CountOfOk, rc.CountOfError, passingRatio) {
-							if _db.UpdateCrawlerInfoHealth(rc.Id, 1) > 0 {
-								log.Printf("[INFO] Update crawler %s(id=%d, carrier-code=%s) to OK\n", crawlerInfo.Name, crawlerInfo.Id, crawlerInfo.CarrierCode)
-							}
-						} else {
-							if _db.UpdateCrawlerInfoHealth(rc.Id, 0) > 0 {
-								log.Printf("[INFO] Update crawler %s(id=%d, carrier-code=%s) to ERROR\n", crawlerInfo.Name, crawlerInfo.Id, crawlerInfo.CarrierCode)
-							}
-						}
-					}
-				}
-			}()
+	// 从缓存拉取查询对象（以及查询结果）。
+	trackingSearchList, err = _rpcclient.PullTrackingSearchFromCache(ctx, _types.PriorityHighest, keys)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			log.Printf("[WARN] Probe is canceled, discard %d result(s)\n", len(trackingSearchList))
 		}
+		return nil, err
 	}
+
+	result := make([]*probeResult, 0, len(trackingSearchList))
+	for _, ts := range trackingSearchList {
+		crawlerInfo := crawlerInfoBySeqNo[ts.SeqNo]
+		if crawlerInfo == nil {
+			continue
+		}
+
+		resultStatus, resultNote := classifyTrackingSearch(ts)
+
+		var endTime time.Time
+		if !_utils.IsZeroTime(ts.AgentEndTime) {
+			endTime = ts.AgentEndTime
+		} else {
+			endTime = time.Now()
+		}
+
+		result = append(result, &probeResult{
+			CrawlerInfo:    crawlerInfo,
+			TrackingSearch: ts,
+			ResultStatus:   resultStatus,
+			ResultNote:     resultNote,
+			Timing:         endTime.Sub(ts.ReqTime).Milliseconds(),
+			EndTime:        endTime,
+		})
+	}
+
+	return result, nil
+}
+
+// 根据查询代理的返回码判断监控结果。
+// ts 查询对象。
+// 返回监控结果（0表示成功，1表示失败）和结果的说明。
+func classifyTrackingSearch(ts *_rpcclient.TrackingSearch) (int, string) {
+	// 注意：此处规则和接口查询不同，result_status=0表示成功；result_status=1表示失败！！！！
+	resultStatus := 1
+	resultNote := ""
+	if ts.AgentCode == _agent.AcSuccess || ts.AgentCode == _agent.AcSuccess2 {
+		resultStatus = 0
+	} else if ts.AgentCode == _agent.AcNoTracking {
+		resultStatus = 0
+		resultNote = "未查询到单号"
+	} else if ts.AgentCode == _agent.AcParseFailed {
+		resultNote = "无法解析目标网站页面"
+	} else if ts.AgentCode == _agent.AcTimeout {
+		resultNote = "查询目标网站超时"
+	} else {
+		resultNote = "未知错误"
+	}
+
+	if resultStatus == 1 && ts.Err != "" {
+		resultNote = resultNote + ": " + ts.Err
+	}
+
+	return resultStatus, resultNote
 }
 
-func isPassed(countOfOk, countOfError int, passingRatio float32) bool {
-	countOfTotal := countOfOk + countOfError
-	if countOfTotal == 0 {
-		return true
+// 保存监控结果到监控日志。
+// r 监控结果。
+func saveProbeResult(r *probeResult) {
+	crawlerInfo := r.CrawlerInfo
+	if r.ResultStatus == 0 {
+		log.Printf("[INFO] Crawler %s(id=%d, carrier-code=%s, tracking-no=%s) is OK\n", crawlerInfo.Name, crawlerInfo.Id, crawlerInfo.CarrierCode, crawlerInfo.HeartBeatNo)
 	} else {
-		return float32(countOfOk)/float32(countOfTotal) >= passingRatio
+		log.Printf("[WARN] Crawler %s(id=%d, carrier-code=%s, tracking-no=%s) has ERROR\n", crawlerInfo.Name, crawlerInfo.Id, crawlerInfo.CarrierCode, crawlerInfo.HeartBeatNo)
+	}
+
+	_db.SaveHealthLog(crawlerInfo.Id, r.TrackingSearch.TrackingNo, int(r.Timing), r.ResultStatus, r.EndTime, r.TrackingSearch.AgentRawText, r.ResultNote)
+	healthCounter.Add(crawlerInfo.Id, r.EndTime, r.ResultStatus == 0, int(r.Timing))
+	_metrics.ObserveCheck(crawlerInfo.Id, crawlerInfo.CarrierCode, time.Duration(r.Timing)*time.Millisecond, int(r.TrackingSearch.AgentCode))
+}
+
+// 执行一轮爬虫监控。
+// ctx 本轮的上下文，如果被取消，那么在下一个检查点放弃本轮剩余的工作。
+// filter 用于筛选本轮需要监控的爬虫。
+func doCheck(ctx context.Context, filter func(*_db.CrawlerInfoPo) bool) {
+	now := time.Now()
+
+	crawlerInfoList := make([]*_db.CrawlerInfoPo, 0)
+	crawlerIds := make(map[int64]bool)
+	for _, crawlerInfo := range _db.QueryAllCrawlerInfos(now) {
+		crawlerIds[crawlerInfo.Id] = true
+		_metrics.SetCrawlerInfo(crawlerInfo.Id, crawlerInfo.Name, crawlerInfo.CarrierCode)
+		if filter(crawlerInfo) {
+			crawlerInfoList = append(crawlerInfoList, crawlerInfo)
+		}
+	}
+	log.Printf("[INFO] %d active crawler(s) found\n", len(crawlerInfoList))
+
+	// 删除已经停用的爬虫的指标。
+	_metrics.RetainCrawlers(crawlerIds)
+
+	if len(crawlerInfoList) == 0 {
+		return
+	}
+
+	// 加载当前生效的静默规则。
+	refreshAlertSilences(alertSuppressor, now)
+
+	results, err := probeCrawlers(ctx, crawlerInfoList)
+	if err != nil {
+		log.Printf("[ERROR] Cannot probe %d crawler(s): %s\n", len(crawlerInfoList), err)
+		return
+	}
+
+	for _, r := range results {
+		saveProbeResult(r)
+	}
+
+	checkTime := time.Now()
+	healthUpdates.Add(1)
+	go func() {
+		defer healthUpdates.Done()
+		defer _utils.RecoverPanic()
+
+		updateCrawlerHealth(crawlerInfoList, results, checkTime)
+	}()
+}
+
+// 根据本轮的监控结果更新爬虫的健康状态，并在可用性变化时发送告警。
+// crawlerInfoList 本轮监控的爬虫。
+// results 本轮的监控结果。
+// checkTime 本轮结束的时间。
+func updateCrawlerHealth(crawlerInfoList []*_db.CrawlerInfoPo, results []*probeResult, checkTime time.Time) {
+	// 丢弃过期的监控结果。
+	healthCounter.Prune(checkTime)
+
+	resolver := currentHealthPolicies()
+	policies := make(map[int64]_health.Policy)
+	stats := make(map[int64]_health.Stats)
+	for _, crawlerInfo := range crawlerInfoList {
+		policy := resolver.Resolve(crawlerInfo.Id, crawlerInfo.CarrierType)
+		policies[crawlerInfo.Id] = policy
+		stats[crawlerInfo.Id] = healthCounter.Stats(crawlerInfo.Id, policy.Window(), checkTime)
+	}
+
+	// 每次执行爬虫监控之后，推进爬虫的健康状态。
+	for _, r := range results {
+		crawlerInfo := r.CrawlerInfo
+		crawlerId := crawlerInfo.Id
+		success := r.ResultStatus == 0
+
+		st := stats[crawlerId]
+		verdict := policies[crawlerId].Evaluate(st.CountOfOk, st.CountOfError)
+
+		// 静默或者维护期间可以冻结爬虫的健康状态。
+		if sp := alertSuppressor.Match(crawlerId, crawlerInfo.CarrierCode, checkTime); sp != nil && sp.FreezeHealth {
+			log.Printf("[INFO] Health of crawler %s(id=%d, carrier-code=%s) is frozen: %s\n", crawlerInfo.Name, crawlerInfo.Id, crawlerInfo.CarrierCode, sp.Reason)
+			continue
+		}
+
+		t := healthMachine.Advance(crawlerId, success, verdict, checkTime)
+
+		state := healthMachine.State(crawlerId)
+		_metrics.SetCrawlerState(crawlerId, crawlerInfo.CarrierCode, int(state), state.IsHealthy())
+
+		if t == nil {
+			continue
+		}
+
+		log.Printf("[INFO] Crawler %s(id=%d, carrier-code=%s) changed from %s to %s: %s\n", crawlerInfo.Name, crawlerInfo.Id, crawlerInfo.CarrierCode, t.From, t.To, t.Reason)
+		_db.SaveHealthTransition(crawlerId, t.From.String(), t.To.String(), t.Reason, t.Time)
+
+		// 只有可用性发生变化时才需要更新爬虫的当前状态。
+		if t.From != _health.StateUnknown && t.From.IsHealthy() == t.To.IsHealthy() {
+			continue
+		}
+
+		if t.To.IsHealthy() {
+			if _db.UpdateCrawlerInfoHealth(crawlerId, 1) > 0 {
+				log.Printf("[INFO] Update crawler %s(id=%d, carrier-code=%s) to OK\n", crawlerInfo.Name, crawlerInfo.Id, crawlerInfo.CarrierCode)
+			}
+		} else {
+			if _db.UpdateCrawlerInfoHealth(crawlerId, 0) > 0 {
+				log.Printf("[INFO] Update crawler %s(id=%d, carrier-code=%s) to ERROR\n", crawlerInfo.Name, crawlerInfo.Id, crawlerInfo.CarrierCode)
+			}
+		}
+
+		// 首次监控就正常的爬虫不需要告警。
+		if t.From == _health.StateUnknown && t.To.IsHealthy() {
+			continue
+		}
+
+		ev := &_alert.Event{
+			Kind:         _alert.KindDown,
+			CrawlerId:    crawlerInfo.Id,
+			CrawlerName:  crawlerInfo.Name,
+			CarrierCode:  crawlerInfo.CarrierCode,
+			HeartBeatNo:  crawlerInfo.HeartBeatNo,
+			ResultNote:   r.ResultNote,
+			FromState:    t.From.String(),
+			ToState:      t.To.String(),
+			CountOfOk:    st.CountOfOk,
+			CountOfError: st.CountOfError,
+			Reason:       t.Reason,
+			Time:         t.Time,
+		}
+		if t.To.IsHealthy() {
+			ev.Kind = _alert.KindRecovered
+		}
+
+		if ok, reason := alertSuppressor.Allow(ev); !ok {
+			log.Printf("[INFO] Alert %s of crawler %s(id=%d, carrier-code=%s) is suppressed: %s\n", ev.Kind, crawlerInfo.Name, crawlerInfo.Id, crawlerInfo.CarrierCode, reason)
+			continue
+		}
+
+		dispatchAlert(ev)
 	}
 }
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_cache "com.cne/ai-tracking-monitor/cache"
+	_db "com.cne/ai-tracking-monitor/db"
+	_health "com.cne/ai-tracking-monitor/health"
+	_queue "com.cne/ai-tracking-monitor/queue"
+	_simulator "com.cne/ai-tracking-monitor/simulator"
+)
+
+// 初始化监控所需的配置、内存数据库、内存缓存和队列，并启动模拟查询代理。
+// script 按照运输商编号指定的模拟查询代理的行为，每个运输商创建一个爬虫，爬虫ID按照运输商编号的顺序从1开始。
+// 返回用于准备和检查数据的数据库连接。
+func initTestCheck(t *testing.T, carrierCodes []string, script map[string]_simulator.Behaviour) *sql.DB {
+	t.Helper()
+
+	// 内存数据库只在同一个连接中可见，所以使用共享缓存，以便测试通过另一个连接准备和检查数据。
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared&_pragma=busy_timeout(5000)", strings.ReplaceAll(t.Name(), "/", "_"))
+
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	config := fmt.Sprintf(`{
+	"DB": {"Driver": "sqlite", "DSN": %q},
+	"Redis": {"Driver": "memory"},
+	"Health": {"Window": "1h", "MinSamples": 1, "FailuresToDegrade": 1, "FailuresToDown": 2, "SuccessesToRecover": 1}
+}`, dsn)
+	if err := ioutil.WriteFile(configFile, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadConfig(configFile); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { alertDispatcher.Close() })
+
+	if err := _db.InitDB(configuration.DB.Driver, configuration.DB.DSN); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _db.Close() })
+	seedHealthCounter(healthCounter, time.Now())
+
+	if err := initCacheAndQueue(&configuration.Redis); err != nil {
+		t.Fatal(err)
+	}
+
+	fixture, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { fixture.Close() })
+
+	for i, carrierCode := range carrierCodes {
+		id := i + 1
+		mustExec(t, fixture, `insert into carrier_info (id, carrier_code, carrier_type, status) values (?, ?, 0, 1)`, id, carrierCode)
+		mustExec(t, fixture, `insert into tracking_crawler_info (id, carrier_id, heart_beat_no, name, type, start_time, end_time) values (?, ?, ?, ?, 'PYTHON', ?, ?)`,
+			id, id, "HB"+carrierCode, "crawler-"+carrierCode, time.Now().AddDate(-1, 0, 0), time.Now().AddDate(1, 0, 0))
+		mustExec(t, fixture, `insert into tracking_crawler_param (info_id) values (?)`, id)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	sim := _simulator.New(_cache.Default(), _queue.Default(), _simulator.Options{Script: script, Delay: 10 * time.Millisecond, PollInterval: 10 * time.Millisecond})
+	go func() {
+		defer close(done)
+		sim.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	return fixture
+}
+
+func mustExec(t *testing.T, db_ *sql.DB, query string, args ...interface{}) {
+	t.Helper()
+
+	if _, err := db_.Exec(query, args...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 执行一轮监控，并等待健康状态更新结束。
+func runTestCheck(t *testing.T) {
+	t.Helper()
+
+	// 静默的查询代理永不返回，所以需要较短的超时时间。
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	doCheck(ctx, func(*_db.CrawlerInfoPo) bool { return true })
+	healthUpdates.Wait()
+}
+
+func TestDoCheckWithSimulator(t *testing.T) {
+	carrierCodes := []string{"DHL", "UPS", "FEDEX", "TNT"}
+	fixture := initTestCheck(t, carrierCodes, map[string]_simulator.Behaviour{
+		"DHL":   _simulator.BehaviourSuccess,
+		"UPS":   _simulator.BehaviourNoTracking,
+		"FEDEX": _simulator.BehaviourMalformed,
+		"TNT":   _simulator.BehaviourSilent,
+	})
+
+	runTestCheck(t)
+	runTestCheck(t)
+
+	// 每个爬虫每轮一条监控日志。
+	wantStatus := map[int64]int{1: 0, 2: 0, 3: 1, 4: 1}
+	wantNote := map[int64]string{1: "", 2: "未查询到单号", 3: "无法解析目标网站页面", 4: "查询目标网站超时"}
+	for crawlerId, status := range wantStatus {
+		logs := _db.QueryHealthLogsByCrawler(crawlerId, time.Now().Add(-time.Hour), 10)
+		if len(logs) != 2 {
+			t.Errorf("crawler %d: expected 2 health logs but got %d", crawlerId, len(logs))
+			continue
+		}
+		for _, po := range logs {
+			if po.ResultStatus != status {
+				t.Errorf("crawler %d: expected result status %d but got %d", crawlerId, status, po.ResultStatus)
+			}
+			if !strings.HasPrefix(po.ResultNote, wantNote[crawlerId]) {
+				t.Errorf("crawler %d: expected result note %q but got %q", crawlerId, wantNote[crawlerId], po.ResultNote)
+			}
+			if po.TrackingNo != "HB"+carrierCodes[crawlerId-1] {
+				t.Errorf("crawler %d: unexpected tracking no %s", crawlerId, po.TrackingNo)
+			}
+		}
+	}
+
+	// 成功和未查询到单号的爬虫首次监控之后即为正常，失败的爬虫先降级，然后不可用。
+	wantTransitions := map[int64]string{
+		1: "UNKNOWN->OK",
+		2: "UNKNOWN->OK",
+		3: "UNKNOWN->DEGRADED,DEGRADED->DOWN",
+		4: "UNKNOWN->DEGRADED,DEGRADED->DOWN",
+	}
+	rows, err := fixture.Query(`select crawler_id, from_state, to_state from crawler_health_transition order by id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transitions := make(map[int64][]string)
+	for rows.Next() {
+		var crawlerId int64
+		var from, to string
+		if err := rows.Scan(&crawlerId, &from, &to); err != nil {
+			t.Fatal(err)
+		}
+		transitions[crawlerId] = append(transitions[crawlerId], from+"->"+to)
+	}
+	rows.Close()
+	for crawlerId, want := range wantTransitions {
+		if got := strings.Join(transitions[crawlerId], ","); got != want {
+			t.Errorf("crawler %d: expected transitions %s but got %s", crawlerId, want, got)
+		}
+	}
+
+	wantStates := map[int64]_health.State{1: _health.StateOk, 2: _health.StateOk, 3: _health.StateDown, 4: _health.StateDown}
+	for crawlerId, want := range wantStates {
+		if got := healthMachine.State(crawlerId); got != want {
+			t.Errorf("crawler %d: expected state %s but got %s", crawlerId, want, got)
+		}
+
+		// 注意：此处result_status=1表示正常，result_status=0表示异常。
+		var resultStatus int
+		if err := fixture.QueryRow(`select result_status from tracking_crawler_info where id = ?`, crawlerId).Scan(&resultStatus); err != nil {
+			t.Fatal(err)
+		}
+		if healthy := resultStatus == 1; healthy != want.IsHealthy() {
+			t.Errorf("crawler %d: expected healthy %v but got result status %d", crawlerId, want.IsHealthy(), resultStatus)
+		}
+	}
+}
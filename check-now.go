@@ -0,0 +1,141 @@
+// 该模块实现了按需立即监控指定的爬虫。
+// @Author: Haart
+// @Created: 2026-10-18
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	_agent "com.cne/ai-tracking-monitor/agent"
+	_db "com.cne/ai-tracking-monitor/db"
+	_rpcclient "com.cne/ai-tracking-monitor/rpcclient"
+)
+
+// 表示按需监控的请求。
+type checkNowRequest struct {
+	CrawlerIds   []int64  `json:"crawlerIds"`   // 需要监控的爬虫ID。
+	CarrierCodes []string `json:"carrierCodes"` // 需要监控的运输商编号。
+	Record       bool     `json:"record"`       // 是否将监控结果保存到监控日志。
+}
+
+// 表示按需监控的结果。
+type checkNowResultVo struct {
+	CrawlerId   int64                     `json:"crawlerId"`   // 爬虫ID。
+	CrawlerName string                    `json:"crawlerName"` // 爬虫名称。
+	CarrierCode string                    `json:"carrierCode"` // 运输商编号。
+	TrackingNo  string                    `json:"trackingNo"`  // 监控单号。
+	SeqNo       string                    `json:"seqNo"`       // 查询流水号。
+	Ok          bool                      `json:"ok"`          // 是否成功。
+	ResultNote  string                    `json:"resultNote"`  // 监控结果的说明。
+	Timing      int64                     `json:"timing"`      // 耗时（毫秒）。
+	AgentCode   _agent.AgCode             `json:"agentCode"`   // 查询代理的返回码。
+	AgentName   string                    `json:"agentName"`   // 查询代理的名字。
+	Err         string                    `json:"err"`         // 查询代理返回的错误消息。
+	Events      _rpcclient.TrackingEvents `json:"events"`      // 查询代理返回的事件列表。
+}
+
+func newCheckNowResultVo(r *probeResult) *checkNowResultVo {
+	ts := r.TrackingSearch
+	return &checkNowResultVo{
+		CrawlerId:   r.CrawlerInfo.Id,
+		CrawlerName: r.CrawlerInfo.Name,
+		CarrierCode: r.CrawlerInfo.CarrierCode,
+		TrackingNo:  ts.TrackingNo,
+		SeqNo:       ts.SeqNo,
+		Ok:          r.ResultStatus == 0,
+		ResultNote:  r.ResultNote,
+		Timing:      r.Timing,
+		AgentCode:   ts.AgentCode,
+		AgentName:   ts.AgentName,
+		Err:         ts.Err,
+		Events:      ts.Events,
+	}
+}
+
+// 选择爬虫。
+// crawlerIds 爬虫ID。
+// carrierCodes 运输商编号。
+// 返回所有匹配任一爬虫ID或者运输商编号的活动爬虫。
+func selectCrawlerInfos(crawlerIds []int64, carrierCodes []string) ([]*_db.CrawlerInfoPo, error) {
+	if len(crawlerIds) == 0 && len(carrierCodes) == 0 {
+		return nil, fmt.Errorf("crawler ids or carrier codes should be specified")
+	}
+
+	result := make([]*_db.CrawlerInfoPo, 0)
+	for _, crawlerInfo := range _db.QueryAllCrawlerInfos(time.Now()) {
+		matched := false
+		for _, id := range crawlerIds {
+			if id == crawlerInfo.Id {
+				matched = true
+				break
+			}
+		}
+		for _, carrierCode := range carrierCodes {
+			if strings.EqualFold(strings.TrimSpace(carrierCode), crawlerInfo.CarrierCode) {
+				matched = true
+				break
+			}
+		}
+
+		if matched {
+			result = append(result, crawlerInfo)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no active crawler matches crawler ids %v or carrier codes %v", crawlerIds, carrierCodes)
+	}
+
+	return result, nil
+}
+
+// 立即监控指定的爬虫，执行和轮询相同的流程，但是不更新爬虫的健康状态。
+// ctx 上下文。
+// crawlerInfoList 需要监控的爬虫。
+// record 是否将监控结果保存到监控日志。
+// 返回监控结果。
+func checkNow(ctx context.Context, crawlerInfoList []*_db.CrawlerInfoPo, record bool) ([]*checkNowResultVo, error) {
+	results, err := probeCrawlers(ctx, crawlerInfoList)
+	if err != nil {
+		return nil, err
+	}
+
+	vos := make([]*checkNowResultVo, 0, len(results))
+	for _, r := range results {
+		if record {
+			saveProbeResult(r)
+		}
+
+		vos = append(vos, newCheckNowResultVo(r))
+	}
+
+	return vos, nil
+}
+
+// POST /api/check
+// 立即监控指定的爬虫，请求体是 `checkNowRequest`，同步返回监控结果。
+func apiCheckNow(r *http.Request) (interface{}, error) {
+	if r.Method != http.MethodPost {
+		return nil, newApiError(http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+	}
+
+	req := checkNowRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, newApiError(http.StatusBadRequest, "illegal request: %s", err)
+	}
+	if len(req.CrawlerIds) == 0 && len(req.CarrierCodes) == 0 {
+		return nil, newApiError(http.StatusBadRequest, "crawler ids or carrier codes should be specified")
+	}
+
+	crawlerInfoList, err := selectCrawlerInfos(req.CrawlerIds, req.CarrierCodes)
+	if err != nil {
+		return nil, newApiError(http.StatusNotFound, "%s", err)
+	}
+
+	return checkNow(r.Context(), crawlerInfoList, req.Record)
+}
@@ -0,0 +1,589 @@
+// 该模块实现了命令行的子命令。
+// @Author: Haart
+// @Created: 2026-10-18
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	_db "com.cne/ai-tracking-monitor/db"
+	_metrics "com.cne/ai-tracking-monitor/metrics"
+	_rpcclient "com.cne/ai-tracking-monitor/rpcclient"
+	_types "com.cne/ai-tracking-monitor/types"
+	_utils "com.cne/ai-tracking-monitor/utils"
+)
+
+// 表示一个子命令。
+type command struct {
+	Name        string                    // 子命令的名字。
+	Usage       string                    // 子命令的参数说明。
+	Description string                    // 子命令的功能说明。
+	Run         func(args []string) error // 子命令的执行体，参数不包含子命令的名字。
+}
+
+// 表示子命令要求以指定的状态码退出，用于向调用者报告监控结果。
+type exitStatus int
+
+func (s exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(s))
+}
+
+var (
+	commands []*command // 所有的子命令。
+)
+
+func init() {
+	commands = []*command{
+		{Name: "run", Usage: "[CONFIG_FILE]", Description: "Run as daemon (default)", Run: cmdRun},
+		{Name: "check", Usage: "[-crawler IDS] [-carrier CODES] [-record] [-timeout DURATION] [CONFIG_FILE]", Description: "Run one round of checking, exit non-zero if any crawler fails", Run: cmdCheck},
+		{Name: "list", Usage: "[CONFIG_FILE]", Description: "List active crawlers", Run: cmdList},
+		{Name: "history", Usage: "[-since DURATION] [-limit N] [-period hour|day] CRAWLER_ID [CONFIG_FILE]", Description: "Show recent health logs of crawler", Run: cmdHistory},
+		{Name: "verify", Usage: "[CONFIG_FILE]", Description: "Verify configuration and connections of database and Redis", Run: cmdVerify},
+		{Name: "nagios", Usage: "-crawler ID|-carrier CODE [-warning DURATION] [-critical DURATION] [-record] [-timeout DURATION] [CONFIG_FILE]", Description: "Check one crawler or carrier as Nagios/Icinga plugin", Run: cmdNagios},
+		{Name: "probe", Usage: "[-crawler IDS] [-carrier CODES] [-record] [-timeout DURATION] [CONFIG_FILE]", Description: "Check crawlers now and print results as JSON", Run: cmdProbe},
+		{Name: "migrate", Usage: "up|down|status [-to VERSION] [-steps N] [CONFIG_FILE]", Description: "Migrate schema of database", Run: cmdMigrate},
+		{Name: "silence", Usage: "[-for DURATION] -reason REASON [-freeze] CRAWLER_ID|CARRIER_CODE [CONFIG_FILE]", Description: "Silence alerts of crawler id or carrier code", Run: cmdSilence},
+		{Name: "unsilence", Usage: "SILENCE_ID [CONFIG_FILE]", Description: "End silence by id", Run: cmdUnsilence},
+		{Name: "simulate", Usage: "[-script CODE=BEHAVIOUR,...] [-default BEHAVIOUR] [-delay DURATION] [-slow DURATION] [-name NAME] [-no-notify] [CONFIG_FILE]", Description: "Run as fake query agent for testing", Run: cmdSimulate},
+	}
+}
+
+// 根据名字查找子命令。
+// name 子命令的名字。
+// 返回对应的子命令，如果不存在则返回nil。
+func findCommand(name string) *command {
+	for _, c := range commands {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// 输出所有子命令的说明。
+func printCommands() {
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.Name, c.Description)
+	}
+}
+
+// 创建子命令的参数集。
+// c 子命令。
+func newFlagSet(c *command) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.Name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s %s\n", AppName, c.Name, c.Usage)
+		fmt.Fprintf(os.Stderr, "%s\n", c.Description)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// 创建子命令使用的上下文，收到退出信号时被取消。
+// timeout 超时时间，非正数表示不设置超时时间。
+func newCommandContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
+	if timeout <= 0 {
+		return ctx, stop
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// 解析逗号分隔的爬虫ID和运输商编号。
+// crawlers 逗号分隔的爬虫ID。
+// carriers 逗号分隔的运输商编号。
+func parseCrawlerFilter(crawlers, carriers string) ([]int64, []string, error) {
+	crawlerIds := make([]int64, 0)
+	for _, s := range strings.Split(crawlers, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		if id, err := strconv.ParseInt(s, 10, 64); err != nil {
+			return nil, nil, fmt.Errorf("illegal crawler id: %s", s)
+		} else {
+			crawlerIds = append(crawlerIds, id)
+		}
+	}
+
+	carrierCodes := make([]string, 0)
+	for _, s := range strings.Split(carriers, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			carrierCodes = append(carrierCodes, s)
+		}
+	}
+
+	return crawlerIds, carrierCodes, nil
+}
+
+// 加载配置并初始化数据库。
+// configFile 配置文件路径。
+func initWithDB(configFile string) error {
+	if err := loadConfig(strings.TrimSpace(configFile)); err != nil {
+		return fmt.Errorf("cannot load configuration: %w", err)
+	}
+
+	return _db.InitDB(configuration.DB.Driver, configuration.DB.DSN)
+}
+
+// 加载配置并初始化数据库、缓存和队列。
+// configFile 配置文件路径。
+func initWithDBAndQueue(configFile string) error {
+	if err := initWithDB(configFile); err != nil {
+		return err
+	}
+
+	return initCacheAndQueue(&configuration.Redis)
+}
+
+// run [CONFIG_FILE]
+// 作为守护进程运行，按照轮询计划监控所有的爬虫。
+func cmdRun(args []string) error {
+	fs := newFlagSet(findCommand("run"))
+	fs.Parse(args)
+
+	if err := loadConfig(strings.TrimSpace(fs.Arg(0))); err != nil {
+		return fmt.Errorf("cannot load configuration: %w", err)
+	}
+
+	// 输出pid文件。
+	pidFilename := AppName + "-pid"
+	if runtime.GOOS != "windows" {
+		pidFilename = "/var/run/" + pidFilename
+	}
+	if pidFile, err := os.Create(pidFilename); err == nil {
+		pidFile.WriteString(fmt.Sprintf("%v", os.Getpid()))
+		pidFile.Close()
+
+		defer os.Remove(pidFilename)
+	}
+
+	// 初始化数据库。
+	if err := _db.InitDB(configuration.DB.Driver, configuration.DB.DSN); err != nil {
+		return err
+	}
+
+	// 表结构落后时拒绝启动。
+	if err := checkSchemaVersion(_db.Default()); err != nil {
+		return err
+	}
+
+	// 恢复爬虫的健康状态和监控结果的计数。
+	restoreHealthStates(healthMachine)
+	seedHealthCounter(healthCounter, time.Now())
+
+	// 初始化缓存和队列。
+	if err := initCacheAndQueue(&configuration.Redis); err != nil {
+		return err
+	}
+
+	// 注册队列长度指标。
+	for _, priority := range []_types.Priority{_types.PriorityHighest, _types.PriorityHigh, _types.PriorityLow} {
+		priority := priority
+		_metrics.RegisterQueueDepth(priority.String(), func() float64 {
+			if l, err := _rpcclient.QueueLength(context.Background(), priority); err != nil {
+				return -1
+			} else {
+				return float64(l)
+			}
+		})
+	}
+
+	// 启动HTTP服务。
+	if err := startHttpServer(&configuration.Http); err != nil {
+		return err
+	}
+
+	// 开始服务。
+	return runForEver()
+}
+
+// check [-crawler IDS] [-carrier CODES] [-record] [-timeout DURATION] [CONFIG_FILE]
+// 执行一轮监控，以表格形式输出监控结果，如果有爬虫监控失败则以状态码1退出。
+func cmdCheck(args []string) error {
+	fs := newFlagSet(findCommand("check"))
+	crawlers := fs.String("crawler", "", "Comma separated crawler ids, all active crawlers if both -crawler and -carrier are empty")
+	carriers := fs.String("carrier", "", "Comma separated carrier codes")
+	record := fs.Bool("record", false, "Save results into health log, a running daemon counts them only after restart")
+	timeout := fs.Duration("timeout", _rpcclient.DefaultPullTimeout, "Time to wait for results")
+	fs.Parse(args)
+
+	crawlerIds, carrierCodes, err := parseCrawlerFilter(*crawlers, *carriers)
+	if err != nil {
+		return err
+	}
+
+	if err := initWithDBAndQueue(fs.Arg(0)); err != nil {
+		return err
+	}
+
+	var crawlerInfoList []*_db.CrawlerInfoPo
+	if len(crawlerIds) == 0 && len(carrierCodes) == 0 {
+		crawlerInfoList = _db.QueryAllCrawlerInfos(time.Now())
+	} else if crawlerInfoList, err = selectCrawlerInfos(crawlerIds, carrierCodes); err != nil {
+		return err
+	}
+	if len(crawlerInfoList) == 0 {
+		fmt.Printf("no active crawler found\n")
+		return nil
+	}
+
+	ctx, cancel := newCommandContext(*timeout)
+	defer cancel()
+
+	results, err := checkNow(ctx, crawlerInfoList, *record)
+	if err != nil {
+		return err
+	}
+
+	failed := len(crawlerInfoList) - len(results)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID\tNAME\tCARRIER\tTRACKING NO\tTIMING\tRESULT\tNOTE\n")
+	for _, r := range results {
+		result := "OK"
+		if !r.Ok {
+			result = "ERROR"
+			failed++
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%dms\t%s\t%s\n", r.CrawlerId, r.CrawlerName, r.CarrierCode, r.TrackingNo, r.Timing, result, r.ResultNote)
+	}
+	tw.Flush()
+
+	fmt.Printf("%d crawler(s) checked, %d result(s) returned, %d failed\n", len(crawlerInfoList), len(results), failed)
+
+	if failed > 0 {
+		return exitStatus(1)
+	}
+
+	return nil
+}
+
+// list [CONFIG_FILE]
+// 以表格形式输出所有的活动爬虫及其健康状态。
+func cmdList(args []string) error {
+	fs := newFlagSet(findCommand("list"))
+	fs.Parse(args)
+
+	if err := initWithDB(fs.Arg(0)); err != nil {
+		return err
+	}
+
+	restoreHealthStates(healthMachine)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID\tNAME\tCARRIER\tCARRIER TYPE\tAGENT TYPE\tHEART BEAT NO\tSTATE\n")
+	for _, crawlerInfo := range _db.QueryAllCrawlerInfos(time.Now()) {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", crawlerInfo.Id, crawlerInfo.Name, crawlerInfo.CarrierCode, crawlerInfo.CarrierType.String(), crawlerInfo.Type, crawlerInfo.HeartBeatNo, healthMachine.State(crawlerInfo.Id))
+	}
+	tw.Flush()
+
+	return nil
+}
+
+// history [-since DURATION] [-limit N] [-period hour|day] CRAWLER_ID [CONFIG_FILE]
+// 以表格形式输出指定爬虫最近的监控日志，原始监控日志已经被清理的时间范围输出按小时、按天的汇总。
+// 如果指定了`-period`，那么只输出指定周期的汇总。
+func cmdHistory(args []string) error {
+	fs := newFlagSet(findCommand("history"))
+	since := fs.Duration("since", 0, "Show health logs since this duration ago, window of health policy if not specified")
+	limit := fs.Int("limit", 20, "Maximum number of health logs")
+	period := fs.String("period", "", "Show hourly or daily aggregates instead of raw health logs, can be hour or day")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return fmt.Errorf("crawler id should be specified")
+	}
+
+	crawlerId, err := strconv.ParseInt(strings.TrimSpace(fs.Arg(0)), 10, 64)
+	if err != nil {
+		return fmt.Errorf("illegal crawler id: %s", fs.Arg(0))
+	}
+	if *limit <= 0 || *limit > maxHistoryLimit {
+		return fmt.Errorf("limit should be between 1 and %d", maxHistoryLimit)
+	}
+
+	if err := initWithDB(fs.Arg(1)); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	// 从汇总中读取更长时间的历史。
+	if *period != "" {
+		p, err := parseStatPeriod(*period)
+		if err != nil {
+			return err
+		}
+		if *since <= 0 {
+			*since = time.Duration(*limit) * 24 * time.Hour
+			if p == _db.StatPeriodHour {
+				*since = time.Duration(*limit) * time.Hour
+			}
+		}
+
+		fmt.Fprintf(tw, "TIME\tOK\tERROR\tP50\tP95\n")
+		for _, po := range _db.QueryHealthStatsByCrawler(crawlerId, p, time.Now().Add(-*since), time.Now(), *limit) {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%dms\t%dms\n", _utils.FormatTime(po.StartTime), po.CountOfOk, po.CountOfError, po.TimingP50, po.TimingP95)
+		}
+		tw.Flush()
+
+		return nil
+	}
+
+	if *since <= 0 {
+		*since = configuration.Health.Window.Duration()
+	}
+
+	startTime := time.Now().Add(-*since)
+	logs := _db.QueryHealthLogsByCrawler(crawlerId, startTime, *limit)
+
+	fmt.Fprintf(tw, "TIME\tTRACKING NO\tTIMING\tRESULT\tNOTE\n")
+	for _, po := range logs {
+		result := "OK"
+		if po.ResultStatus != 0 {
+			result = "ERROR"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%dms\t%s\t%s\n", _utils.FormatTime(po.CreateTime), po.TrackingNo, po.Timing, result, po.ResultNote)
+	}
+	tw.Flush()
+
+	// 原始监控日志已经被清理的时间范围使用汇总。
+	if stats := queryHealthStatsBeforeRawLogs(crawlerId, startTime, *limit-len(logs)); len(stats) > 0 {
+		fmt.Printf("\nAggregated health logs:\n")
+		fmt.Fprintf(tw, "PERIOD\tTIME\tOK\tERROR\tP50\tP95\n")
+		for _, po := range stats {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%dms\t%dms\n", po.Period, _utils.FormatTime(po.StartTime), po.CountOfOk, po.CountOfError, po.TimingP50, po.TimingP95)
+		}
+		tw.Flush()
+	}
+
+	return nil
+}
+
+// verify [CONFIG_FILE]
+// 检查配置文件，并测试数据库和Redis的连接。
+func cmdVerify(args []string) error {
+	fs := newFlagSet(findCommand("verify"))
+	fs.Parse(args)
+
+	if err := loadConfig(strings.TrimSpace(fs.Arg(0))); err != nil {
+		return fmt.Errorf("cannot load configuration: %w", err)
+	}
+	if s, err := formatMaskedConfig(configuration); err != nil {
+		return fmt.Errorf("cannot format configuration: %w", err)
+	} else {
+		fmt.Printf("configuration:\n%s\n", s)
+	}
+
+	if err := _db.InitDB(configuration.DB.Driver, configuration.DB.DSN); err != nil {
+		return fmt.Errorf("cannot connect to database: %w", err)
+	}
+	fmt.Printf("database(%s): OK\n", configuration.DB.Driver)
+
+	if current, latest, err := _db.QuerySchemaVersion(); err != nil {
+		return fmt.Errorf("cannot query schema version: %w", err)
+	} else {
+		fmt.Printf("schema: version %d of %d\n", current, latest)
+	}
+
+	if err := initCacheAndQueue(&configuration.Redis); err != nil {
+		return fmt.Errorf("cannot connect to redis: %w", err)
+	}
+	fmt.Printf("redis(%s): OK\n", configuration.Redis.Driver)
+
+	return nil
+}
+
+// probe [-crawler IDS] [-carrier CODES] [-record] [-timeout DURATION] [CONFIG_FILE]
+// 立即监控指定的爬虫，并将监控结果以JSON格式输出到标准输出。
+func cmdProbe(args []string) error {
+	fs := newFlagSet(findCommand("probe"))
+	crawlers := fs.String("crawler", "", "Comma separated crawler ids")
+	carriers := fs.String("carrier", "", "Comma separated carrier codes")
+	record := fs.Bool("record", false, "Save results into health log, a running daemon counts them only after restart")
+	timeout := fs.Duration("timeout", _rpcclient.DefaultPullTimeout, "Time to wait for results")
+	fs.Parse(args)
+
+	crawlerIds, carrierCodes, err := parseCrawlerFilter(*crawlers, *carriers)
+	if err != nil {
+		return err
+	}
+
+	if err := initWithDBAndQueue(fs.Arg(0)); err != nil {
+		return err
+	}
+
+	crawlerInfoList, err := selectCrawlerInfos(crawlerIds, carrierCodes)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newCommandContext(*timeout)
+	defer cancel()
+
+	results, err := checkNow(ctx, crawlerInfoList, *record)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+// 检查表结构的版本。
+// 如果存在尚未执行的迁移，返回错误。
+// st 待检查的存储。
+func checkSchemaVersion(st _db.Store) error {
+	current, latest, err := _db.QueryStoreSchemaVersion(st)
+	if err != nil {
+		return fmt.Errorf("cannot query schema version: %w", err)
+	}
+	if current < latest {
+		return fmt.Errorf("schema version %d is behind %d, run `%s migrate up` first", current, latest, AppName)
+	}
+
+	return nil
+}
+
+// migrate up|down|status [-to VERSION] [-steps N] [CONFIG_FILE]
+// 执行、回滚或者列出数据库表结构的迁移。
+func cmdMigrate(args []string) error {
+	c := findCommand("migrate")
+	fs := newFlagSet(c)
+	to := fs.Int("to", 0, "Target version of up, latest if 0")
+	steps := fs.Int("steps", 1, "Number of migrations to revert by down")
+
+	if len(args) < 1 {
+		fs.Usage()
+		return fmt.Errorf("action should be specified")
+	}
+	action := args[0]
+	fs.Parse(args[1:])
+
+	if action != "up" && action != "down" && action != "status" {
+		fs.Usage()
+		return fmt.Errorf("unknown action: %s", action)
+	}
+	if *steps <= 0 {
+		return fmt.Errorf("steps should be positive")
+	}
+
+	if err := loadConfig(strings.TrimSpace(fs.Arg(0))); err != nil {
+		return fmt.Errorf("cannot load configuration: %w", err)
+	}
+
+	if err := _db.InitDBWithoutMigration(configuration.DB.Driver, configuration.DB.DSN); err != nil {
+		return err
+	}
+
+	switch action {
+	case "up":
+		ms, err := _db.MigrateUp(*to)
+		for _, m := range ms {
+			fmt.Printf("applied: %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migration(s) applied\n", len(ms))
+	case "down":
+		ms, err := _db.MigrateDown(*steps)
+		for _, m := range ms {
+			fmt.Printf("reverted: %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migration(s) reverted\n", len(ms))
+	default:
+		status, err := _db.QueryMigrationStatus()
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(tw, "VERSION\tNAME\tAPPLIED\n")
+		for _, rec := range status {
+			applied := "-"
+			if rec.Applied {
+				applied = _utils.FormatTime(rec.AppliedTime)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", rec.Version, rec.Name, applied)
+		}
+		tw.Flush()
+	}
+
+	return nil
+}
+
+// silence [-for DURATION] -reason REASON [-freeze] CRAWLER_ID|CARRIER_CODE [CONFIG_FILE]
+// 创建静默规则。
+func cmdSilence(args []string) error {
+	fs := newFlagSet(findCommand("silence"))
+	duration := fs.Duration("for", time.Hour, "Duration of silence")
+	reason := fs.String("reason", "", "Reason of silence")
+	freeze := fs.Bool("freeze", false, "Freeze health of crawlers during silence")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return fmt.Errorf("crawler id or carrier code should be specified")
+	}
+
+	if err := initWithDB(fs.Arg(1)); err != nil {
+		return err
+	}
+
+	if id, err := doSilence(fs.Arg(0), *duration, *reason, *freeze); err != nil {
+		return err
+	} else {
+		fmt.Printf("silence %d created\n", id)
+		return nil
+	}
+}
+
+// unsilence SILENCE_ID [CONFIG_FILE]
+// 提前结束静默规则。
+func cmdUnsilence(args []string) error {
+	fs := newFlagSet(findCommand("unsilence"))
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return fmt.Errorf("silence id should be specified")
+	}
+
+	id, err := strconv.ParseInt(strings.TrimSpace(fs.Arg(0)), 10, 64)
+	if err != nil {
+		return fmt.Errorf("illegal silence id: %s", fs.Arg(0))
+	}
+
+	if err := initWithDB(fs.Arg(1)); err != nil {
+		return err
+	}
+
+	fmt.Printf("%d silence(s) ended\n", _db.ExpireAlertSilence(id, time.Now()))
+	return nil
+}
@@ -0,0 +1,298 @@
+// 该模块实现了按照扩展名解析JSON、YAML和TOML格式的配置文件，严格检查配置项，以及输出隐藏密钥的配置。
+// @Author: Haart
+// @Created: 2026-10-18
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	_types "com.cne/ai-tracking-monitor/types"
+)
+
+const (
+	maskedSecret string = "******" // 表示被隐藏的密钥。
+)
+
+// 读取配置文件，根据扩展名选择格式：`.yaml`和`.yml`是YAML，`.toml`是TOML，其它都是JSON。
+// 所有格式都先解析为通用的树形结构，检查之后转换为JSON，再按照JSON格式解析到配置中，因此配置项的名字和取值在所有格式中都相同。
+// configFile 配置文件的路径。
+// 返回配置文件的树形结构。
+func readConfigTree(configFile string) (interface{}, error) {
+	b, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree interface{}
+	switch strings.ToLower(filepath.Ext(configFile)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(b, &tree); err != nil {
+			return nil, err
+		}
+		tree = normalizeConfigTree(tree)
+	case ".toml":
+		m := make(map[string]interface{})
+		if err := toml.Unmarshal(b, &m); err != nil {
+			return nil, err
+		}
+		tree = m
+	default:
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		if err := dec.Decode(&tree); err != nil {
+			return nil, err
+		}
+	}
+
+	return tree, nil
+}
+
+// 将YAML解析出的非字符串键的映射转换为字符串键的映射，以便转换为JSON。
+func normalizeConfigTree(node interface{}) interface{} {
+	switch n := node.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(n))
+		for k, v := range n {
+			m[fmt.Sprint(k)] = normalizeConfigTree(v)
+		}
+		return m
+	case map[string]interface{}:
+		for k, v := range n {
+			n[k] = normalizeConfigTree(v)
+		}
+		return n
+	case []interface{}:
+		for i, v := range n {
+			n[i] = normalizeConfigTree(v)
+		}
+		return n
+	default:
+		return node
+	}
+}
+
+// 按照配置类型严格检查树形结构，不允许未知的配置项。
+// node 树形结构的节点。
+// t 节点对应的类型。
+// path 节点的路径，用于错误信息。
+func verifyConfigTree(node interface{}, t reflect.Type, path string) error {
+	if node == nil {
+		return nil
+	}
+
+	// 实现了JSON反序列化的类型作为整体检查。
+	if reflect.PtrTo(t).Implements(jsonUnmarshalerType) {
+		return verifyConfigValue(node, t, path)
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: should be an object", configPathOrRoot(path))
+		}
+
+		for k, v := range m {
+			if f, ok := findConfigField(t, k); !ok {
+				return fmt.Errorf("%s: unknown field", joinConfigPath(path, k))
+			} else if err := verifyConfigTree(v, f.Type, joinConfigPath(path, f.Name)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: should be an object", configPathOrRoot(path))
+		}
+
+		for k, v := range m {
+			p := fmt.Sprintf("%s[%s]", path, k)
+			// JSON中整数类型的键也使用字符串表示。
+			if t.Key().Kind() != reflect.String && json.Unmarshal([]byte(k), reflect.New(t.Key()).Interface()) != nil {
+				return fmt.Errorf("%s: illegal key, expected %s", p, t.Key())
+			}
+			if err := verifyConfigTree(v, t.Elem(), p); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		arr, ok := node.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: should be an array", configPathOrRoot(path))
+		}
+
+		for i, v := range arr {
+			if err := verifyConfigTree(v, t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return verifyConfigValue(node, t, path)
+	}
+
+	return nil
+}
+
+// 检查配置项的值能否解析为指定的类型。
+func verifyConfigValue(v interface{}, t reflect.Type, path string) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", configPathOrRoot(path), err)
+	}
+
+	if err := json.Unmarshal(b, reflect.New(t).Interface()); err != nil {
+		var te *json.UnmarshalTypeError
+		if errors.As(err, &te) {
+			return fmt.Errorf("%s: expected %s but got %s", configPathOrRoot(path), te.Type, te.Value)
+		}
+		return fmt.Errorf("%s: %s", configPathOrRoot(path), strings.TrimPrefix(err.Error(), "json: "))
+	}
+
+	return nil
+}
+
+// 按照名字查找结构体的字段，不区分大小写，内嵌结构体的字段被看作外层结构体的字段。
+func findConfigField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if ff, ok := findConfigField(f.Type, name); ok {
+				return ff, true
+			}
+		} else if strings.EqualFold(f.Name, name) {
+			return f, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+func joinConfigPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// 计算创建对象失败时对应的配置项的路径。
+// path 创建对象使用的配置项的路径。
+// err 创建对象时返回的错误，如果包含不正确的字段名，那么追加到路径后面。
+func errorConfigPath(path string, err error) string {
+	if field := _types.ErrorField(err); field != "" {
+		return joinConfigPath(path, field)
+	}
+
+	return path
+}
+
+func configPathOrRoot(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+
+	return path
+}
+
+// 输出配置，其中的口令、密钥、数据库连接字符串中的口令、地址中除协议和主机之外的部分以及请求头都被隐藏。
+// c 配置。
+// 返回缩进的JSON。
+func formatMaskedConfig(c *Configuration) (string, error) {
+	// 通过JSON复制配置，避免修改正在使用的配置。
+	masked := &Configuration{}
+	if b, err := json.Marshal(c); err != nil {
+		return "", err
+	} else if err := json.Unmarshal(b, masked); err != nil {
+		return "", err
+	}
+
+	maskSecrets(reflect.ValueOf(masked).Elem(), "")
+
+	if b, err := json.MarshalIndent(masked, "", "  "); err != nil {
+		return "", err
+	} else {
+		return string(b), nil
+	}
+}
+
+func maskSecrets(v reflect.Value, name string) {
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		if s == "" {
+			return
+		}
+
+		switch name {
+		case "Password", "Secret", "Token", "Headers":
+			v.SetString(maskedSecret)
+		case "DSN":
+			v.SetString(maskDSN(s))
+		case "Url":
+			v.SetString(maskUrl(s))
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath == "" {
+				maskSecrets(v.Field(i), t.Field(i).Name)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			maskSecrets(v.Index(i), name)
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(v.MapIndex(k))
+			maskSecrets(e, name)
+			v.SetMapIndex(k, e)
+		}
+	}
+}
+
+// 隐藏MySQL连接字符串中的口令，比如`user:pass@tcp(host:3306)/db`变为`user:******@tcp(host:3306)/db`。
+func maskDSN(dsn string) string {
+	at := strings.LastIndex(dsn, "@")
+	if at < 0 {
+		return dsn
+	}
+
+	if colon := strings.Index(dsn[:at], ":"); colon >= 0 {
+		return dsn[:colon+1] + maskedSecret + dsn[at:]
+	}
+
+	return dsn
+}
+
+// 隐藏地址中除协议和主机之外的部分，比如`https://hooks.slack.com/services/T0/B0/XXX`变为`https://hooks.slack.com/******`。
+// 群机器人的令牌可能位于用户信息、路径或者查询参数中，比如Slack和飞书的令牌位于路径中。
+func maskUrl(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return maskedSecret
+	}
+
+	masked := u.Scheme + "://" + u.Host
+	if u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" || u.Opaque != "" {
+		masked += "/" + maskedSecret
+	}
+
+	return masked
+}
@@ -0,0 +1,251 @@
+// 该模块实现了按照环境变量和命令行参数覆盖配置，以及从密钥文件读取配置。
+// @Author: Haart
+// @Created: 2026-10-18
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+const (
+	ConfigEnvPrefix  string = "TRACKING_MONITOR_" // 表示覆盖配置的环境变量名的前缀。
+	ConfigFilePrefix string = "@file:"            // 表示从文件读取配置的前缀，通常用于引用密钥文件。
+)
+
+// 表示命令行参数`-set`指定的所有配置，每一项的格式是`PATH=VALUE`。
+type configOverrides []string
+
+func (o *configOverrides) String() string {
+	return strings.Join(*o, ",")
+}
+
+func (o *configOverrides) Set(s string) error {
+	if !strings.Contains(s, "=") {
+		return fmt.Errorf("should be PATH=VALUE")
+	}
+
+	*o = append(*o, s)
+	return nil
+}
+
+// 表示一个可以被覆盖的配置项。
+type configField struct {
+	path  string        // 配置项的路径，比如`Redis.Password`。
+	env   string        // 对应的环境变量名，比如`TRACKING_MONITOR_REDIS_PASSWORD`。
+	value reflect.Value // 配置项的值。
+}
+
+// 列出所有可以被覆盖的配置项，即除了结构体之外的所有字段，内嵌结构体的字段被看作外层结构体的字段。
+// c 配置。
+func listConfigFields(c *Configuration) []*configField {
+	result := make([]*configField, 0)
+	collectConfigFields(reflect.ValueOf(c).Elem(), "", &result)
+	return result
+}
+
+func collectConfigFields(v reflect.Value, path string, result *[]*configField) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		fv := v.Field(i)
+		if f.Anonymous && fv.Kind() == reflect.Struct {
+			collectConfigFields(fv, path, result)
+			continue
+		}
+
+		p := f.Name
+		if path != "" {
+			p = path + "." + f.Name
+		}
+
+		// 实现了JSON反序列化的结构体作为整体处理。
+		if fv.Kind() == reflect.Struct && !fv.Addr().Type().Implements(jsonUnmarshalerType) {
+			collectConfigFields(fv, p, result)
+		} else {
+			*result = append(*result, &configField{path: p, env: ConfigEnvPrefix + toEnvName(p), value: fv})
+		}
+	}
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// 将配置项的路径转换为环境变量名，比如`Schedule.GracePeriod`转换为`SCHEDULE_GRACE_PERIOD`。
+// path 配置项的路径。
+func toEnvName(path string) string {
+	sb := strings.Builder{}
+	rs := []rune(path)
+	for i, r := range rs {
+		if r == '.' {
+			sb.WriteRune('_')
+			continue
+		}
+
+		// 在单词的边界插入下划线，连续的大写字母（比如`DSN`）被看作一个单词。
+		if i > 0 && unicode.IsUpper(r) && rs[i-1] != '.' {
+			prev := rs[i-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (i+1 < len(rs) && unicode.IsLower(rs[i+1])) {
+				sb.WriteRune('_')
+			}
+		}
+		sb.WriteRune(unicode.ToUpper(r))
+	}
+
+	return sb.String()
+}
+
+// 将字符串解析为配置项的值。
+// 字符串类型直接使用原始值，其它类型按照JSON解析，如果失败则作为JSON字符串解析，比如`5m`和`SKIP`。
+// v 配置项的值。
+// s 待解析的字符串。
+func setConfigValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.String {
+		v.SetString(s)
+		return nil
+	}
+
+	nv := reflect.New(v.Type())
+	if err := json.Unmarshal([]byte(s), nv.Interface()); err != nil {
+		quoted, _ := json.Marshal(s)
+		nv = reflect.New(v.Type())
+		if json.Unmarshal(quoted, nv.Interface()) != nil {
+			return err
+		}
+	}
+
+	v.Set(nv.Elem())
+	return nil
+}
+
+// 按照环境变量和命令行参数覆盖配置，命令行参数优先。
+// c 配置，已经包含默认值和配置文件中的配置。
+// overrides 命令行参数`-set`指定的配置。
+func overrideConfig(c *Configuration, overrides []string) error {
+	fields := listConfigFields(c)
+
+	known := make(map[string]bool)
+	for _, f := range fields {
+		known[f.env] = true
+		if s, ok := os.LookupEnv(f.env); ok {
+			if err := setConfigValue(f.value, s); err != nil {
+				return fmt.Errorf("illegal value of environment variable %s: %w", f.env, err)
+			}
+		}
+	}
+
+	// 环境变量名的拼写错误不会导致启动失败，但是需要提示。
+	for _, kv := range os.Environ() {
+		name := strings.SplitN(kv, "=", 2)[0]
+		if strings.HasPrefix(name, ConfigEnvPrefix) && !known[name] {
+			fmt.Fprintf(os.Stderr, "Unknown environment variable %s is ignored\n", name)
+		}
+	}
+
+	for _, o := range overrides {
+		kv := strings.SplitN(o, "=", 2)
+		path := strings.TrimSpace(kv[0])
+
+		found := false
+		for _, f := range fields {
+			if strings.EqualFold(f.path, path) {
+				if err := setConfigValue(f.value, kv[1]); err != nil {
+					return fmt.Errorf("illegal value of %s: %w", f.path, err)
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown configuration field: %s", path)
+		}
+	}
+
+	return nil
+}
+
+// 读取配置中所有以`@file:`开头的字符串引用的文件，并使用文件的内容（去除末尾的空白）替换原来的字符串。
+// c 配置。
+// baseDir 相对路径的基准目录，通常是配置文件所在的目录。
+func resolveConfigSecrets(c *Configuration, baseDir string) error {
+	return resolveSecrets(reflect.ValueOf(c).Elem(), "", baseDir)
+}
+
+func resolveSecrets(v reflect.Value, path string, baseDir string) error {
+	switch v.Kind() {
+	case reflect.String:
+		if s := v.String(); strings.HasPrefix(s, ConfigFilePrefix) {
+			if content, err := readSecretFile(strings.TrimPrefix(s, ConfigFilePrefix), baseDir); err != nil {
+				return fmt.Errorf("cannot read %s: %w", path, err)
+			} else {
+				v.SetString(content)
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+
+			p := path
+			if !f.Anonymous {
+				if p != "" {
+					p += "."
+				}
+				p += f.Name
+			}
+			if err := resolveSecrets(v.Field(i), p, baseDir); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := resolveSecrets(v.Index(i), fmt.Sprintf("%s[%d]", path, i), baseDir); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// 映射的元素不能直接修改，需要复制之后写回。
+		for _, k := range v.MapKeys() {
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(v.MapIndex(k))
+			if err := resolveSecrets(e, fmt.Sprintf("%s[%v]", path, k), baseDir); err != nil {
+				return err
+			}
+			v.SetMapIndex(k, e)
+		}
+	}
+
+	return nil
+}
+
+// 读取密钥文件。
+// fileName 文件名，相对路径基于 `baseDir`。
+// baseDir 相对路径的基准目录。
+// 返回去除末尾空白之后的文件内容。
+func readSecretFile(fileName, baseDir string) (string, error) {
+	fileName = strings.TrimSpace(fileName)
+	if fileName == "" {
+		return "", fmt.Errorf("file name should not be empty")
+	}
+	if !filepath.IsAbs(fileName) {
+		fileName = filepath.Join(baseDir, fileName)
+	}
+
+	if b, err := ioutil.ReadFile(fileName); err != nil {
+		return "", err
+	} else {
+		return strings.TrimRightFunc(string(b), unicode.IsSpace), nil
+	}
+}
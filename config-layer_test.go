@@ -0,0 +1,141 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigLayerPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(configFile, []byte(`{"Redis": {"Host": "file-host", "Port": 6380, "Password": "file"}, "Schedule": {"Interval": "10m"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name      string
+		env       map[string]string
+		overrides []string
+		host      string
+		port      int
+		password  string
+		interval  time.Duration
+	}{
+		{"file over defaults", nil, nil, "file-host", 6380, "file", 10 * time.Minute},
+		{"env over file",
+			map[string]string{"TRACKING_MONITOR_REDIS_PASSWORD": "env", "TRACKING_MONITOR_SCHEDULE_INTERVAL": "3m"},
+			nil, "file-host", 6380, "env", 3 * time.Minute},
+		{"set over env",
+			map[string]string{"TRACKING_MONITOR_REDIS_PASSWORD": "env", "TRACKING_MONITOR_REDIS_PORT": "6381"},
+			[]string{"Redis.Password=set", "redis.host=set-host"}, "set-host", 6381, "set", 10 * time.Minute},
+		{"last set wins", nil, []string{"Schedule.Interval=1m", "Schedule.Interval=2m"}, "file-host", 6380, "file", 2 * time.Minute},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for k, v := range c.env {
+				t.Setenv(k, v)
+			}
+
+			configuration := newConfiguration()
+			if err := loadConfigFromFile(configFile, configuration); err != nil {
+				t.Fatal(err)
+			}
+			if err := overrideConfig(configuration, c.overrides); err != nil {
+				t.Fatal(err)
+			}
+
+			rc := configuration.Redis
+			if rc.Host != c.host || rc.Port != c.port || rc.Password != c.password {
+				t.Errorf("redis should be %s:%d/%s, but got %s:%d/%s", c.host, c.port, c.password, rc.Host, rc.Port, rc.Password)
+			}
+			if interval := configuration.Schedule.Interval.Duration(); interval != c.interval {
+				t.Errorf("interval should be %s, but got %s", c.interval, interval)
+			}
+			// 没有被覆盖的配置项保持默认值。
+			if rc.DB != DefaultRedisDB {
+				t.Errorf("redis db should be default %d, but got %d", DefaultRedisDB, rc.DB)
+			}
+		})
+	}
+}
+
+func TestOverrideConfigRejectsIllegalValues(t *testing.T) {
+	cases := []struct {
+		name      string
+		env       map[string]string
+		overrides []string
+		err       string
+	}{
+		{"unknown field", nil, []string{"Redis.Passwd=x"}, "unknown configuration field: Redis.Passwd"},
+		{"illegal set", nil, []string{"Redis.Port=abc"}, "illegal value of Redis.Port"},
+		{"illegal env", map[string]string{"TRACKING_MONITOR_REDIS_PORT": "abc"}, nil, "illegal value of environment variable TRACKING_MONITOR_REDIS_PORT"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for k, v := range c.env {
+				t.Setenv(k, v)
+			}
+
+			if err := overrideConfig(newConfiguration(), c.overrides); err == nil {
+				t.Fatal("error expected")
+			} else if !strings.Contains(err.Error(), c.err) {
+				t.Errorf("error should contain %q, but got %v", c.err, err)
+			}
+		})
+	}
+}
+
+func TestResolveConfigSecrets(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "secrets"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "secrets", "redis"), []byte("s3cret\n\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	absFile := filepath.Join(dir, "dsn")
+	if err := ioutil.WriteFile(absFile, []byte("user:pass@tcp(db)/monitor"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		password string
+		want     string
+		err      string
+	}{
+		{"plain value", "plain", "plain", ""},
+		{"relative file", "@file:secrets/redis", "s3cret", ""},
+		{"absolute file", "@file:" + absFile, "user:pass@tcp(db)/monitor", ""},
+		{"missing file", "@file:secrets/missing", "", "cannot read Redis.Password"},
+		{"empty file name", "@file: ", "", "file name should not be empty"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			configuration := newConfiguration()
+			configuration.Redis.Password = c.password
+
+			err := resolveConfigSecrets(configuration, dir)
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("error should contain %q, but got %v", c.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if configuration.Redis.Password != c.want {
+				t.Errorf("password should be %q, but got %q", c.want, configuration.Redis.Password)
+			}
+		})
+	}
+}
@@ -3,20 +3,144 @@
 // @Created: 2021-10-27
 package main
 
+import (
+	_alert "com.cne/ai-tracking-monitor/alert"
+	_scheduler "com.cne/ai-tracking-monitor/scheduler"
+	_types "com.cne/ai-tracking-monitor/types"
+)
+
 // Configuration 表示全局配置对象。
 type Configuration struct {
 	DB DBConfiguration // 数据库设置。
 
 	Redis RedisConfiguration // Redis配置。
+
+	Schedule ScheduleConfiguration // 轮询计划配置。
+
+	Health HealthConfiguration // 健康评估配置。
+
+	Alert AlertConfiguration // 告警配置。
+
+	Maintenances []MaintenanceConfiguration // 维护窗口。
+
+	Http HttpConfiguration // HTTP服务配置。
+
+	Retention RetentionConfiguration // 监控日志保留配置。
+
+	Log LogConfiguration // 日志配置。
 }
 
 type DBConfiguration struct {
-	DSN string // 连接数据库的字符串。
+	Driver string // 数据库驱动，可以是`mysql`或者`sqlite`，默认是`mysql`。
+	DSN    string // 连接数据库的字符串，使用`sqlite`时是数据库文件的路径。
+}
+
+type LogConfiguration struct {
+	Debug bool   // 是否输出日志，指定命令行参数`-debug`时总是输出日志。
+	File  string // 日志文件名的模式，其中的`$date`被替换为当天的日期，相对于当前目录。
+}
+
+type HttpConfiguration struct {
+	Addr  string // HTTP服务的监听地址，比如`:8080`，如果为空则不启动HTTP服务。
+	Token string // 调用立即监控和重新加载配置等修改状态的接口时需要携带的Bearer令牌，如果为空则这些接口只接受来自本机的请求。
+}
+
+type RetentionConfiguration struct {
+	RawDays    int             // 原始监控日志保留的天数，过期并且已经汇总的监控日志会被删除。如果为0则不删除。
+	HourlyDays int             // 按小时汇总保留的天数，如果为0则永久保留。
+	DailyDays  int             // 按天汇总保留的天数，如果为0则永久保留。
+	BatchSize  int             // 每批删除的条数。
+	Interval   _types.Duration // 执行汇总和清理的周期。
+	Cron       string          // 执行汇总和清理的cron表达式，如果设置则忽略`Interval`。
 }
 
 type RedisConfiguration struct {
+	Driver   string // 缓存和队列的实现，可以是`redis`或者`memory`，如果为空则表示`redis`。`memory`仅用于测试和单机运行。
 	Host     string // Redis 的地址。
 	Port     int    // Redis 的端口。
 	Password string // Redis 的口令。
 	DB       int    // 使用的Redis数据库。
 }
+
+type ScheduleConfiguration struct {
+	Interval     _types.Duration                 // 轮询周期。
+	InitialDelay _types.Duration                 // 启动后首次轮询前的延迟。
+	Jitter       _types.Duration                 // 每次轮询附加的随机延迟上限。
+	Cron         string                          // 轮询的cron表达式，如果设置则忽略`Interval`。
+	Overlap      _scheduler.Policy               // 前一轮次尚未结束时如何处理新的轮次，可以是`SKIP`、`QUEUE`或者`CANCEL`。
+	GracePeriod  _types.Duration                 // 退出时等待正在执行的轮次结束的最长时间，超过之后取消这些轮次。
+	Overrides    []ScheduleOverrideConfiguration // 针对部分爬虫的轮询计划。
+}
+
+type ScheduleOverrideConfiguration struct {
+	Name         string          // 轮询计划的名字，仅用于日志。
+	CrawlerIds   []int64         // 适用的爬虫ID。
+	CarrierCodes []string        // 适用的运输商编号。
+	Interval     _types.Duration // 轮询周期。
+	Jitter       _types.Duration // 每次轮询附加的随机延迟上限，如果为0则使用全局设置。
+	Cron         string          // 轮询的cron表达式，如果设置则忽略`Interval`。
+}
+
+type HealthConfiguration struct {
+	HealthPolicyConfiguration                                      // 默认的健康评估策略。
+	CarrierTypes              map[string]HealthPolicyConfiguration // 针对运输商类别的健康评估策略，键是运输商类别，比如`EMS`。
+	Crawlers                  map[int64]HealthPolicyConfiguration  // 针对爬虫的健康评估策略，键是爬虫ID。
+
+	FailuresToDegrade  int // 正常状态下连续失败多少次进入降级状态。
+	FailuresToDown     int // 连续失败多少次进入不可用状态。
+	SuccessesToRecover int // 恢复状态下连续成功多少次进入正常状态。
+}
+
+type HealthPolicyConfiguration struct {
+	Window       _types.Duration // 统计监控结果的时间窗口，如果为0则继承上一级设置。
+	PassingRatio float32         // 成功率不低于此值则认为爬虫健康，如果为0则继承上一级设置。
+	MinSamples   int             // 最少样本数，样本数不足时不改变爬虫的状态，如果为0则继承上一级设置。
+}
+
+type AlertConfiguration struct {
+	DedupWindow   _types.Duration        // 去重的时间窗口，同一爬虫的同类告警在此时间内只发送一次。
+	Timeout       _types.Duration        // 发送告警通知的超时时间。
+	Retries       int                    // 发送告警通知失败时的重试次数。
+	RetryInterval _types.Duration        // 重试的间隔。
+	Webhooks      []WebhookConfiguration // 通过HTTP Webhook发送告警通知。
+	Chats         []ChatConfiguration    // 通过群机器人发送告警通知。
+	Mails         []MailConfiguration    // 通过SMTP发送告警邮件。
+}
+
+type WebhookConfiguration struct {
+	Name         string            // 通知渠道的名字。
+	Url          string            // Webhook的地址。
+	Headers      map[string]string // 附加的请求头。
+	Template     string            // 请求体的模板，如果为空则使用默认模板。
+	TemplateFile string            // 请求体的模板文件，相对于配置文件所在的目录。如果设置则忽略`Template`。
+}
+
+type ChatConfiguration struct {
+	Name   string            // 通知渠道的名字。
+	Format _alert.ChatFormat // 消息的格式，可以是`DINGTALK`、`WECOM`、`FEISHU`或者`SLACK`。
+	Url    string            // 群机器人的Webhook地址。
+	Secret string            // 群机器人的签名密钥，仅钉钉和飞书支持。
+}
+
+type MailConfiguration struct {
+	Name               string              // 通知渠道的名字。
+	Host               string              // SMTP服务器地址。
+	Port               int                 // SMTP服务器端口号。
+	Security           _alert.SmtpSecurity // 连接的加密方式，可以是`NONE`、`STARTTLS`或者`TLS`。
+	Username           string              // 登录用户名，如果为空则不登录。
+	Password           string              // 登录口令。
+	From               string              // 发件人。
+	To                 []string            // 收件人。
+	InsecureSkipVerify bool                // 是否跳过服务器证书校验。
+	Digest             _types.Duration     // 汇总的周期，如果不为0则将每个周期内的所有告警合并为一封邮件。
+}
+
+type MaintenanceConfiguration struct {
+	CarrierCodes []string        // 适用的运输商编号。
+	Start        string          // 开始时间，格式是`yyyy-MM-dd HH:mm:ss`。
+	End          string          // 结束时间，格式是`yyyy-MM-dd HH:mm:ss`。
+	Cron         string          // 重复维护的cron表达式，如果设置则忽略`Start`和`End`。
+	Duration     _types.Duration // 重复维护的时长。
+	Reason       string          // 维护的原因。
+	FreezeHealth bool            // 维护期间是否冻结爬虫的健康状态。
+}
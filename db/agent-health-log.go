@@ -18,7 +18,15 @@ type CrawlerHealthLogRec struct {
 }
 
 func SaveHealthLog(carrierId int64, trackingNo string, timing int, resultStatus int, datePoint time.Time, crawlerRespBody, resultNote string) int64 {
-	if result, err := db.Exec(insertCrawlerHealthLog, carrierId, trackingNo, timing, resultStatus, datePoint, datePoint, 1 /*status*/, crawlerRespBody, resultNote); err != nil {
+	return Default().SaveHealthLog(carrierId, trackingNo, timing, resultStatus, datePoint, crawlerRespBody, resultNote)
+}
+
+func CountHealthLogByResultStatus(datePoint time.Time) []*CrawlerHealthLogRec {
+	return Default().CountHealthLogByResultStatus(datePoint)
+}
+
+func (s *sqlStore) SaveHealthLog(carrierId int64, trackingNo string, timing int, resultStatus int, datePoint time.Time, crawlerRespBody, resultNote string) int64 {
+	if result, err := s.db.Exec(insertCrawlerHealthLog, carrierId, trackingNo, timing, resultStatus, datePoint, datePoint, 1 /*status*/, crawlerRespBody, resultNote); err != nil {
 		panic(err)
 	} else {
 		if lastRowId, err := result.LastInsertId(); err != nil {
@@ -29,8 +37,8 @@ func SaveHealthLog(carrierId int64, trackingNo string, timing int, resultStatus
 	}
 }
 
-func CountHealthLogByResultStatus(datePoint time.Time) []*CrawlerHealthLogRec {
-	if result, err := db.Query(countHealthLogByResultStatus, datePoint); err != nil {
+func (s *sqlStore) CountHealthLogByResultStatus(datePoint time.Time) []*CrawlerHealthLogRec {
+	if result, err := s.db.Query(countHealthLogByResultStatus, datePoint); err != nil {
 		panic(err)
 	} else {
 		mr := make(map[int64]*CrawlerHealthLogRec)
@@ -61,3 +69,71 @@ func CountHealthLogByResultStatus(datePoint time.Time) []*CrawlerHealthLogRec {
 		return r
 	}
 }
+
+const (
+	selectLatestHealthLogs = `select l.crawler_id, l.tracking_no, l.timing, l.result_status, l.create_time, l.result_note from crawler_health_log l
+join (select crawler_id, max(id) id from crawler_health_log group by crawler_id) m on m.id = l.id`
+
+	selectHealthLogsByCrawler = `select crawler_id, tracking_no, timing, result_status, create_time, result_note, ifnull(crawler_resp_body, '') from crawler_health_log
+where crawler_id = ? and create_time > ? order by create_time desc limit ?`
+)
+
+type CrawlerHealthLogPo struct {
+	CrawlerId       int64     // 爬虫ID。
+	TrackingNo      string    // 监控单号。
+	Timing          int       // 耗时（毫秒）。
+	ResultStatus    int       // 监控结果，0表示成功，1表示失败。
+	CreateTime      time.Time // 监控时间。
+	ResultNote      string    // 监控结果的说明。
+	CrawlerRespBody string    // 爬虫返回的原始文本。
+}
+
+// 查询每个爬虫最近一次的监控日志，不包含爬虫返回的原始文本。
+func QueryLatestHealthLogs() []*CrawlerHealthLogPo {
+	return Default().QueryLatestHealthLogs()
+}
+
+func (s *sqlStore) QueryLatestHealthLogs() []*CrawlerHealthLogPo {
+	if rows, err := s.db.Query(selectLatestHealthLogs); err != nil {
+		panic(err)
+	} else {
+		defer rows.Close()
+
+		r := make([]*CrawlerHealthLogPo, 0)
+		for rows.Next() {
+			po := CrawlerHealthLogPo{}
+			if err := rows.Scan(&po.CrawlerId, &po.TrackingNo, &po.Timing, &po.ResultStatus, &po.CreateTime, &po.ResultNote); err != nil {
+				panic(err)
+			} else {
+				r = append(r, &po)
+			}
+		}
+
+		return r
+	}
+}
+
+// 查询指定爬虫的监控日志，按照监控时间倒序排列。
+func QueryHealthLogsByCrawler(crawlerId int64, datePoint time.Time, limit int) []*CrawlerHealthLogPo {
+	return Default().QueryHealthLogsByCrawler(crawlerId, datePoint, limit)
+}
+
+func (s *sqlStore) QueryHealthLogsByCrawler(crawlerId int64, datePoint time.Time, limit int) []*CrawlerHealthLogPo {
+	if rows, err := s.db.Query(selectHealthLogsByCrawler, crawlerId, datePoint, limit); err != nil {
+		panic(err)
+	} else {
+		defer rows.Close()
+
+		r := make([]*CrawlerHealthLogPo, 0)
+		for rows.Next() {
+			po := CrawlerHealthLogPo{}
+			if err := rows.Scan(&po.CrawlerId, &po.TrackingNo, &po.Timing, &po.ResultStatus, &po.CreateTime, &po.ResultNote, &po.CrawlerRespBody); err != nil {
+				panic(err)
+			} else {
+				r = append(r, &po)
+			}
+		}
+
+		return r
+	}
+}
@@ -3,34 +3,47 @@
 // @Created: 2021-10-27
 package db
 
-import (
-	"database/sql"
-	"time"
-
-	_ "github.com/go-sql-driver/mysql"
-)
-
-var (
-	db *sql.DB
-)
-
 // 初始化数据配置。
+// driver 数据库驱动的名字，可以是`mysql`或者`sqlite`，如果为空则表示`mysql`。
 // dsn_ 数据库连接字符串。
 // 尝试根据指定的连接字符串创建数据库连接并且Ping，如果成功则返回nil，否则返回连接时发生的错误。
-func InitDB(dsn_ string) error {
-	if db_, err := sql.Open("mysql", dsn_); err != nil {
+func InitDB(driver, dsn_ string) error {
+	return initDB(driver, dsn_, true)
+}
+
+// 初始化数据配置，但是不自动执行迁移，用于手动管理迁移。
+// driver 数据库驱动的名字，可以是`mysql`或者`sqlite`，如果为空则表示`mysql`。
+// dsn_ 数据库连接字符串。
+func InitDBWithoutMigration(driver, dsn_ string) error {
+	return initDB(driver, dsn_, false)
+}
+
+func initDB(driver, dsn_ string, autoMigrate bool) error {
+	if s, err := open(driver, dsn_, autoMigrate); err != nil {
 		return err
 	} else {
-		// TODO: 以下参数应当改为通过配置文件配置。
-		db_.SetMaxOpenConns(100)
-		db_.SetMaxIdleConns(90)
-		db_.SetConnMaxLifetime(20 * time.Minute)
+		SetDefault(s)
+		return nil
+	}
+}
+
+// 替换当前使用的存储，之后所有的数据库操作都通过新的存储执行。
+// s 新的存储。
+// 返回被替换的存储，调用者负责关闭，如果之前没有初始化则返回nil。
+func SetDefault(s Store) Store {
+	storeLock.Lock()
+	defer storeLock.Unlock()
 
-		if err := db_.Ping(); err != nil {
-			return err
-		} else {
-			db = db_
-			return nil
-		}
+	old := store
+	store = s
+	return old
+}
+
+// 关闭当前使用的存储。
+func Close() error {
+	if s := Default(); s == nil {
+		return nil
+	} else {
+		return s.Close()
 	}
 }
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+// 包装另一个存储，记录被调用的方法。
+type recordingStore struct {
+	Store
+	calls []string
+}
+
+func (s *recordingStore) QueryLatestHealthLogs() []*CrawlerHealthLogPo {
+	s.calls = append(s.calls, "QueryLatestHealthLogs")
+	return s.Store.QueryLatestHealthLogs()
+}
+
+func (s *recordingStore) QueryHealthStatsByCrawler(crawlerId int64, period string, startTime, endTime time.Time, limit int) []*CrawlerHealthStatPo {
+	s.calls = append(s.calls, "QueryHealthStatsByCrawler")
+	return s.Store.QueryHealthStatsByCrawler(crawlerId, period, startTime, endTime, limit)
+}
+
+func (s *recordingStore) QueryMigrationStatus() ([]*MigrationStatusRec, error) {
+	s.calls = append(s.calls, "QueryMigrationStatus")
+	return s.Store.QueryMigrationStatus()
+}
+
+func TestSetDefaultAcceptsOtherStores(t *testing.T) {
+	if err := InitDB(DriverSQLite, ":memory:"); err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+
+	s := &recordingStore{Store: Default()}
+	if replaced := SetDefault(s); replaced != s.Store {
+		t.Fatalf("replaced store should be %v, but got %v", s.Store, replaced)
+	}
+
+	now := time.Now()
+	SaveHealthLog(1, "JD0001", 100, 0, now, "", "")
+	if logs := QueryLatestHealthLogs(); len(logs) != 1 {
+		t.Errorf("expected 1 latest health log, but got %d", len(logs))
+	}
+	QueryHealthStatsByCrawler(1, StatPeriodHour, now.Add(-time.Hour), now, 10)
+	if _, _, err := QuerySchemaVersion(); err != nil {
+		t.Error(err)
+	}
+
+	want := []string{"QueryLatestHealthLogs", "QueryHealthStatsByCrawler", "QueryMigrationStatus"}
+	if len(s.calls) != len(want) {
+		t.Fatalf("calls should be %v, but got %v", want, s.calls)
+	}
+	for i := range want {
+		if s.calls[i] != want[i] {
+			t.Errorf("calls should be %v, but got %v", want, s.calls)
+			break
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"time"
+)
+
+const (
+	insertCrawlerAlertLog string = `insert into crawler_alert_log (crawler_id, kind, notifier, result_status, attempts, result_note, create_time) 
+	values(?, ?, ?, ?, ?, ?, ?)`
+)
+
+// 保存告警通知的投递结果。
+// 注意：此处和爬虫监控日志的规则一致，result_status=0表示成功；result_status=1表示失败。
+func SaveAlertLog(crawlerId int64, kind, notifier string, resultStatus int, attempts int, resultNote string, datePoint time.Time) int64 {
+	return Default().SaveAlertLog(crawlerId, kind, notifier, resultStatus, attempts, resultNote, datePoint)
+}
+
+func (s *sqlStore) SaveAlertLog(crawlerId int64, kind, notifier string, resultStatus int, attempts int, resultNote string, datePoint time.Time) int64 {
+	if result, err := s.db.Exec(insertCrawlerAlertLog, crawlerId, kind, notifier, resultStatus, attempts, resultNote, datePoint); err != nil {
+		panic(err)
+	} else {
+		if lastRowId, err := result.LastInsertId(); err != nil {
+			panic(err)
+		} else {
+			return lastRowId
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"time"
+)
+
+const (
+	insertCrawlerAlertSilence string = `insert into crawler_alert_silence (crawler_id, carrier_code, reason, start_time, end_time, freeze_health, create_time) 
+	values(?, ?, ?, ?, ?, ?, ?)`
+
+	selectActiveAlertSilences = `select id, crawler_id, carrier_code, reason, start_time, end_time, freeze_health from crawler_alert_silence where start_time <= ? and end_time > ?`
+
+	expireAlertSilence = `update crawler_alert_silence set end_time = ? where id = ? and end_time > ?`
+)
+
+type CrawlerAlertSilencePo struct {
+	Id           int64     // 静默规则ID。
+	CrawlerId    int64     // 适用的爬虫ID，0表示不限爬虫。
+	CarrierCode  string    // 适用的运输商编号，空字符串表示不限运输商。
+	Reason       string    // 静默的原因。
+	StartTime    time.Time // 开始时间。
+	EndTime      time.Time // 结束时间。
+	FreezeHealth bool      // 静默期间是否冻结爬虫的健康状态。
+}
+
+func SaveAlertSilence(crawlerId int64, carrierCode, reason string, startTime, endTime time.Time, freezeHealth bool) int64 {
+	return Default().SaveAlertSilence(crawlerId, carrierCode, reason, startTime, endTime, freezeHealth)
+}
+
+func (s *sqlStore) SaveAlertSilence(crawlerId int64, carrierCode, reason string, startTime, endTime time.Time, freezeHealth bool) int64 {
+	if result, err := s.db.Exec(insertCrawlerAlertSilence, crawlerId, carrierCode, reason, startTime, endTime, freezeHealth, startTime); err != nil {
+		panic(err)
+	} else {
+		if lastRowId, err := result.LastInsertId(); err != nil {
+			panic(err)
+		} else {
+			return lastRowId
+		}
+	}
+}
+
+// 查询指定时间生效的所有静默规则。
+func QueryActiveAlertSilences(datePoint time.Time) []*CrawlerAlertSilencePo {
+	return Default().QueryActiveAlertSilences(datePoint)
+}
+
+func (s *sqlStore) QueryActiveAlertSilences(datePoint time.Time) []*CrawlerAlertSilencePo {
+	if rows, err := s.db.Query(selectActiveAlertSilences, datePoint, datePoint); err != nil {
+		panic(err)
+	} else {
+		defer rows.Close()
+
+		r := make([]*CrawlerAlertSilencePo, 0)
+		for rows.Next() {
+			po := CrawlerAlertSilencePo{}
+			if err := rows.Scan(&po.Id, &po.CrawlerId, &po.CarrierCode, &po.Reason, &po.StartTime, &po.EndTime, &po.FreezeHealth); err != nil {
+				panic(err)
+			} else {
+				r = append(r, &po)
+			}
+		}
+
+		return r
+	}
+}
+
+// 使静默规则在指定时间结束。
+func ExpireAlertSilence(id int64, datePoint time.Time) int64 {
+	return Default().ExpireAlertSilence(id, datePoint)
+}
+
+func (s *sqlStore) ExpireAlertSilence(id int64, datePoint time.Time) int64 {
+	if result, err := s.db.Exec(expireAlertSilence, datePoint, id, datePoint); err != nil {
+		panic(err)
+	} else {
+		if c, err := result.RowsAffected(); err != nil {
+			panic(err)
+		} else {
+			return c
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	StatPeriodHour string = "HOUR" // 按小时汇总。
+	StatPeriodDay  string = "DAY"  // 按天汇总。
+)
+
+const (
+	selectEarliestHealthLogTime = `select create_time from crawler_health_log order by id limit 1`
+
+	selectHealthLogTimings = `select crawler_id, result_status, timing, create_time from crawler_health_log where create_time >= ? and create_time < ?`
+
+	selectLatestHealthStatTime = `select start_time from crawler_health_stat where period = ? order by start_time desc limit 1`
+
+	selectEarliestHealthStatTime = `select start_time from crawler_health_stat where period = ? order by start_time limit 1`
+
+	selectHealthStatsByCrawler = `select crawler_id, period, start_time, count_of_ok, count_of_error, timing_p50, timing_p95 from crawler_health_stat
+where crawler_id = ? and period = ? and start_time >= ? and start_time < ? order by start_time desc limit ?`
+)
+
+// 表示一条监控日志的耗时，用于汇总。
+type CrawlerHealthTimingRec struct {
+	CrawlerId    int64     // 爬虫ID。
+	ResultStatus int       // 监控结果，0表示成功，1表示失败。
+	Timing       int       // 耗时（毫秒）。
+	CreateTime   time.Time // 监控时间。
+}
+
+// 表示一个爬虫在一个时间段内的监控汇总。
+type CrawlerHealthStatPo struct {
+	CrawlerId    int64     // 爬虫ID。
+	Period       string    // 汇总的周期，`HOUR`或者`DAY`。
+	StartTime    time.Time // 时间段的开始时间。
+	CountOfOk    int       // 成功的次数。
+	CountOfError int       // 失败的次数。
+	TimingP50    int       // 耗时的中位数（毫秒）。
+	TimingP95    int       // 耗时的95分位数（毫秒）。
+}
+
+// 查询最早的监控日志的时间。
+// 返回最早的监控日志的时间，如果不存在监控日志则返回零值。
+func QueryEarliestHealthLogTime() time.Time {
+	return Default().QueryEarliestHealthLogTime()
+}
+
+func (s *sqlStore) QueryEarliestHealthLogTime() time.Time {
+	var r time.Time
+	if err := s.db.QueryRow(selectEarliestHealthLogTime).Scan(&r); err != nil && err != sql.ErrNoRows {
+		panic(err)
+	}
+
+	return r
+}
+
+// 查询指定时间段内所有监控日志的耗时，不包含爬虫返回的原始文本。
+// startTime 开始时间（包含）。
+// endTime 结束时间（不包含）。
+func QueryHealthLogTimings(startTime, endTime time.Time) []*CrawlerHealthTimingRec {
+	return Default().QueryHealthLogTimings(startTime, endTime)
+}
+
+func (s *sqlStore) QueryHealthLogTimings(startTime, endTime time.Time) []*CrawlerHealthTimingRec {
+	if rows, err := s.db.Query(selectHealthLogTimings, startTime, endTime); err != nil {
+		panic(err)
+	} else {
+		defer rows.Close()
+
+		r := make([]*CrawlerHealthTimingRec, 0)
+		for rows.Next() {
+			rec := CrawlerHealthTimingRec{}
+			if err := rows.Scan(&rec.CrawlerId, &rec.ResultStatus, &rec.Timing, &rec.CreateTime); err != nil {
+				panic(err)
+			} else {
+				r = append(r, &rec)
+			}
+		}
+
+		return r
+	}
+}
+
+// 删除指定时间之前的监控日志，最多删除 `limit` 条。
+// 返回实际删除的条数。
+func DeleteHealthLogsBefore(datePoint time.Time, limit int) int64 {
+	return Default().DeleteHealthLogsBefore(datePoint, limit)
+}
+
+func (s *sqlStore) DeleteHealthLogsBefore(datePoint time.Time, limit int) int64 {
+	if result, err := s.db.Exec(s.dialect.deleteHealthLogsBefore, datePoint, limit); err != nil {
+		panic(err)
+	} else {
+		if c, err := result.RowsAffected(); err != nil {
+			panic(err)
+		} else {
+			return c
+		}
+	}
+}
+
+// 查询指定周期的最后一个汇总的开始时间。
+// 返回最后一个汇总的开始时间，如果不存在汇总则返回零值。
+func QueryLatestHealthStatTime(period string) time.Time {
+	return Default().QueryLatestHealthStatTime(period)
+}
+
+func (s *sqlStore) QueryLatestHealthStatTime(period string) time.Time {
+	var r time.Time
+	if err := s.db.QueryRow(selectLatestHealthStatTime, period).Scan(&r); err != nil && err != sql.ErrNoRows {
+		panic(err)
+	}
+
+	return r
+}
+
+// 查询指定周期的最早的汇总的开始时间。
+// 返回最早的汇总的开始时间，如果不存在汇总则返回零值。
+func QueryEarliestHealthStatTime(period string) time.Time {
+	return Default().QueryEarliestHealthStatTime(period)
+}
+
+func (s *sqlStore) QueryEarliestHealthStatTime(period string) time.Time {
+	var r time.Time
+	if err := s.db.QueryRow(selectEarliestHealthStatTime, period).Scan(&r); err != nil && err != sql.ErrNoRows {
+		panic(err)
+	}
+
+	return r
+}
+
+// 保存监控汇总，如果已存在相同爬虫、周期和开始时间的汇总则覆盖。
+func SaveHealthStat(po *CrawlerHealthStatPo, datePoint time.Time) {
+	Default().SaveHealthStat(po, datePoint)
+}
+
+func (s *sqlStore) SaveHealthStat(po *CrawlerHealthStatPo, datePoint time.Time) {
+	if _, err := s.db.Exec(s.dialect.upsertCrawlerHealthStat, po.CrawlerId, po.Period, po.StartTime, po.CountOfOk, po.CountOfError, po.TimingP50, po.TimingP95, datePoint, datePoint); err != nil {
+		panic(err)
+	}
+}
+
+// 删除指定周期、指定时间之前的监控汇总，最多删除 `limit` 条。
+// 返回实际删除的条数。
+func DeleteHealthStatsBefore(period string, datePoint time.Time, limit int) int64 {
+	return Default().DeleteHealthStatsBefore(period, datePoint, limit)
+}
+
+func (s *sqlStore) DeleteHealthStatsBefore(period string, datePoint time.Time, limit int) int64 {
+	if result, err := s.db.Exec(s.dialect.deleteHealthStatsBefore, period, datePoint, limit); err != nil {
+		panic(err)
+	} else {
+		if c, err := result.RowsAffected(); err != nil {
+			panic(err)
+		} else {
+			return c
+		}
+	}
+}
+
+// 查询指定爬虫在指定时间段内的监控汇总，按照开始时间倒序排列。
+// startTime 开始时间（包含）。
+// endTime 结束时间（不包含）。
+func QueryHealthStatsByCrawler(crawlerId int64, period string, startTime, endTime time.Time, limit int) []*CrawlerHealthStatPo {
+	return Default().QueryHealthStatsByCrawler(crawlerId, period, startTime, endTime, limit)
+}
+
+func (s *sqlStore) QueryHealthStatsByCrawler(crawlerId int64, period string, startTime, endTime time.Time, limit int) []*CrawlerHealthStatPo {
+	if rows, err := s.db.Query(selectHealthStatsByCrawler, crawlerId, period, startTime, endTime, limit); err != nil {
+		panic(err)
+	} else {
+		defer rows.Close()
+
+		r := make([]*CrawlerHealthStatPo, 0)
+		for rows.Next() {
+			po := CrawlerHealthStatPo{}
+			if err := rows.Scan(&po.CrawlerId, &po.Period, &po.StartTime, &po.CountOfOk, &po.CountOfError, &po.TimingP50, &po.TimingP95); err != nil {
+				panic(err)
+			} else {
+				r = append(r, &po)
+			}
+		}
+
+		return r
+	}
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"time"
+)
+
+const (
+	insertCrawlerHealthTransition string = `insert into crawler_health_transition (crawler_id, from_state, to_state, reason, create_time) 
+	values(?, ?, ?, ?, ?)`
+
+	selectLatestHealthTransitions = `select t.crawler_id, t.to_state, t.create_time from crawler_health_transition t
+join (select crawler_id, max(id) id from crawler_health_transition group by crawler_id) m on m.id = t.id`
+)
+
+type CrawlerHealthStateRec struct {
+	Id         int64     // 爬虫ID。
+	State      string    // 爬虫的健康状态。
+	UpdateTime time.Time // 最后一次状态迁移的时间。
+}
+
+func SaveHealthTransition(crawlerId int64, fromState, toState, reason string, datePoint time.Time) int64 {
+	return Default().SaveHealthTransition(crawlerId, fromState, toState, reason, datePoint)
+}
+
+func (s *sqlStore) SaveHealthTransition(crawlerId int64, fromState, toState, reason string, datePoint time.Time) int64 {
+	if result, err := s.db.Exec(insertCrawlerHealthTransition, crawlerId, fromState, toState, reason, datePoint); err != nil {
+		panic(err)
+	} else {
+		if lastRowId, err := result.LastInsertId(); err != nil {
+			panic(err)
+		} else {
+			return lastRowId
+		}
+	}
+}
+
+// 查询所有爬虫最后一次状态迁移之后的状态。
+func QueryLatestHealthStates() []*CrawlerHealthStateRec {
+	return Default().QueryLatestHealthStates()
+}
+
+func (s *sqlStore) QueryLatestHealthStates() []*CrawlerHealthStateRec {
+	if rows, err := s.db.Query(selectLatestHealthTransitions); err != nil {
+		panic(err)
+	} else {
+		defer rows.Close()
+
+		r := make([]*CrawlerHealthStateRec, 0)
+		for rows.Next() {
+			rec := CrawlerHealthStateRec{}
+			if err := rows.Scan(&rec.Id, &rec.State, &rec.UpdateTime); err != nil {
+				panic(err)
+			} else {
+				r = append(r, &rec)
+			}
+		}
+
+		return r
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"database/sql"
 	"errors"
 	"time"
+
+	_types "com.cne/ai-tracking-monitor/types"
 )
 
 type CrawlerInfoPo struct {
-	CarrierId   int64  // 对应的运输商ID。
-	CarrierCode string // 对应的运输商编号。
+	CarrierId   int64              // 对应的运输商ID。
+	CarrierCode string             // 对应的运输商编号。
+	CarrierType _types.CarrierType // 对应的运输商类别。
 
 	HeartBeatNo string // 监控单号。
 	Name        string // 查询代理名称。
@@ -32,7 +35,7 @@ type CrawlerInfoPo struct {
 }
 
 const (
-	selectAllCrawlerInfo string = `select ci.id, ci.carrier_code, tci.id, tci.heart_beat_no,
+	selectAllCrawlerInfo string = `select ci.id, ci.carrier_code, ifnull(ci.carrier_type, 0), tci.id, tci.heart_beat_no,
 	tci.name, tci.req_url, tci.type, tcp.req_url, tcp.req_method, tcp.req_headers, tcp.req_data, tcp.req_verify, tcp.req_json, tcp.req_proxy,
 	tcp.req_timeout, tcp.site_encrypt, tcp.tracking_field_name, tcp.tracking_field_type, tcp.site_crawling_name, tcp.site_analyzed_name
 from tracking_crawler_info  tci
@@ -52,8 +55,16 @@ where ci.status = 1
 )
 
 func QueryAllCrawlerInfos(datePoint time.Time) []*CrawlerInfoPo {
+	return Default().QueryAllCrawlerInfos(datePoint)
+}
+
+func UpdateCrawlerInfoHealth(crawlerId int64, status int) int64 {
+	return Default().UpdateCrawlerInfoHealth(crawlerId, status)
+}
+
+func (s *sqlStore) QueryAllCrawlerInfos(datePoint time.Time) []*CrawlerInfoPo {
 	result := make([]*CrawlerInfoPo, 0)
-	if rows, err := db.Query(selectAllCrawlerInfo, datePoint, datePoint); err != nil {
+	if rows, err := s.db.Query(selectAllCrawlerInfo, datePoint, datePoint); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return result
 		} else {
@@ -62,7 +73,7 @@ func QueryAllCrawlerInfos(datePoint time.Time) []*CrawlerInfoPo {
 	} else {
 		for rows.Next() {
 			crawlerInfoPo := CrawlerInfoPo{}
-			if rows.Scan(&crawlerInfoPo.CarrierId, &crawlerInfoPo.CarrierCode, &crawlerInfoPo.Id, &crawlerInfoPo.HeartBeatNo, &crawlerInfoPo.Name, &crawlerInfoPo.Url, &crawlerInfoPo.Type, &crawlerInfoPo.TargetUrl, &crawlerInfoPo.ReqHttpMethod, &crawlerInfoPo.ReqHttpHeaders, &crawlerInfoPo.ReqHttpBody,
+			if rows.Scan(&crawlerInfoPo.CarrierId, &crawlerInfoPo.CarrierCode, &crawlerInfoPo.CarrierType, &crawlerInfoPo.Id, &crawlerInfoPo.HeartBeatNo, &crawlerInfoPo.Name, &crawlerInfoPo.Url, &crawlerInfoPo.Type, &crawlerInfoPo.TargetUrl, &crawlerInfoPo.ReqHttpMethod, &crawlerInfoPo.ReqHttpHeaders, &crawlerInfoPo.ReqHttpBody,
 				&crawlerInfoPo.Verify, &crawlerInfoPo.Json, &crawlerInfoPo.ReqProxy, &crawlerInfoPo.ReqTimeout, &crawlerInfoPo.SiteEncrypt, &crawlerInfoPo.TrackingFieldName, &crawlerInfoPo.TrackingFieldType, &crawlerInfoPo.SiteCrawlingName, &crawlerInfoPo.SiteAnalyzedName); err != nil {
 				panic(err)
 			} else {
@@ -74,8 +85,8 @@ func QueryAllCrawlerInfos(datePoint time.Time) []*CrawlerInfoPo {
 	}
 }
 
-func UpdateCrawlerInfoHealth(crawlerId int64, status int) int64 {
-	if result, err := db.Exec(updateCrawlerInfoHealth, status, crawlerId); err != nil {
+func (s *sqlStore) UpdateCrawlerInfoHealth(crawlerId int64, status int) int64 {
+	if result, err := s.db.Exec(updateCrawlerInfoHealth, status, crawlerId); err != nil {
 		panic(err)
 	} else {
 		if c, err := result.RowsAffected(); err != nil {
@@ -0,0 +1,382 @@
+// 该模块实现了数据库表结构的版本化迁移。
+// 迁移脚本嵌入在 `migrations/{driver}/` 目录下，文件名格式是`{version}_{name}.up.sql`和`{version}_{name}.down.sql`。
+// @Author: Haart
+// @Created: 2026-10-18
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+const (
+	createSchemaMigration = `create table if not exists monitor_schema_migration (
+	version int not null primary key,
+	name varchar(200) not null,
+	applied_time datetime not null
+)`
+
+	selectSchemaMigrations = `select version, applied_time from monitor_schema_migration order by version`
+
+	insertSchemaMigration = `insert into monitor_schema_migration (version, name, applied_time) values(?, ?, ?)`
+
+	deleteSchemaMigration = `delete from monitor_schema_migration where version = ?`
+)
+
+// 表示一个迁移。
+type Migration struct {
+	Version int    // 版本号。
+	Name    string // 名字。
+	Up      string // 升级脚本。
+	Down    string // 降级脚本。
+}
+
+// 表示一个迁移的状态。
+type MigrationStatusRec struct {
+	Version     int       // 版本号。
+	Name        string    // 名字。
+	Applied     bool      // 是否已经执行。
+	AppliedTime time.Time // 执行的时间。
+}
+
+// 加载指定数据库的所有迁移，按照版本号升序排列。
+// driver 数据库驱动的名字。
+func loadMigrations(driver string) ([]*Migration, error) {
+	dir := path.Join("migrations", driver)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+
+		var direction string
+		if strings.HasSuffix(fileName, ".up.sql") {
+			direction = "up"
+		} else if strings.HasSuffix(fileName, ".down.sql") {
+			direction = "down"
+		} else {
+			continue
+		}
+
+		base := strings.TrimSuffix(fileName, "."+direction+".sql")
+		i := strings.Index(base, "_")
+		if i <= 0 {
+			return nil, fmt.Errorf("illegal migration file name: %s", fileName)
+		}
+		version, err := strconv.Atoi(base[:i])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("illegal version of migration file: %s", fileName)
+		}
+
+		content, err := migrationFiles.ReadFile(path.Join(dir, fileName))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: base[i+1:]}
+			byVersion[version] = m
+		} else if m.Name != base[i+1:] {
+			return nil, fmt.Errorf("duplicated version of migration: %d", version)
+		}
+
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	result := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		result = append(result, m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+
+	return result, nil
+}
+
+// 将脚本拆分为单独的语句。
+// 语句以行末的分号结束，以`--`开头的行是注释。
+func splitStatements(script string) []string {
+	result := make([]string, 0)
+	lines := make([]string, 0)
+	for _, line := range strings.Split(script, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+
+		lines = append(lines, line)
+		if strings.HasSuffix(strings.TrimSpace(line), ";") {
+			if stmt := strings.TrimSuffix(strings.TrimSpace(strings.Join(lines, "\n")), ";"); stmt != "" {
+				result = append(result, stmt)
+			}
+			lines = lines[:0]
+		}
+	}
+
+	if stmt := strings.TrimSpace(strings.Join(lines, "\n")); stmt != "" {
+		result = append(result, stmt)
+	}
+
+	return result
+}
+
+// 查询已经执行的迁移。
+// 返回已经执行的迁移的版本号和执行时间。
+func queryAppliedMigrations(db_ *sql.DB) (map[int]time.Time, error) {
+	if _, err := db_.Exec(createSchemaMigration); err != nil {
+		return nil, err
+	}
+
+	rows, err := db_.Query(selectSchemaMigrations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedTime time.Time
+		if err := rows.Scan(&version, &appliedTime); err != nil {
+			return nil, err
+		}
+		result[version] = appliedTime
+	}
+
+	return result, rows.Err()
+}
+
+// 检查已经执行的迁移是否都是已知的迁移。
+// 数据库已经由更新的程序迁移到更新的版本时，当前程序不应该再使用或者迁移这个数据库。
+// migrations 已知的所有迁移，按照版本号升序排列。
+// applied 已经执行的迁移。
+func checkNewerMigrations(migrations []*Migration, applied map[int]time.Time) error {
+	latest := 0
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+
+	newest := 0
+	for version := range applied {
+		if version > latest && version > newest {
+			newest = version
+		}
+	}
+	if newest > 0 {
+		return fmt.Errorf("schema version %d is newer than %d supported by this program", newest, latest)
+	}
+
+	return nil
+}
+
+// 执行一个迁移的脚本，并记录版本号。
+// 注意：MySQL的DDL语句会隐式提交事务，所以脚本执行到一半失败时需要手动修复。
+func execMigration(db_ *sql.DB, m *Migration, up bool) error {
+	script := m.Down
+	if up {
+		script = m.Up
+	}
+
+	tx, err := db_.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range splitStatements(script) {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("cannot execute migration %d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+
+	if up {
+		_, err = tx.Exec(insertSchemaMigration, m.Version, m.Name, time.Now())
+	} else {
+		_, err = tx.Exec(deleteSchemaMigration, m.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// 执行所有尚未执行的迁移，直到指定的版本。
+// target 目标版本，0表示最新版本。
+// 返回本次执行的迁移。
+func migrateUp(db_ *sql.DB, d *dialect, target int) ([]*Migration, error) {
+	migrations, err := loadMigrations(d.driver)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := queryAppliedMigrations(db_)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNewerMigrations(migrations, applied); err != nil {
+		return nil, err
+	}
+
+	result := make([]*Migration, 0)
+	for _, m := range migrations {
+		if target > 0 && m.Version > target {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		if err := execMigration(db_, m, true); err != nil {
+			return result, err
+		}
+		log.Printf("[INFO] Migration %d_%s applied\n", m.Version, m.Name)
+
+		result = append(result, m)
+	}
+
+	return result, nil
+}
+
+// 回滚最近执行的若干个迁移。
+// steps 回滚的迁移个数。
+// 返回本次回滚的迁移。
+func migrateDown(db_ *sql.DB, d *dialect, steps int) ([]*Migration, error) {
+	migrations, err := loadMigrations(d.driver)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := queryAppliedMigrations(db_)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNewerMigrations(migrations, applied); err != nil {
+		return nil, err
+	}
+
+	result := make([]*Migration, 0)
+	for i := len(migrations) - 1; i >= 0 && len(result) < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == "" {
+			return result, fmt.Errorf("migration %d_%s cannot be reverted", m.Version, m.Name)
+		}
+
+		if err := execMigration(db_, m, false); err != nil {
+			return result, err
+		}
+		log.Printf("[INFO] Migration %d_%s reverted\n", m.Version, m.Name)
+
+		result = append(result, m)
+	}
+
+	return result, nil
+}
+
+// 执行所有尚未执行的迁移，直到指定的版本。
+// target 目标版本，0表示最新版本。
+// 返回本次执行的迁移。
+func MigrateUp(target int) ([]*Migration, error) {
+	return Default().MigrateUp(target)
+}
+
+func (s *sqlStore) MigrateUp(target int) ([]*Migration, error) {
+	return migrateUp(s.db, s.dialect, target)
+}
+
+// 回滚最近执行的若干个迁移。
+// steps 回滚的迁移个数。
+// 返回本次回滚的迁移。
+func MigrateDown(steps int) ([]*Migration, error) {
+	return Default().MigrateDown(steps)
+}
+
+func (s *sqlStore) MigrateDown(steps int) ([]*Migration, error) {
+	return migrateDown(s.db, s.dialect, steps)
+}
+
+// 查询所有迁移的状态，按照版本号升序排列。
+func QueryMigrationStatus() ([]*MigrationStatusRec, error) {
+	return Default().QueryMigrationStatus()
+}
+
+func (s *sqlStore) QueryMigrationStatus() ([]*MigrationStatusRec, error) {
+	migrations, err := loadMigrations(s.dialect.driver)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := queryAppliedMigrations(s.db)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNewerMigrations(migrations, applied); err != nil {
+		return nil, err
+	}
+
+	result := make([]*MigrationStatusRec, 0, len(migrations))
+	for _, m := range migrations {
+		appliedTime, ok := applied[m.Version]
+		result = append(result, &MigrationStatusRec{Version: m.Version, Name: m.Name, Applied: ok, AppliedTime: appliedTime})
+	}
+
+	return result, nil
+}
+
+// 检查表结构是否是最新的。
+// 返回当前版本和最新版本，只有全部迁移都已经执行时当前版本才等于最新版本。
+// 数据库已经迁移到比当前程序更新的版本时返回错误。
+func QuerySchemaVersion() (int, int, error) {
+	return QueryStoreSchemaVersion(Default())
+}
+
+// 检查指定存储的表结构是否是最新的，用于在替换当前使用的存储之前检查新的存储。
+// st 待检查的存储。
+// 返回当前版本和最新版本，只有全部迁移都已经执行时当前版本才等于最新版本。
+// 数据库已经迁移到比当前程序更新的版本时返回错误。
+func QueryStoreSchemaVersion(st Store) (int, int, error) {
+	if st == nil {
+		return 0, 0, fmt.Errorf("database is not initialized")
+	}
+
+	status, err := st.QueryMigrationStatus()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	current, latest := 0, 0
+	contiguous := true
+	for _, rec := range status {
+		latest = rec.Version
+		if rec.Applied && contiguous {
+			current = rec.Version
+		} else {
+			contiguous = false
+		}
+	}
+
+	return current, latest, nil
+}
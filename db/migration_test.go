@@ -0,0 +1,138 @@
+package db
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadMigrationsInOrder(t *testing.T) {
+	for _, driver := range []string{DriverMySQL, DriverSQLite} {
+		migrations, err := loadMigrations(driver)
+		if err != nil {
+			t.Fatalf("%s: %v", driver, err)
+		}
+		if len(migrations) == 0 {
+			t.Fatalf("%s: no migration loaded", driver)
+		}
+
+		for i, m := range migrations {
+			if i > 0 && m.Version <= migrations[i-1].Version {
+				t.Errorf("%s: migration %d_%s should be after %d", driver, m.Version, m.Name, migrations[i-1].Version)
+			}
+			if m.Up == "" || m.Down == "" {
+				t.Errorf("%s: migration %d_%s should have both up and down scripts", driver, m.Version, m.Name)
+			}
+		}
+	}
+}
+
+func TestMigrateInOrder(t *testing.T) {
+	s, err := open(DriverSQLite, ":memory:", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	migrations, err := loadMigrations(DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := migrations[len(migrations)-1].Version
+
+	tests := []struct {
+		name     string
+		up       bool
+		arg      int
+		versions []int
+	}{
+		{"up to first", true, migrations[0].Version, []int{migrations[0].Version}},
+		{"up to latest", true, 0, versionsOf(migrations[1:])},
+		{"nothing to apply", true, 0, []int{}},
+		{"down one step", false, 1, []int{latest}},
+		{"down all", false, len(migrations), reversedVersionsOf(migrations[:len(migrations)-1])},
+		{"up again", true, 0, versionsOf(migrations)},
+	}
+
+	for _, tt := range tests {
+		var result []*Migration
+		if tt.up {
+			result, err = migrateUp(s.db, s.dialect, tt.arg)
+		} else {
+			result, err = migrateDown(s.db, s.dialect, tt.arg)
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		if got := versionsOf(result); !equalVersions(got, tt.versions) {
+			t.Errorf("%s: migrated %v, want %v", tt.name, got, tt.versions)
+		}
+	}
+}
+
+func TestRefuseNewerSchema(t *testing.T) {
+	if err := InitDBWithoutMigration(DriverSQLite, ":memory:"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MigrateUp(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Default().(*sqlStore).db.Exec(insertSchemaMigration, 9999, "from_newer_program", "2026-10-18 00:00:00"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		f    func() error
+	}{
+		{"up", func() error { _, err := MigrateUp(0); return err }},
+		{"down", func() error { _, err := MigrateDown(1); return err }},
+		{"status", func() error { _, err := QueryMigrationStatus(); return err }},
+		{"version", func() error { _, _, err := QuerySchemaVersion(); return err }},
+	}
+
+	for _, tt := range tests {
+		if err := tt.f(); err == nil {
+			t.Errorf("%s: newer schema should be refused", tt.name)
+		} else if !strings.Contains(err.Error(), "schema version 9999 is newer") {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+	}
+
+	// 不能回滚更新的程序执行的迁移。
+	if migrations, err := loadMigrations(DriverSQLite); err != nil {
+		t.Fatal(err)
+	} else if applied, err := queryAppliedMigrations(Default().(*sqlStore).db); err != nil {
+		t.Fatal(err)
+	} else if len(applied) != len(migrations)+1 {
+		t.Errorf("applied migrations should be kept, but got %d", len(applied))
+	}
+}
+
+func versionsOf(migrations []*Migration) []int {
+	result := make([]int, 0, len(migrations))
+	for _, m := range migrations {
+		result = append(result, m.Version)
+	}
+	return result
+}
+
+func reversedVersionsOf(migrations []*Migration) []int {
+	result := make([]int, 0, len(migrations))
+	for i := len(migrations) - 1; i >= 0; i-- {
+		result = append(result, migrations[i].Version)
+	}
+	return result
+}
+
+func equalVersions(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,6 @@
+-- 保留 crawler_health_log，它可能在引入迁移之前就已经存在。
+
+drop table if exists crawler_health_stat;
+drop table if exists crawler_alert_silence;
+drop table if exists crawler_alert_log;
+drop table if exists crawler_health_transition;
@@ -0,0 +1,69 @@
+-- 监控自身使用的表。
+-- crawler_health_log 可能在引入迁移之前就已经存在，所以使用 if not exists。
+
+create table if not exists crawler_health_log (
+	id bigint not null auto_increment,
+	crawler_id bigint not null,
+	tracking_no varchar(100) not null default '',
+	timing int not null default 0,
+	result_status int not null default 0 comment '0-成功，1-失败',
+	create_time datetime not null,
+	update_time datetime not null,
+	status int not null default 1,
+	crawler_resp_body mediumtext,
+	result_note varchar(500) not null default '',
+	primary key (id)
+) engine=InnoDB default charset=utf8mb4 comment='爬虫监控日志';
+
+create table if not exists crawler_health_transition (
+	id bigint not null auto_increment,
+	crawler_id bigint not null,
+	from_state varchar(20) not null,
+	to_state varchar(20) not null,
+	reason varchar(500) not null default '',
+	create_time datetime not null,
+	primary key (id),
+	key idx_crawler_health_transition_crawler_id (crawler_id)
+) engine=InnoDB default charset=utf8mb4 comment='爬虫健康状态迁移';
+
+create table if not exists crawler_alert_log (
+	id bigint not null auto_increment,
+	crawler_id bigint not null,
+	kind varchar(20) not null,
+	notifier varchar(100) not null,
+	result_status int not null comment '0-成功，1-失败',
+	attempts int not null,
+	result_note varchar(500) not null default '',
+	create_time datetime not null,
+	primary key (id),
+	key idx_crawler_alert_log_crawler_id (crawler_id)
+) engine=InnoDB default charset=utf8mb4 comment='告警通知的投递结果';
+
+create table if not exists crawler_alert_silence (
+	id bigint not null auto_increment,
+	crawler_id bigint not null default 0 comment '0-不限爬虫',
+	carrier_code varchar(50) not null default '' comment '空字符串-不限运输商',
+	reason varchar(500) not null default '',
+	start_time datetime not null,
+	end_time datetime not null,
+	freeze_health tinyint(1) not null default 0,
+	create_time datetime not null,
+	primary key (id),
+	key idx_crawler_alert_silence_end_time (end_time)
+) engine=InnoDB default charset=utf8mb4 comment='告警静默规则';
+
+create table if not exists crawler_health_stat (
+	id bigint not null auto_increment,
+	crawler_id bigint not null,
+	period varchar(10) not null comment 'HOUR-按小时汇总，DAY-按天汇总',
+	start_time datetime not null,
+	count_of_ok int not null default 0,
+	count_of_error int not null default 0,
+	timing_p50 int not null default 0,
+	timing_p95 int not null default 0,
+	create_time datetime not null,
+	update_time datetime not null,
+	primary key (id),
+	unique key uk_crawler_health_stat (crawler_id, period, start_time),
+	key idx_crawler_health_stat_period_start_time (period, start_time)
+) engine=InnoDB default charset=utf8mb4 comment='爬虫监控汇总';
@@ -0,0 +1,4 @@
+-- 按爬虫查询监控日志，以及汇总和清理时按时间扫描监控日志。
+
+create index idx_crawler_health_log_crawler_id_create_time on crawler_health_log (crawler_id, create_time);
+create index idx_crawler_health_log_create_time on crawler_health_log (create_time);
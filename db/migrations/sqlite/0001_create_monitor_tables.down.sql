@@ -0,0 +1,8 @@
+drop table if exists crawler_health_stat;
+drop table if exists crawler_alert_silence;
+drop table if exists crawler_alert_log;
+drop table if exists crawler_health_transition;
+drop table if exists crawler_health_log;
+drop table if exists tracking_crawler_param;
+drop table if exists tracking_crawler_info;
+drop table if exists carrier_info;
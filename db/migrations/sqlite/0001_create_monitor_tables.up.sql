@@ -0,0 +1,105 @@
+-- 除了监控自身的表之外，也包含了查询爬虫所需的业务表，以便不依赖MySQL运行。
+
+create table carrier_info (
+	id integer primary key autoincrement,
+	carrier_code varchar(50),
+	carrier_type integer,
+	status integer not null default 1
+);
+
+create table tracking_crawler_info (
+	id integer primary key autoincrement,
+	carrier_id integer not null,
+	heart_beat_no varchar(100),
+	name varchar(100) not null default '',
+	req_url varchar(500) not null default '',
+	type varchar(20) not null default '',
+	priority integer not null default 0,
+	status integer not null default 1,
+	service_status integer not null default 1,
+	result_status integer not null default 1,
+	start_time datetime not null,
+	end_time datetime not null
+);
+
+create table tracking_crawler_param (
+	id integer primary key autoincrement,
+	info_id integer not null,
+	req_url varchar(500) not null default '',
+	req_method varchar(10) not null default '',
+	req_headers text not null default '',
+	req_data text not null default '',
+	req_verify boolean not null default 0,
+	req_json boolean not null default 0,
+	req_proxy varchar(200) not null default '',
+	req_timeout integer not null default 0,
+	site_encrypt integer not null default 0,
+	tracking_field_name varchar(100) not null default '',
+	tracking_field_type integer not null default 0,
+	site_crawling_name varchar(100) not null default '',
+	site_analyzed_name varchar(100) not null default '',
+	status integer not null default 1
+);
+
+create table crawler_health_log (
+	id integer primary key autoincrement,
+	crawler_id integer not null,
+	tracking_no varchar(100) not null default '',
+	timing integer not null default 0,
+	result_status integer not null default 0,
+	create_time datetime not null,
+	update_time datetime not null,
+	status integer not null default 1,
+	crawler_resp_body text not null default '',
+	result_note varchar(500) not null default ''
+);
+
+create table crawler_health_transition (
+	id integer primary key autoincrement,
+	crawler_id integer not null,
+	from_state varchar(20) not null,
+	to_state varchar(20) not null,
+	reason varchar(500) not null default '',
+	create_time datetime not null
+);
+
+create table crawler_alert_log (
+	id integer primary key autoincrement,
+	crawler_id integer not null,
+	kind varchar(20) not null,
+	notifier varchar(100) not null,
+	result_status integer not null,
+	attempts integer not null,
+	result_note varchar(500) not null default '',
+	create_time datetime not null
+);
+
+create table crawler_alert_silence (
+	id integer primary key autoincrement,
+	crawler_id integer not null default 0,
+	carrier_code varchar(50) not null default '',
+	reason varchar(500) not null default '',
+	start_time datetime not null,
+	end_time datetime not null,
+	freeze_health boolean not null default 0,
+	create_time datetime not null
+);
+
+create table crawler_health_stat (
+	id integer primary key autoincrement,
+	crawler_id integer not null,
+	period varchar(10) not null,
+	start_time datetime not null,
+	count_of_ok integer not null default 0,
+	count_of_error integer not null default 0,
+	timing_p50 integer not null default 0,
+	timing_p95 integer not null default 0,
+	create_time datetime not null,
+	update_time datetime not null,
+	unique (crawler_id, period, start_time)
+);
+
+create index idx_crawler_health_transition_crawler_id on crawler_health_transition (crawler_id);
+create index idx_crawler_alert_log_crawler_id on crawler_alert_log (crawler_id);
+create index idx_crawler_alert_silence_end_time on crawler_alert_silence (end_time);
+create index idx_crawler_health_stat_period_start_time on crawler_health_stat (period, start_time);
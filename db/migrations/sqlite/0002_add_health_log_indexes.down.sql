@@ -0,0 +1,2 @@
+drop index idx_crawler_health_log_create_time;
+drop index idx_crawler_health_log_crawler_id_create_time;
@@ -0,0 +1,57 @@
+// 该模块实现了基于MySQL的存储。
+// @Author: Haart
+// @Created: 2026-10-18
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var (
+	mysqlDialect = &dialect{
+		driver:                  DriverMySQL,
+		deleteHealthLogsBefore:  `delete from crawler_health_log where create_time < ? limit ?`,
+		deleteHealthStatsBefore: `delete from crawler_health_stat where period = ? and start_time < ? limit ?`,
+		upsertCrawlerHealthStat: `insert into crawler_health_stat (crawler_id, period, start_time, count_of_ok, count_of_error, timing_p50, timing_p95, create_time, update_time)
+	values(?, ?, ?, ?, ?, ?, ?, ?, ?)
+	on duplicate key update count_of_ok = values(count_of_ok), count_of_error = values(count_of_error), timing_p50 = values(timing_p50), timing_p95 = values(timing_p95), update_time = values(update_time)`,
+	}
+)
+
+// 打开MySQL存储。
+// dsn_ 数据库连接字符串。
+func openMySQL(dsn_ string) (*sqlStore, error) {
+	// 查询结果中包含时间，所以总是需要将时间字段解析为`time.Time`。
+	if cfg, err := mysql.ParseDSN(dsn_); err != nil {
+		return nil, err
+	} else {
+		cfg.ParseTime = true
+		dsn_ = cfg.FormatDSN()
+	}
+
+	if db_, err := sql.Open("mysql", dsn_); err != nil {
+		return nil, err
+	} else {
+		// TODO: 以下参数应当改为通过配置文件配置。
+		db_.SetMaxOpenConns(100)
+		db_.SetMaxIdleConns(90)
+		db_.SetConnMaxLifetime(20 * time.Minute)
+
+		if err := db_.Ping(); err != nil {
+			db_.Close()
+			return nil, err
+		} else {
+			return &sqlStore{db: db_, dialect: mysqlDialect}, nil
+		}
+	}
+}
+
+// 打开MySQL存储。
+// dsn 数据库连接字符串。
+// 返回新打开的存储，调用者负责关闭。
+func NewMySQLStore(dsn string) (Store, error) {
+	return Open(DriverMySQL, dsn)
+}
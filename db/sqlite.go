@@ -0,0 +1,56 @@
+// 该模块实现了基于嵌入式SQLite的存储，用于单机运行和测试。
+// 使用纯Go实现的SQLite驱动，不依赖cgo。
+// @Author: Haart
+// @Created: 2026-10-18
+package db
+
+import (
+	"database/sql"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+var (
+	sqliteDialect = &dialect{
+		driver:                  DriverSQLite,
+		deleteHealthLogsBefore:  `delete from crawler_health_log where id in (select id from crawler_health_log where create_time < ? limit ?)`,
+		deleteHealthStatsBefore: `delete from crawler_health_stat where id in (select id from crawler_health_stat where period = ? and start_time < ? limit ?)`,
+		upsertCrawlerHealthStat: `insert into crawler_health_stat (crawler_id, period, start_time, count_of_ok, count_of_error, timing_p50, timing_p95, create_time, update_time)
+	values(?, ?, ?, ?, ?, ?, ?, ?, ?)
+	on conflict (crawler_id, period, start_time) do update set count_of_ok = excluded.count_of_ok, count_of_error = excluded.count_of_error, timing_p50 = excluded.timing_p50, timing_p95 = excluded.timing_p95, update_time = excluded.update_time`,
+	}
+)
+
+// 打开SQLite存储。
+// dsn_ 数据库文件的路径，也可以是`:memory:`表示内存数据库。
+// autoMigrate 是否自动执行所有尚未执行的迁移。
+func openSQLite(dsn_ string, autoMigrate bool) (*sqlStore, error) {
+	dsn_ = strings.TrimSpace(dsn_)
+	if !strings.Contains(dsn_, "?") {
+		dsn_ = dsn_ + "?_pragma=busy_timeout(5000)"
+	}
+
+	if db_, err := sql.Open("sqlite", dsn_); err != nil {
+		return nil, err
+	} else {
+		// SQLite只允许一个写入者，并且每个内存数据库的连接都是独立的，所以只使用一个连接。
+		db_.SetMaxOpenConns(1)
+
+		if autoMigrate {
+			if _, err := migrateUp(db_, sqliteDialect, 0); err != nil {
+				db_.Close()
+				return nil, err
+			}
+		}
+
+		return &sqlStore{db: db_, dialect: sqliteDialect}, nil
+	}
+}
+
+// 打开SQLite存储，并自动执行所有尚未执行的迁移。
+// dsn 数据库文件的路径，也可以是`:memory:`表示内存数据库。
+// 返回新打开的存储，调用者负责关闭。
+func NewSQLiteStore(dsn string) (Store, error) {
+	return Open(DriverSQLite, dsn)
+}
@@ -0,0 +1,170 @@
+// 该模块定义了存储接口。
+// @Author: Haart
+// @Created: 2026-10-18
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DriverMySQL  string = "mysql"  // MySQL数据库。
+	DriverSQLite string = "sqlite" // 嵌入式SQLite数据库。
+)
+
+// 表示监控使用的存储。
+type Store interface {
+	// 查询指定时间有效的所有活动爬虫。
+	QueryAllCrawlerInfos(datePoint time.Time) []*CrawlerInfoPo
+
+	// 更新爬虫的当前状态。
+	// 注意：此处result_status=1表示正常，result_status=0表示异常。
+	// 返回实际更新的条数。
+	UpdateCrawlerInfoHealth(crawlerId int64, status int) int64
+
+	// 保存一条监控日志。
+	// 返回新的监控日志的ID。
+	SaveHealthLog(crawlerId int64, trackingNo string, timing int, resultStatus int, datePoint time.Time, crawlerRespBody, resultNote string) int64
+
+	// 按照爬虫统计指定时间之后的成功和失败次数。
+	CountHealthLogByResultStatus(datePoint time.Time) []*CrawlerHealthLogRec
+
+	// 查询每个爬虫最近一次的监控日志，不包含爬虫返回的原始文本。
+	QueryLatestHealthLogs() []*CrawlerHealthLogPo
+
+	// 查询指定爬虫的监控日志，按照监控时间倒序排列。
+	QueryHealthLogsByCrawler(crawlerId int64, datePoint time.Time, limit int) []*CrawlerHealthLogPo
+
+	// 查询最早的监控日志的时间。
+	// 返回最早的监控日志的时间，如果不存在监控日志则返回零值。
+	QueryEarliestHealthLogTime() time.Time
+
+	// 查询指定时间段内所有监控日志的耗时，不包含爬虫返回的原始文本。
+	// startTime 开始时间（包含）。
+	// endTime 结束时间（不包含）。
+	QueryHealthLogTimings(startTime, endTime time.Time) []*CrawlerHealthTimingRec
+
+	// 删除指定时间之前的监控日志，最多删除 `limit` 条。
+	// 返回实际删除的条数。
+	DeleteHealthLogsBefore(datePoint time.Time, limit int) int64
+
+	// 查询指定周期的最后一个汇总的开始时间。
+	// 返回最后一个汇总的开始时间，如果不存在汇总则返回零值。
+	QueryLatestHealthStatTime(period string) time.Time
+
+	// 查询指定周期的最早的汇总的开始时间。
+	// 返回最早的汇总的开始时间，如果不存在汇总则返回零值。
+	QueryEarliestHealthStatTime(period string) time.Time
+
+	// 保存监控汇总，如果已存在相同爬虫、周期和开始时间的汇总则覆盖。
+	SaveHealthStat(po *CrawlerHealthStatPo, datePoint time.Time)
+
+	// 删除指定周期、指定时间之前的监控汇总，最多删除 `limit` 条。
+	// 返回实际删除的条数。
+	DeleteHealthStatsBefore(period string, datePoint time.Time, limit int) int64
+
+	// 查询指定爬虫在指定时间段内的监控汇总，按照开始时间倒序排列。
+	// startTime 开始时间（包含）。
+	// endTime 结束时间（不包含）。
+	QueryHealthStatsByCrawler(crawlerId int64, period string, startTime, endTime time.Time, limit int) []*CrawlerHealthStatPo
+
+	// 保存一次状态迁移。
+	// 返回新的状态迁移的ID。
+	SaveHealthTransition(crawlerId int64, fromState, toState, reason string, datePoint time.Time) int64
+
+	// 查询所有爬虫最后一次状态迁移之后的状态。
+	QueryLatestHealthStates() []*CrawlerHealthStateRec
+
+	// 保存告警通知的投递结果。
+	// 注意：此处和爬虫监控日志的规则一致，result_status=0表示成功；result_status=1表示失败。
+	SaveAlertLog(crawlerId int64, kind, notifier string, resultStatus int, attempts int, resultNote string, datePoint time.Time) int64
+
+	// 保存一条静默规则。
+	// 返回新的静默规则的ID。
+	SaveAlertSilence(crawlerId int64, carrierCode, reason string, startTime, endTime time.Time, freezeHealth bool) int64
+
+	// 查询指定时间生效的所有静默规则。
+	QueryActiveAlertSilences(datePoint time.Time) []*CrawlerAlertSilencePo
+
+	// 使静默规则在指定时间结束。
+	ExpireAlertSilence(id int64, datePoint time.Time) int64
+
+	// 执行所有尚未执行的迁移，直到指定的版本。
+	// target 目标版本，0表示最新版本。
+	// 返回本次执行的迁移。
+	MigrateUp(target int) ([]*Migration, error)
+
+	// 回滚最近执行的若干个迁移。
+	// steps 回滚的迁移个数。
+	// 返回本次回滚的迁移。
+	MigrateDown(steps int) ([]*Migration, error)
+
+	// 查询所有迁移的状态，按照版本号升序排列。
+	// 数据库已经迁移到比当前程序更新的版本时返回错误。
+	QueryMigrationStatus() ([]*MigrationStatusRec, error)
+
+	// 关闭存储。
+	Close() error
+}
+
+// 表示不同数据库之间存在差异的SQL语句。
+type dialect struct {
+	driver                  string // 数据库驱动的名字。
+	deleteHealthLogsBefore  string // 分批删除监控日志。
+	deleteHealthStatsBefore string // 分批删除监控汇总。
+	upsertCrawlerHealthStat string // 保存或者覆盖监控汇总。
+}
+
+// 基于SQL数据库的存储。
+type sqlStore struct {
+	db      *sql.DB  // 数据库连接。
+	dialect *dialect // SQL方言。
+}
+
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
+
+var (
+	store     Store        // 当前使用的存储。
+	storeLock sync.RWMutex // 保护当前使用的存储，重新加载配置时会被替换。
+)
+
+// 根据驱动的名字打开存储。
+// driver 驱动的名字，可以是`mysql`或者`sqlite`，如果为空则表示`mysql`。
+// dsn 数据库连接字符串。
+// autoMigrate 是否自动执行尚未执行的迁移，仅对SQLite有效。
+func open(driver, dsn string, autoMigrate bool) (*sqlStore, error) {
+	driver = strings.ToLower(strings.TrimSpace(driver))
+	if driver == "" || driver == DriverMySQL {
+		return openMySQL(dsn)
+	} else if driver == DriverSQLite {
+		return openSQLite(dsn, autoMigrate)
+	} else {
+		return nil, fmt.Errorf("unknown database driver: %s", driver)
+	}
+}
+
+// 根据驱动的名字打开存储。
+// driver 驱动的名字，可以是`mysql`或者`sqlite`，如果为空则表示`mysql`。
+// dsn 数据库连接字符串。
+// 返回新打开的存储，调用者负责关闭。
+func Open(driver, dsn string) (Store, error) {
+	if s, err := open(driver, dsn, true); err != nil {
+		return nil, err
+	} else {
+		return s, nil
+	}
+}
+
+// 获取当前使用的存储。
+func Default() Store {
+	storeLock.RLock()
+	defer storeLock.RUnlock()
+
+	return store
+}
@@ -0,0 +1,120 @@
+// 该模块实现了根据配置创建健康评估策略。
+// @Author: Haart
+// @Created: 2026-10-18
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	_db "com.cne/ai-tracking-monitor/db"
+	_health "com.cne/ai-tracking-monitor/health"
+	_types "com.cne/ai-tracking-monitor/types"
+)
+
+var (
+	healthPolicies *_health.Resolver // 健康评估策略的解析器。
+	healthMachine  *_health.Machine  // 健康状态机。
+	healthCounter  *_health.Counter  // 监控结果的滑动窗口计数器。
+)
+
+// 合并健康评估策略配置，未设置的项目继承上一级配置。
+// parent 上一级配置。
+// hpc 当前配置。
+// 返回合并后的配置。
+func mergeHealthPolicyConfiguration(parent, hpc HealthPolicyConfiguration) HealthPolicyConfiguration {
+	if hpc.Window == 0 {
+		hpc.Window = parent.Window
+	}
+	if hpc.PassingRatio == 0 {
+		hpc.PassingRatio = parent.PassingRatio
+	}
+	if hpc.MinSamples == 0 {
+		hpc.MinSamples = parent.MinSamples
+	}
+
+	return hpc
+}
+
+// 根据配置创建健康评估策略的解析器。
+// hc 健康评估配置。
+// 返回新创建的解析器。
+func newHealthPolicyResolver(hc *HealthConfiguration) (*_health.Resolver, error) {
+	defaultPolicy, err := _health.NewRatioPolicy(hc.Window.Duration(), hc.PassingRatio, hc.MinSamples)
+	if err != nil {
+		return nil, fmt.Errorf("%s: illegal health policy: %w", errorConfigPath("Health", err), err)
+	}
+
+	r := _health.NewResolver(defaultPolicy)
+
+	for k, hpc := range hc.CarrierTypes {
+		path := fmt.Sprintf("Health.CarrierTypes[%s]", k)
+		if carrierType, err := _types.ParseCarrierType(k); err != nil {
+			return nil, fmt.Errorf("%s: illegal health policy of carrier type: %w", path, err)
+		} else if p, err := newMergedRatioPolicy(hc.HealthPolicyConfiguration, hpc); err != nil {
+			return nil, fmt.Errorf("%s: illegal health policy of carrier type: %w", errorConfigPath(path, err), err)
+		} else {
+			r.SetCarrierTypePolicy(carrierType, p)
+		}
+	}
+
+	for crawlerId, hpc := range hc.Crawlers {
+		path := fmt.Sprintf("Health.Crawlers[%d]", crawlerId)
+		if p, err := newMergedRatioPolicy(hc.HealthPolicyConfiguration, hpc); err != nil {
+			return nil, fmt.Errorf("%s: illegal health policy of crawler: %w", errorConfigPath(path, err), err)
+		} else {
+			r.SetCrawlerPolicy(crawlerId, p)
+		}
+	}
+
+	return r, nil
+}
+
+func newMergedRatioPolicy(parent, hpc HealthPolicyConfiguration) (*_health.RatioPolicy, error) {
+	hpc = mergeHealthPolicyConfiguration(parent, hpc)
+	return _health.NewRatioPolicy(hpc.Window.Duration(), hpc.PassingRatio, hpc.MinSamples)
+}
+
+// 根据配置创建健康状态机。
+// hc 健康评估配置。
+// 返回新创建的状态机。
+func newHealthMachine(hc *HealthConfiguration) (*_health.Machine, error) {
+	if m, err := _health.NewMachine(_health.Thresholds{
+		FailuresToDegrade:  hc.FailuresToDegrade,
+		FailuresToDown:     hc.FailuresToDown,
+		SuccessesToRecover: hc.SuccessesToRecover,
+	}); err != nil {
+		return nil, fmt.Errorf("%s: illegal health thresholds: %w", errorConfigPath("Health", err), err)
+	} else {
+		return m, nil
+	}
+}
+
+// 从数据库恢复所有爬虫的健康状态。
+// m 健康状态机。
+func restoreHealthStates(m *_health.Machine) {
+	for _, rec := range _db.QueryLatestHealthStates() {
+		if state, err := _health.ParseState(rec.State); err != nil {
+			log.Printf("[WARN] Cannot restore health state of crawler %d: %s\n", rec.Id, err)
+		} else {
+			m.Restore(rec.Id, state)
+		}
+	}
+}
+
+// 从数据库加载最近一个时间窗口内的监控日志，替换计数器中原有的监控结果。
+// 只在启动时和切换数据库之后加载，之后的监控结果在保存监控日志时直接计入计数器。
+// 其它进程（比如`probe -record`）保存的监控日志在下次加载之前不会被计入。
+// c 滑动窗口计数器。
+// now 当前时间。
+func seedHealthCounter(c *_health.Counter, now time.Time) {
+	recs := _db.QueryHealthLogTimings(now.Add(-c.Window()), now)
+
+	c.Reset()
+	for _, rec := range recs {
+		c.Add(rec.CrawlerId, rec.CreateTime, rec.ResultStatus == 0, rec.Timing)
+	}
+
+	log.Printf("[INFO] %d health log(s) loaded into counter\n", len(recs))
+}
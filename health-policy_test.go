@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	_db "com.cne/ai-tracking-monitor/db"
+	_health "com.cne/ai-tracking-monitor/health"
+	_rpcclient "com.cne/ai-tracking-monitor/rpcclient"
+)
+
+func TestSeedHealthCounter(t *testing.T) {
+	initTestDB(t)
+
+	now := time.Now()
+	_db.SaveHealthLog(1, "JD0001", 100, 0, now.Add(-2*time.Hour), "", "")
+	_db.SaveHealthLog(1, "JD0001", 100, 0, now.Add(-10*time.Minute), "", "")
+	_db.SaveHealthLog(1, "JD0001", 200, 1, now.Add(-5*time.Minute), "", "")
+	_db.SaveHealthLog(2, "JD0002", 200, 0, now.Add(-time.Minute), "", "")
+
+	// 加载之前已有的监控结果被替换，只加载时间窗口内的监控日志。
+	c := _health.NewCounter(time.Hour)
+	c.Add(3, now, true, 100)
+	seedHealthCounter(c, now)
+
+	cases := []struct {
+		crawlerId int64
+		ok        int
+		error     int
+	}{
+		{1, 1, 1},
+		{2, 1, 0},
+		{3, 0, 0},
+	}
+	for _, cc := range cases {
+		if st := c.Stats(cc.crawlerId, time.Hour, now); st.CountOfOk != cc.ok || st.CountOfError != cc.error {
+			t.Errorf("crawler %d: expected %d ok and %d error but got %+v", cc.crawlerId, cc.ok, cc.error, st)
+		}
+	}
+}
+
+func TestSaveProbeResultFeedsHealthCounter(t *testing.T) {
+	initTestDB(t)
+
+	old := healthCounter
+	healthCounter = _health.NewCounter(time.Hour)
+	t.Cleanup(func() { healthCounter = old })
+
+	now := time.Now()
+	seedHealthCounter(healthCounter, now)
+
+	crawlerInfo := &_db.CrawlerInfoPo{Id: 1, Name: "c1", CarrierCode: "JD", HeartBeatNo: "JD0001"}
+	for i, status := range []int{0, 1, 0} {
+		saveProbeResult(&probeResult{
+			CrawlerInfo:    crawlerInfo,
+			TrackingSearch: &_rpcclient.TrackingSearch{TrackingNo: "JD0001"},
+			ResultStatus:   status,
+			Timing:         100,
+			EndTime:        now.Add(time.Duration(i-3) * time.Minute),
+		})
+	}
+
+	// 不需要重新加载，保存的监控结果已经计入计数器，并且和数据库中的监控日志一致。
+	if st := healthCounter.Stats(1, time.Hour, now); st.CountOfOk != 2 || st.CountOfError != 1 {
+		t.Errorf("expected 2 ok and 1 error but got %+v", st)
+	}
+
+	seeded := _health.NewCounter(time.Hour)
+	seedHealthCounter(seeded, now)
+	if st := seeded.Stats(1, time.Hour, now); st.CountOfOk != 2 || st.CountOfError != 1 {
+		t.Errorf("expected 2 ok and 1 error saved into health log but got %+v", st)
+	}
+}
@@ -0,0 +1,152 @@
+// 该模块实现了按爬虫统计监控结果的滑动窗口计数器。
+// @Author: Haart
+// @Created: 2026-10-18
+package health
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	_utils "com.cne/ai-tracking-monitor/utils"
+)
+
+// 表示一次监控结果。
+type sample struct {
+	time   time.Time // 监控时间。
+	ok     bool      // 是否成功。
+	timing int       // 耗时（毫秒）。
+}
+
+// 表示一个爬虫在时间窗口内的统计结果。
+type Stats struct {
+	CountOfOk    int // 成功的次数。
+	CountOfError int // 失败的次数。
+	TimingP50    int // 耗时的中位数（毫秒）。
+	TimingP95    int // 耗时的95分位数（毫秒）。
+}
+
+// 表示滑动窗口计数器。
+// 每个爬虫保存最近一个时间窗口内的监控结果，按照监控时间升序排列，过期的监控结果会被丢弃。
+type Counter struct {
+	window  time.Duration      // 保存监控结果的最长时间窗口。
+	lock    sync.Mutex         // 同步锁。
+	samples map[int64][]sample // 每个爬虫的监控结果。
+}
+
+// 创建滑动窗口计数器。
+// window 保存监控结果的最长时间窗口，应当不短于所有健康评估策略的时间窗口。
+func NewCounter(window time.Duration) *Counter {
+	return &Counter{
+		window:  window,
+		samples: make(map[int64][]sample),
+	}
+}
+
+// 获取保存监控结果的最长时间窗口。
+func (c *Counter) Window() time.Duration {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.window
+}
+
+// 修改保存监控结果的最长时间窗口，已经保存的监控结果不受影响，过期的监控结果在下次添加或者清理时丢弃。
+// window 新的最长时间窗口。
+func (c *Counter) SetWindow(window time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.window = window
+}
+
+// 丢弃所有监控结果。
+func (c *Counter) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.samples = make(map[int64][]sample)
+}
+
+// 添加一次监控结果。
+// crawlerId 爬虫ID。
+// t 监控时间。
+// ok 是否成功。
+// timing 耗时（毫秒）。
+func (c *Counter) Add(crawlerId int64, t time.Time, ok bool, timing int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ss := c.samples[crawlerId]
+	s := sample{time: t, ok: ok, timing: timing}
+
+	// 通常监控结果按照时间顺序到达，只有乱序时才需要插入到中间。
+	if n := len(ss); n == 0 || !t.Before(ss[n-1].time) {
+		ss = append(ss, s)
+	} else {
+		i := sort.Search(n, func(i int) bool { return ss[i].time.After(t) })
+		ss = append(ss, sample{})
+		copy(ss[i+1:], ss[i:])
+		ss[i] = s
+	}
+
+	c.samples[crawlerId] = expire(ss, t.Add(-c.window))
+}
+
+// 统计指定爬虫在时间窗口内的监控结果。
+// crawlerId 爬虫ID。
+// window 时间窗口，不能超过计数器的最长时间窗口。
+// now 当前时间。
+// 返回统计结果。
+func (c *Counter) Stats(crawlerId int64, window time.Duration, now time.Time) Stats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ss := c.samples[crawlerId]
+	since := now.Add(-window)
+	i := sort.Search(len(ss), func(i int) bool { return ss[i].time.After(since) })
+
+	r := Stats{}
+	timings := make([]int, 0, len(ss)-i)
+	for _, s := range ss[i:] {
+		if s.ok {
+			r.CountOfOk++
+		} else {
+			r.CountOfError++
+		}
+		timings = append(timings, s.timing)
+	}
+
+	sort.Ints(timings)
+	r.TimingP50 = _utils.Percentile(timings, .5)
+	r.TimingP95 = _utils.Percentile(timings, .95)
+
+	return r
+}
+
+// 丢弃所有过期的监控结果，并移除已经没有监控结果的爬虫。
+// now 当前时间。
+func (c *Counter) Prune(now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	since := now.Add(-c.window)
+	for crawlerId, ss := range c.samples {
+		if ss = expire(ss, since); len(ss) == 0 {
+			delete(c.samples, crawlerId)
+		} else {
+			c.samples[crawlerId] = ss
+		}
+	}
+}
+
+// 丢弃不晚于指定时间的监控结果。
+func expire(ss []sample, since time.Time) []sample {
+	i := sort.Search(len(ss), func(i int) bool { return ss[i].time.After(since) })
+	if i == 0 {
+		return ss
+	}
+
+	// 复制剩余的监控结果，避免底层数组无限增长。
+	return append(make([]sample, 0, len(ss)-i+1), ss[i:]...)
+}
@@ -0,0 +1,150 @@
+// 该模块定义了爬虫健康评估策略。
+// @Author: Haart
+// @Created: 2026-10-18
+package health
+
+import (
+	"time"
+
+	_types "com.cne/ai-tracking-monitor/types"
+)
+
+// 表示健康评估的结论。
+type Verdict int
+
+const (
+	VerdictPassed       Verdict = 0 // 通过。
+	VerdictFailed       Verdict = 1 // 未通过。
+	VerdictInsufficient Verdict = 2 // 样本不足，无法得出结论。
+)
+
+func (v *Verdict) String() string {
+	if *v == VerdictPassed {
+		return "PASSED"
+	} else if *v == VerdictFailed {
+		return "FAILED"
+	} else if *v == VerdictInsufficient {
+		return "INSUFFICIENT"
+	} else {
+		return ""
+	}
+}
+
+// 表示健康评估策略。
+type Policy interface {
+	// 获取统计监控结果的时间窗口。
+	Window() time.Duration
+
+	// 根据时间窗口内的监控结果评估爬虫是否健康。
+	// countOfOk 成功的次数。
+	// countOfError 失败的次数。
+	// 返回评估结论。
+	Evaluate(countOfOk, countOfError int) Verdict
+}
+
+// 按照成功率评估爬虫是否健康的策略。
+type RatioPolicy struct {
+	window       time.Duration // 统计监控结果的时间窗口。
+	passingRatio float32       // 成功率不低于此值则认为通过。
+	minSamples   int           // 最少样本数，样本数不足时不得出结论。
+}
+
+// 创建按照成功率评估的策略。
+// window 统计监控结果的时间窗口。
+// passingRatio 成功率不低于此值则认为通过。
+// minSamples 最少样本数，样本数不足时不得出结论。
+func NewRatioPolicy(window time.Duration, passingRatio float32, minSamples int) (*RatioPolicy, error) {
+	if window <= 0 {
+		return nil, _types.NewFieldError("Window", "window should be positive, but %s", window)
+	}
+	if passingRatio < 0 || passingRatio > 1 {
+		return nil, _types.NewFieldError("PassingRatio", "passing ratio should be between 0 and 1, but %v", passingRatio)
+	}
+	if minSamples < 0 {
+		return nil, _types.NewFieldError("MinSamples", "min samples should not be negative, but %d", minSamples)
+	}
+
+	return &RatioPolicy{window: window, passingRatio: passingRatio, minSamples: minSamples}, nil
+}
+
+func (rp *RatioPolicy) Window() time.Duration {
+	return rp.window
+}
+
+func (rp *RatioPolicy) PassingRatio() float32 {
+	return rp.passingRatio
+}
+
+func (rp *RatioPolicy) MinSamples() int {
+	return rp.minSamples
+}
+
+func (rp *RatioPolicy) Evaluate(countOfOk, countOfError int) Verdict {
+	countOfTotal := countOfOk + countOfError
+	if countOfTotal == 0 || countOfTotal < rp.minSamples {
+		return VerdictInsufficient
+	} else if float32(countOfOk)/float32(countOfTotal) >= rp.passingRatio {
+		return VerdictPassed
+	} else {
+		return VerdictFailed
+	}
+}
+
+// 表示健康评估策略的解析器。
+// 优先使用针对爬虫ID的策略，其次使用针对运输商类别的策略，最后使用默认策略。
+type Resolver struct {
+	defaultPolicy Policy                        // 默认策略。
+	byCarrierType map[_types.CarrierType]Policy // 针对运输商类别的策略。
+	byCrawlerId   map[int64]Policy              // 针对爬虫ID的策略。
+}
+
+// 创建健康评估策略的解析器。
+// defaultPolicy 默认策略。
+func NewResolver(defaultPolicy Policy) *Resolver {
+	return &Resolver{
+		defaultPolicy: defaultPolicy,
+		byCarrierType: make(map[_types.CarrierType]Policy),
+		byCrawlerId:   make(map[int64]Policy),
+	}
+}
+
+// 设置针对运输商类别的策略。
+func (r *Resolver) SetCarrierTypePolicy(carrierType _types.CarrierType, policy Policy) {
+	r.byCarrierType[carrierType] = policy
+}
+
+// 设置针对爬虫ID的策略。
+func (r *Resolver) SetCrawlerPolicy(crawlerId int64, policy Policy) {
+	r.byCrawlerId[crawlerId] = policy
+}
+
+// 查找适用于指定爬虫的策略。
+// crawlerId 爬虫ID。
+// carrierType 爬虫对应的运输商类别。
+// 返回适用的策略。
+func (r *Resolver) Resolve(crawlerId int64, carrierType _types.CarrierType) Policy {
+	if p, ok := r.byCrawlerId[crawlerId]; ok {
+		return p
+	} else if p, ok := r.byCarrierType[carrierType]; ok {
+		return p
+	} else {
+		return r.defaultPolicy
+	}
+}
+
+// 获取所有策略中最长的时间窗口。
+func (r *Resolver) MaxWindow() time.Duration {
+	window := r.defaultPolicy.Window()
+	for _, p := range r.byCarrierType {
+		if p.Window() > window {
+			window = p.Window()
+		}
+	}
+	for _, p := range r.byCrawlerId {
+		if p.Window() > window {
+			window = p.Window()
+		}
+	}
+
+	return window
+}
@@ -0,0 +1,268 @@
+// 该模块实现了爬虫健康状态机。
+// @Author: Haart
+// @Created: 2026-10-18
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	_types "com.cne/ai-tracking-monitor/types"
+)
+
+// 表示爬虫的健康状态。
+type State int
+
+const (
+	StateUnknown    State = 0 // 未知，尚未监控过。
+	StateOk         State = 1 // 正常。
+	StateDegraded   State = 2 // 出现了少量失败，但是仍然认为可用。
+	StateDown       State = 3 // 不可用。
+	StateRecovering State = 4 // 不可用之后开始恢复，但是尚未确认。
+)
+
+func (s State) String() string {
+	if s == StateOk {
+		return "OK"
+	} else if s == StateDegraded {
+		return "DEGRADED"
+	} else if s == StateDown {
+		return "DOWN"
+	} else if s == StateRecovering {
+		return "RECOVERING"
+	} else {
+		return "UNKNOWN"
+	}
+}
+
+// 将字符串解析为State
+// s 待解析的字符串，会被自动去除首尾空格，然后变为大写。
+// 返回解析结果。
+func ParseState(s string) (State, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+
+	if s == "OK" {
+		return StateOk, nil
+	} else if s == "DEGRADED" {
+		return StateDegraded, nil
+	} else if s == "DOWN" {
+		return StateDown, nil
+	} else if s == "RECOVERING" {
+		return StateRecovering, nil
+	} else if s == "UNKNOWN" {
+		return StateUnknown, nil
+	} else {
+		return 0, fmt.Errorf("unkown health state: %s", s)
+	}
+}
+
+// 判断该状态下爬虫是否被看作可用。
+// 对应 `tracking_crawler_info` 的 `result_status`，可用为1，不可用为0。
+func (s State) IsHealthy() bool {
+	return s == StateOk || s == StateDegraded
+}
+
+func (s State) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *State) UnmarshalJSON(b []byte) error {
+	ss := ""
+	if err := json.Unmarshal(b, &ss); err != nil {
+		return err
+	} else if st, err := ParseState(ss); err != nil {
+		return err
+	} else {
+		*s = st
+		return nil
+	}
+}
+
+// 表示状态机的阈值。
+type Thresholds struct {
+	FailuresToDegrade  int // 正常状态下连续失败多少次进入降级状态。
+	FailuresToDown     int // 连续失败多少次进入不可用状态。
+	SuccessesToRecover int // 恢复状态下连续成功多少次进入正常状态。
+}
+
+// 表示一次状态迁移。
+type Transition struct {
+	CrawlerId int64     // 爬虫ID。
+	From      State     // 迁移前的状态。
+	To        State     // 迁移后的状态。
+	Reason    string    // 迁移的原因。
+	Time      time.Time // 迁移的时间。
+}
+
+// 表示单个爬虫的状态。
+type crawlerState struct {
+	state            State // 当前状态。
+	consecutiveOk    int   // 连续成功的次数。
+	consecutiveError int   // 连续失败的次数。
+	failedError      int   // 健康评估持续未通过期间累计失败的次数，成功不会清零。
+}
+
+// 表示爬虫健康状态机。
+// 状态迁移同时由连续成功/失败的次数和健康评估结论驱动：
+// UNKNOWN -> DEGRADED：首次监控失败；
+// OK -> DEGRADED：连续失败达到 `FailuresToDegrade`，或者最近一次失败并且健康评估未通过；
+// OK/DEGRADED -> DOWN：连续失败达到 `FailuresToDown`，或者健康评估持续未通过期间累计失败达到 `FailuresToDown`（避免成功和失败交替出现时一直停留在DEGRADED），
+// 健康评估未通过也不能跳过此阈值，避免在临界值附近反复切换；
+// DEGRADED -> OK：最近一次成功并且健康评估未失败；
+// DOWN -> RECOVERING：最近一次成功；
+// RECOVERING -> OK：连续成功达到 `SuccessesToRecover` 并且健康评估未失败；
+// RECOVERING -> DOWN：最近一次失败。
+type Machine struct {
+	thresholds Thresholds              // 阈值。
+	crawlers   map[int64]*crawlerState // 所有爬虫的状态。
+	lock       sync.Mutex              // 同步锁。
+}
+
+// 创建状态机。
+// thresholds 阈值。
+func NewMachine(thresholds Thresholds) (*Machine, error) {
+	if thresholds.FailuresToDegrade < 1 {
+		return nil, _types.NewFieldError("FailuresToDegrade", "failures to degrade should be positive")
+	}
+	if thresholds.FailuresToDown < 1 {
+		return nil, _types.NewFieldError("FailuresToDown", "failures to down should be positive")
+	}
+	if thresholds.SuccessesToRecover < 1 {
+		return nil, _types.NewFieldError("SuccessesToRecover", "successes to recover should be positive")
+	}
+	if thresholds.FailuresToDown < thresholds.FailuresToDegrade {
+		return nil, _types.NewFieldError("FailuresToDown", "failures to down should not be less than failures to degrade")
+	}
+
+	return &Machine{
+		thresholds: thresholds,
+		crawlers:   make(map[int64]*crawlerState),
+	}, nil
+}
+
+// 采用另一个状态机的阈值，保留所有爬虫的当前状态，通常在重新加载配置时调用。
+// other 另一个状态机。
+func (m *Machine) Adopt(other *Machine) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.thresholds = other.thresholds
+}
+
+// 恢复爬虫的状态，通常在启动时根据持久化的状态调用。
+// crawlerId 爬虫ID。
+// state 爬虫的状态。
+func (m *Machine) Restore(crawlerId int64, state State) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.crawlers[crawlerId] = &crawlerState{state: state}
+}
+
+// 获取爬虫的当前状态。
+// crawlerId 爬虫ID。
+func (m *Machine) State(crawlerId int64) State {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if cs, ok := m.crawlers[crawlerId]; ok {
+		return cs.state
+	} else {
+		return StateUnknown
+	}
+}
+
+// 根据最近一次监控结果推进爬虫的状态。
+// crawlerId 爬虫ID。
+// success 最近一次监控是否成功。
+// verdict 根据时间窗口内的监控结果得出的健康评估结论。
+// now 当前时间。
+// 如果发生了状态迁移则返回迁移记录，否则返回nil。
+func (m *Machine) Advance(crawlerId int64, success bool, verdict Verdict, now time.Time) *Transition {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	cs, ok := m.crawlers[crawlerId]
+	if !ok {
+		cs = &crawlerState{state: StateUnknown}
+		m.crawlers[crawlerId] = cs
+	}
+
+	if success {
+		cs.consecutiveOk++
+		cs.consecutiveError = 0
+	} else {
+		cs.consecutiveError++
+		cs.consecutiveOk = 0
+	}
+	if verdict != VerdictFailed {
+		cs.failedError = 0
+	} else if !success {
+		cs.failedError++
+	}
+
+	to, reason := m.next(cs, success, verdict)
+	if to == cs.state {
+		return nil
+	}
+
+	t := &Transition{
+		CrawlerId: crawlerId,
+		From:      cs.state,
+		To:        to,
+		Reason:    reason,
+		Time:      now,
+	}
+	cs.state = to
+
+	return t
+}
+
+func (m *Machine) next(cs *crawlerState, success bool, verdict Verdict) (State, string) {
+	th := m.thresholds
+
+	if !success {
+		switch cs.state {
+		case StateUnknown, StateOk, StateDegraded:
+			if cs.consecutiveError >= th.FailuresToDown {
+				return StateDown, fmt.Sprintf("%d consecutive failure(s)", cs.consecutiveError)
+			} else if verdict == VerdictFailed && cs.failedError >= th.FailuresToDown {
+				return StateDown, fmt.Sprintf("%d failure(s) while passing ratio not reached", cs.failedError)
+			} else if cs.state == StateDegraded {
+				break
+			} else if verdict == VerdictFailed {
+				return StateDegraded, fmt.Sprintf("failed and passing ratio not reached after %d consecutive failure(s)", cs.consecutiveError)
+			} else if cs.consecutiveError >= th.FailuresToDegrade {
+				return StateDegraded, fmt.Sprintf("%d consecutive failure(s)", cs.consecutiveError)
+			} else if cs.state == StateUnknown {
+				return StateDegraded, "first check failed"
+			}
+		case StateRecovering:
+			return StateDown, "failed while recovering"
+		}
+	} else {
+		switch cs.state {
+		case StateUnknown:
+			if verdict == VerdictFailed {
+				return StateRecovering, "succeeded but passing ratio not reached"
+			} else {
+				return StateOk, "succeeded"
+			}
+		case StateDegraded:
+			if verdict != VerdictFailed {
+				return StateOk, "succeeded"
+			}
+		case StateDown:
+			return StateRecovering, "succeeded after down"
+		case StateRecovering:
+			if cs.consecutiveOk >= th.SuccessesToRecover && verdict != VerdictFailed {
+				return StateOk, fmt.Sprintf("%d consecutive success(es)", cs.consecutiveOk)
+			}
+		}
+	}
+
+	return cs.state, ""
+}
@@ -0,0 +1,129 @@
+package health
+
+import (
+	"testing"
+	"time"
+)
+
+type step struct {
+	success bool
+	verdict Verdict
+	want    State
+}
+
+func runSteps(t *testing.T, th Thresholds, initial State, steps []step) *Machine {
+	t.Helper()
+
+	m, err := NewMachine(th)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if initial != StateUnknown {
+		m.Restore(1, initial)
+	}
+
+	now := time.Now()
+	for i, s := range steps {
+		m.Advance(1, s.success, s.verdict, now.Add(time.Duration(i)*time.Minute))
+		if got := m.State(1); got != s.want {
+			t.Fatalf("step #%d: expected %s but got %s", i, s.want.String(), got.String())
+		}
+	}
+
+	return m
+}
+
+func TestRatioFailureDoesNotBypassFailuresToDown(t *testing.T) {
+	runSteps(t, Thresholds{FailuresToDegrade: 1, FailuresToDown: 3, SuccessesToRecover: 2}, StateOk, []step{
+		{false, VerdictFailed, StateDegraded},
+		{false, VerdictFailed, StateDegraded},
+		{false, VerdictFailed, StateDown},
+	})
+}
+
+func TestRatioFailureDegradesBeforeFailuresToDegrade(t *testing.T) {
+	runSteps(t, Thresholds{FailuresToDegrade: 2, FailuresToDown: 3, SuccessesToRecover: 2}, StateOk, []step{
+		{false, VerdictFailed, StateDegraded},
+		{true, VerdictPassed, StateOk},
+	})
+}
+
+func TestFlappingNearThresholdStaysAvailable(t *testing.T) {
+	runSteps(t, Thresholds{FailuresToDegrade: 1, FailuresToDown: 3, SuccessesToRecover: 2}, StateOk, []step{
+		{false, VerdictFailed, StateDegraded},
+		{true, VerdictFailed, StateDegraded},
+		{false, VerdictFailed, StateDegraded},
+		{true, VerdictPassed, StateOk},
+	})
+}
+
+func TestFirstFailureIsNotOk(t *testing.T) {
+	runSteps(t, Thresholds{FailuresToDegrade: 2, FailuresToDown: 3, SuccessesToRecover: 2}, StateUnknown, []step{
+		{false, VerdictInsufficient, StateDegraded},
+	})
+}
+
+func TestRecovery(t *testing.T) {
+	runSteps(t, Thresholds{FailuresToDegrade: 1, FailuresToDown: 2, SuccessesToRecover: 2}, StateUnknown, []step{
+		{true, VerdictInsufficient, StateOk},
+		{false, VerdictInsufficient, StateDegraded},
+		{false, VerdictInsufficient, StateDown},
+		{true, VerdictInsufficient, StateRecovering},
+		{false, VerdictInsufficient, StateDown},
+		{true, VerdictInsufficient, StateRecovering},
+		{true, VerdictInsufficient, StateOk},
+	})
+}
+
+func TestAlternatingResults(t *testing.T) {
+	th := Thresholds{FailuresToDegrade: 1, FailuresToDown: 3, SuccessesToRecover: 2}
+
+	cases := []struct {
+		name    string
+		steps   []step
+		healthy bool
+	}{
+		{"failed verdict goes down", []step{
+			{false, VerdictFailed, StateDegraded},
+			{true, VerdictFailed, StateDegraded},
+			{false, VerdictFailed, StateDegraded},
+			{true, VerdictFailed, StateDegraded},
+			{false, VerdictFailed, StateDown},
+			{true, VerdictFailed, StateRecovering},
+			{false, VerdictFailed, StateDown},
+		}, false},
+		{"passed verdict stays available", []step{
+			{false, VerdictPassed, StateDegraded},
+			{true, VerdictPassed, StateOk},
+			{false, VerdictPassed, StateDegraded},
+			{true, VerdictPassed, StateOk},
+			{false, VerdictPassed, StateDegraded},
+			{true, VerdictPassed, StateOk},
+		}, true},
+		{"insufficient verdict stays available", []step{
+			{false, VerdictInsufficient, StateDegraded},
+			{true, VerdictInsufficient, StateOk},
+			{false, VerdictInsufficient, StateDegraded},
+			{true, VerdictInsufficient, StateOk},
+			{false, VerdictInsufficient, StateDegraded},
+		}, true},
+		{"passed verdict resets failures", []step{
+			{false, VerdictFailed, StateDegraded},
+			{true, VerdictFailed, StateDegraded},
+			{false, VerdictFailed, StateDegraded},
+			{true, VerdictPassed, StateOk},
+			{false, VerdictFailed, StateDegraded},
+			{true, VerdictFailed, StateDegraded},
+			{false, VerdictFailed, StateDegraded},
+		}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m := runSteps(t, th, StateOk, c.steps)
+			if state := m.State(1); state.IsHealthy() != c.healthy {
+				t.Errorf("%s should be healthy: %v", state, c.healthy)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -9,14 +10,19 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
-	"runtime"
+	"reflect"
 	"strings"
 	"syscall"
 	"time"
 
+	_alert "com.cne/ai-tracking-monitor/alert"
 	_cache "com.cne/ai-tracking-monitor/cache"
 	_db "com.cne/ai-tracking-monitor/db"
+	_health "com.cne/ai-tracking-monitor/health"
 	_queue "com.cne/ai-tracking-monitor/queue"
+	_rpcclient "com.cne/ai-tracking-monitor/rpcclient"
+	_scheduler "com.cne/ai-tracking-monitor/scheduler"
+	_types "com.cne/ai-tracking-monitor/types"
 	_utils "com.cne/ai-tracking-monitor/utils"
 )
 
@@ -24,13 +30,36 @@ const (
 	AppName    string = "tracking-monitor" // 表示应用程序名。
 	AppVersion string = "0.1.0"            // 表示应用程序版本。
 
-	DefaultConfigFile string = "./" + AppName + ".json" // 表示默认的配置文件名。
-	DefaultDebug      bool   = false                    // 表示默认是否开启Debug模式。
+	DefaultConfigFile string = "./" + AppName + ".json"        // 表示默认的配置文件名。
+	DefaultDebug      bool   = false                           // 表示默认是否开启Debug模式。
+	DefaultLogFile    string = "log/" + AppName + "-$date.log" // 表示默认的日志文件名模式。
 
+	DefaultRedisDriver   string = "redis"     // 表示默认的缓存和队列实现。
 	DefaultRedisHost     string = "localhost" // 表示默认的Redis主机地址。
 	DefaultRedisPort     int    = 6379        // 表示默认的Redis端口号。
 	DefaultRedisPassword string = ""          // 表示默认的Redis口令。
 	DefaultRedisDB       int    = 0           // 表示默认的Redis数据库。
+
+	DefaultScheduleInterval     = _types.Duration(5 * time.Minute)  // 表示默认的轮询周期。
+	DefaultScheduleInitialDelay = _types.Duration(5 * time.Second)  // 表示默认的首次轮询前的延迟。
+	DefaultScheduleGracePeriod  = _types.Duration(30 * time.Second) // 表示默认的退出时等待正在执行的轮次结束的时间。
+
+	DefaultHealthWindow       = _types.Duration(48 * time.Hour) // 表示默认的统计监控结果的时间窗口。
+	DefaultHealthPassingRatio = float32(.89)                    // 表示默认的爬虫健康的最低成功率。
+	DefaultHealthMinSamples   = 5                               // 表示默认的评估爬虫健康的最少样本数。
+
+	DefaultHealthFailuresToDegrade  = 1 // 表示默认的正常状态下连续失败多少次进入降级状态。
+	DefaultHealthFailuresToDown     = 3 // 表示默认的连续失败多少次进入不可用状态。
+	DefaultHealthSuccessesToRecover = 3 // 表示默认的恢复状态下连续成功多少次进入正常状态。
+
+	DefaultAlertTimeout       = _types.Duration(10 * time.Second) // 表示默认的发送告警通知的超时时间。
+	DefaultAlertRetries       = 3                                 // 表示默认的发送告警通知失败时的重试次数。
+	DefaultAlertRetryInterval = _types.Duration(5 * time.Second)  // 表示默认的重试间隔。
+
+	DefaultRetentionRawDays    = 30                         // 表示默认的原始监控日志保留的天数。
+	DefaultRetentionHourlyDays = 90                         // 表示默认的按小时汇总保留的天数。
+	DefaultRetentionBatchSize  = 1000                       // 表示默认的每批删除的条数。
+	DefaultRetentionInterval   = _types.Duration(time.Hour) // 表示默认的执行汇总和清理的周期。
 )
 
 var (
@@ -39,31 +68,75 @@ var (
 	flagVerify  bool // 是否只检查配置文件
 	flagDebug   bool // 是否显示调试信息
 
-	configuration *Configuration = &Configuration{
+	flagOverrides configOverrides // 命令行参数指定的配置，优先于环境变量和配置文件
+
+	configuration *Configuration = newConfiguration() // 当前使用的配置。
+
+	logWriter *_utils.RollingFileLoggerWriter // 当前使用的日志文件。
+)
+
+// 创建包含所有默认值的配置。
+func newConfiguration() *Configuration {
+	return &Configuration{
 		Redis: RedisConfiguration{
+			Driver:   DefaultRedisDriver,
 			Host:     DefaultRedisHost,
 			Port:     DefaultRedisPort,
 			Password: DefaultRedisPassword,
 			DB:       DefaultRedisDB,
 		},
+		Schedule: ScheduleConfiguration{
+			Interval:     DefaultScheduleInterval,
+			InitialDelay: DefaultScheduleInitialDelay,
+			GracePeriod:  DefaultScheduleGracePeriod,
+		},
+		Health: HealthConfiguration{
+			HealthPolicyConfiguration: HealthPolicyConfiguration{
+				Window:       DefaultHealthWindow,
+				PassingRatio: DefaultHealthPassingRatio,
+				MinSamples:   DefaultHealthMinSamples,
+			},
+			FailuresToDegrade:  DefaultHealthFailuresToDegrade,
+			FailuresToDown:     DefaultHealthFailuresToDown,
+			SuccessesToRecover: DefaultHealthSuccessesToRecover,
+		},
+		Alert: AlertConfiguration{
+			Timeout:       DefaultAlertTimeout,
+			Retries:       DefaultAlertRetries,
+			RetryInterval: DefaultAlertRetryInterval,
+		},
+		Retention: RetentionConfiguration{
+			RawDays:    DefaultRetentionRawDays,
+			HourlyDays: DefaultRetentionHourlyDays,
+			BatchSize:  DefaultRetentionBatchSize,
+			Interval:   DefaultRetentionInterval,
+		},
+		Log: LogConfiguration{
+			File: DefaultLogFile,
+		},
 	}
-)
+}
 
 func init() {
 	log.SetFlags(log.Ldate | log.Ltime | log.Llongfile)
-	log.SetOutput(&_utils.RollingFileLoggerWriter{Pattern: "log/" + AppName + "-$date.log"})
+	logWriter = &_utils.RollingFileLoggerWriter{Pattern: DefaultLogFile}
+	log.SetOutput(logWriter)
 
 	flag.BoolVar(&flagVersion, "version", false, "Shows version message")
 	flag.BoolVar(&flagHelp, "h", false, "Shows this help message")
-	flag.BoolVar(&flagVerify, "verify", false, "Verify configuration and quit")
+	flag.BoolVar(&flagVerify, "verify", false, "Verify configuration and quit, same as command verify")
 	flag.BoolVar(&flagDebug, "debug", DefaultDebug, "Show debugging information")
+	flag.Var(&flagOverrides, "set", "Override configuration field as PATH=VALUE, e.g. Redis.Password=@file:/run/secrets/redis, can be repeated")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s -version\n", AppName)
 		fmt.Fprintf(os.Stderr, "Usage: %s -h\n", AppName)
-		fmt.Fprintf(os.Stderr, "Usage: %s -verify\n", AppName)
-		fmt.Fprintf(os.Stderr, "Usage: %s [-debug] [CONFIG_FILE]\n", AppName)
+		fmt.Fprintf(os.Stderr, "Usage: %s [-debug] [-set PATH=VALUE ...] [CONFIG_FILE]\n", AppName)
+		fmt.Fprintf(os.Stderr, "Usage: %s [-debug] [-set PATH=VALUE ...] COMMAND [ARGS...]\n", AppName)
 		flag.PrintDefaults()
+		printCommands()
+		fmt.Fprintf(os.Stderr, "Configuration is layered as defaults, CONFIG_FILE (JSON, YAML or TOML by extension), environment variables %sPATH (e.g. %sREDIS_PASSWORD) and -set.\n", ConfigEnvPrefix, ConfigEnvPrefix)
+		fmt.Fprintf(os.Stderr, "Any string value can be read from file by %s/run/secrets/NAME.\n", ConfigFilePrefix)
 	}
 }
 
@@ -84,134 +157,306 @@ func main() {
 		log.SetOutput(ioutil.Discard)
 	}
 
-	// 加载配置。
-	if err := loadConfig(strings.TrimSpace(flag.Arg(0))); err != nil {
-		panic(fmt.Errorf("cannot load configuration: %w", err))
+	// 选择子命令，如果第一个参数不是子命令，那么作为守护进程运行，以兼容旧的用法。
+	c, args := findCommand("run"), flag.Args()
+	if len(args) > 0 {
+		if c_ := findCommand(args[0]); c_ != nil {
+			c, args = c_, args[1:]
+		}
 	}
-
 	if flagVerify {
-		fmt.Printf("configuration:\n%#v\n", configuration)
-		return
+		c = findCommand("verify")
+	}
+
+	if err := c.Run(args); err != nil {
+		if s, ok := err.(exitStatus); ok {
+			os.Exit(int(s))
+		}
+
+		fmt.Fprintf(os.Stderr, "%s: %s\n", c.Name, err)
+		os.Exit(1)
 	}
+}
 
-	// 输出pid文件。
-	pidFilename := AppName + "-pid"
-	if runtime.GOOS != "windows" {
-		pidFilename = "/var/run/" + pidFilename
+// 初始化缓存和队列。
+// rc Redis配置，根据其中的`Driver`选择缓存和队列的实现。
+func initCacheAndQueue(rc *RedisConfiguration) error {
+	if c, q, err := newCacheAndQueue(rc); err != nil {
+		return err
+	} else {
+		_cache.SetDefault(c)
+		_queue.SetDefault(q)
+		_rpcclient.InitClient(c, q)
+		return nil
 	}
-	if pidFile, err := os.Create(pidFilename); err == nil {
-		pidFile.WriteString(fmt.Sprintf("%v", os.Getpid()))
-		pidFile.Close()
+}
 
-		defer os.Remove(pidFilename)
+// 创建缓存和队列。
+// rc Redis配置，根据其中的`Driver`选择缓存和队列的实现。
+// 返回新创建的缓存和队列，调用者负责关闭。
+func newCacheAndQueue(rc *RedisConfiguration) (_cache.Cache, _queue.Queue, error) {
+	if rc.Driver == _cache.DriverMemory {
+		return _cache.NewMemoryCache(), _queue.NewMemoryQueue(), nil
 	}
 
-	// 初始化数据库。
-	if err := _db.InitDB(configuration.DB.DSN); err != nil {
-		panic(err)
+	c, err := _cache.NewRedisCache(rc.Host, rc.Port, rc.Password, rc.DB)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// 初始化Redis缓存。
-	if err := _cache.InitRedisCache(configuration.Redis.Host, configuration.Redis.Port, configuration.Redis.Password, configuration.Redis.DB); err != nil {
-		panic(err)
+	q, err := _queue.NewRedisQueue(rc.Host, rc.Port, rc.Password, rc.DB)
+	if err != nil {
+		c.Close()
+		return nil, nil, err
 	}
 
-	// 初始化Redis队列。
-	if err := _queue.InitRedisQueue(configuration.Redis.Host, configuration.Redis.Port, configuration.Redis.Password, configuration.Redis.DB); err != nil {
-		panic(err)
+	return c, q, nil
+}
+
+// 表示根据配置创建的组件。
+type configComponents struct {
+	healthPolicies  *_health.Resolver  // 健康评估策略的解析器。
+	healthMachine   *_health.Machine   // 健康状态机。
+	alertSuppressor *_alert.Suppressor // 告警抑制器。
+	alertDispatcher *_alert.Dispatcher // 告警分发器。
+}
+
+// 加载配置文件，并创建依赖配置的全局对象。
+// configFile 配置文件路径，如果是目录则使用其中的默认配置文件，如果为空则使用默认配置文件。
+func loadConfig(configFile string) error {
+	configFile, err := resolveConfigFile(configFile)
+	if err != nil {
+		return err
 	}
 
-	// 开始服务。
-	err := runForEver()
+	c, cc, err := readConfig(configFile)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
+	configFilePath = configFile
+	configuration = c
+	healthPolicies = cc.healthPolicies
+	healthCounter = _health.NewCounter(cc.healthPolicies.MaxWindow())
+	healthMachine = cc.healthMachine
+	alertSuppressor = cc.alertSuppressor
+	alertDispatcher = cc.alertDispatcher
+
+	applyLogConfiguration(&c.Log)
+
+	return nil
 }
 
-func loadConfig(configFile string) (err error) {
+// 计算配置文件的绝对路径。
+// configFile 配置文件路径，如果是目录则使用其中的默认配置文件，如果为空则使用默认配置文件。
+func resolveConfigFile(configFile string) (string, error) {
 	if configFile == "" {
 		configFile = DefaultConfigFile
 	}
 
-	configFile, err = filepath.Abs(configFile)
+	configFile, err := filepath.Abs(configFile)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	configFileStat, err := os.Stat(configFile)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	if configFileStat.IsDir() {
 		configFile = filepath.Join(configFile, DefaultConfigFile)
 	}
 
-	err = loadConfigFromFile(configFile)
-	if err != nil {
-		return err
+	return configFile, nil
+}
+
+// 读取并检查配置文件，然后创建依赖配置的组件。此方法不修改任何全局对象。
+// configFile 配置文件的绝对路径。
+// 返回新的配置和组件，调用者负责关闭其中的告警分发器。
+func readConfig(configFile string) (*Configuration, *configComponents, error) {
+	// 依次使用默认值、配置文件、环境变量和命令行参数。
+	configuration := newConfiguration()
+	if err := loadConfigFromFile(configFile, configuration); err != nil {
+		return nil, nil, err
+	}
+	if err := overrideConfig(configuration, flagOverrides); err != nil {
+		return nil, nil, err
+	}
+
+	// 读取配置中引用的密钥文件，重新加载配置时也会重新读取。
+	if err := resolveConfigSecrets(configuration, filepath.Dir(configFile)); err != nil {
+		return nil, nil, err
 	}
 
 	// 检查数据库DSN的格式是否正确。
+	configuration.DB.Driver = strings.ToLower(strings.TrimSpace(configuration.DB.Driver))
 	configuration.DB.DSN = strings.TrimSpace(configuration.DB.DSN)
-	if configuration.DB.DSN == "" || !strings.Contains(configuration.DB.DSN, "@") || !strings.Contains(configuration.DB.DSN, ":") {
-		return fmt.Errorf("dsn should contains at(@) and colon(:)")
+	if configuration.DB.Driver == _db.DriverSQLite {
+		if configuration.DB.DSN == "" {
+			return nil, nil, fmt.Errorf("DB.DSN: dsn of sqlite should not be empty")
+		}
+	} else if configuration.DB.Driver == "" || configuration.DB.Driver == _db.DriverMySQL {
+		configuration.DB.Driver = _db.DriverMySQL
+		if configuration.DB.DSN == "" || !strings.Contains(configuration.DB.DSN, "@") || !strings.Contains(configuration.DB.DSN, ":") {
+			return nil, nil, fmt.Errorf("DB.DSN: dsn should contains at(@) and colon(:)")
+		}
+	} else {
+		return nil, nil, fmt.Errorf("DB.Driver: unknown database driver: %s", configuration.DB.Driver)
 	}
 
-	return err
-}
+	// 检查缓存和队列的实现是否正确。
+	configuration.Redis.Driver = strings.ToLower(strings.TrimSpace(configuration.Redis.Driver))
+	if configuration.Redis.Driver == "" {
+		configuration.Redis.Driver = _cache.DriverRedis
+	} else if configuration.Redis.Driver != _cache.DriverRedis && configuration.Redis.Driver != _cache.DriverMemory {
+		return nil, nil, fmt.Errorf("Redis.Driver: unknown redis driver: %s", configuration.Redis.Driver)
+	}
+
+	// 检查日志配置是否正确。
+	configuration.Log.File = strings.TrimSpace(configuration.Log.File)
+	if configuration.Log.File == "" {
+		return nil, nil, fmt.Errorf("Log.File: log file should not be empty")
+	}
+
+	// 检查轮询计划是否正确。
+	if err := verifyScheduleConfiguration(&configuration.Schedule); err != nil {
+		return nil, nil, err
+	}
 
-func loadConfigFromFile(configFile string) (err error) {
-	var cf *os.File
+	// 检查监控日志保留配置是否正确。
+	if err := verifyRetentionConfiguration(&configuration.Retention); err != nil {
+		return nil, nil, err
+	}
+
+	cc := &configComponents{}
+
+	// 创建健康评估策略。
+	if r, err := newHealthPolicyResolver(&configuration.Health); err != nil {
+		return nil, nil, err
+	} else {
+		cc.healthPolicies = r
+	}
+
+	// 创建健康状态机。
+	if m, err := newHealthMachine(&configuration.Health); err != nil {
+		return nil, nil, err
+	} else {
+		cc.healthMachine = m
+	}
 
-	fmt.Printf("Loading configuration from %s ...\n", configFile)
+	// 创建告警抑制器。
+	if s, err := newAlertSuppressor(&configuration.Alert, configuration.Maintenances); err != nil {
+		return nil, nil, err
+	} else {
+		cc.alertSuppressor = s
+	}
+
+	// 最后创建告警分发器，因为其中启动了汇总routine。
+	if d, err := newAlertDispatcher(&configuration.Alert, filepath.Dir(configFile)); err != nil {
+		return nil, nil, err
+	} else {
+		cc.alertDispatcher = d
+	}
+
+	return configuration, cc, nil
+}
 
-	if cf, err = os.Open(configFile); err != nil {
+// 读取配置文件，严格检查其中的配置项，然后覆盖配置中的默认值。
+// configFile 配置文件的路径。
+// configuration 包含默认值的配置。
+func loadConfigFromFile(configFile string, configuration *Configuration) error {
+	fmt.Fprintf(os.Stderr, "Loading configuration from %s ...\n", configFile)
+
+	tree, err := readConfigTree(configFile)
+	if err != nil {
 		return err
 	}
 
-	defer cf.Close()
+	if err := verifyConfigTree(tree, reflect.TypeOf(configuration).Elem(), ""); err != nil {
+		return fmt.Errorf("illegal configuration: %w", err)
+	}
 
-	dec := json.NewDecoder(cf)
-	err = dec.Decode(&configuration)
+	b, err := json.Marshal(tree)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	return dec.Decode(configuration)
+}
+
+// 应用日志配置。
+// 指定命令行参数`-debug`或者配置了输出日志时，日志写入按日期滚动的文件，否则丢弃日志。
+// lc 日志配置。
+func applyLogConfiguration(lc *LogConfiguration) {
+	if !flagDebug && !lc.Debug {
+		log.SetOutput(ioutil.Discard)
+		return
+	}
+
+	if logWriter.Pattern == lc.File {
+		log.SetOutput(logWriter)
+		return
+	}
+
+	// 替换之后旧的日志文件不会再被写入，可以安全地关闭。
+	old := logWriter
+	logWriter = &_utils.RollingFileLoggerWriter{Pattern: lc.File}
+	log.SetOutput(logWriter)
+	old.Close()
 }
 
 func runForEver() error {
-	go doRun()
+	s, err := doRun()
+	if err != nil {
+		return err
+	}
 
 	// 启动守护routine。
 	sigChannel := make(chan os.Signal, 256)
-	signal.Notify(sigChannel, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
+	signal.Notify(sigChannel, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
 	for {
 		sig := <-sigChannel
 		fmt.Fprintf(os.Stderr, "Received sig: %#v\n", sig)
 		switch sig {
+		case syscall.SIGHUP:
+			// 重新加载失败时继续使用原来的配置，原因已经记录在日志中。
+			reloadConfig()
 		case syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM:
+			shutdown(s)
 			return nil
 		}
 	}
 }
 
-func doRun() {
-	// TODO: 通过配置文件设置轮询周期。
-	timer := time.NewTimer(5 * time.Second)
-
-	fmt.Printf("Checking... \n")
+func doRun() (*_scheduler.Scheduler, error) {
+	if jobs, err := newJobs(configuration); err != nil {
+		return nil, err
+	} else {
+		s := _scheduler.NewScheduler()
+		for _, j := range jobs {
+			s.Add(j)
+		}
+		checkScheduler = s
 
-	for {
-		<-timer.C
-		timer.Reset(5 * time.Minute)
+		fmt.Printf("Checking... \n")
 
-		go func() {
-			defer _utils.RecoverPanic()
+		s.Start()
+		return s, nil
+	}
+}
 
-			doCheck()
-		}()
+// 根据配置创建所有定时任务，包括所有轮询计划和汇总清理任务。
+// c 配置。
+// 返回新创建的任务。
+func newJobs(c *Configuration) ([]*_scheduler.Job, error) {
+	if jobs, err := newCheckJobs(&c.Schedule); err != nil {
+		return nil, err
+	} else if j, err := newRetentionJob(&c.Retention); err != nil {
+		return nil, err
+	} else {
+		return append(jobs, j), nil
 	}
 }
@@ -0,0 +1,204 @@
+// 该模块定义了Prometheus监控指标。
+// @Author: Haart
+// @Created: 2026-10-18
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace string = "tracking_monitor" // 所有指标的命名空间。
+)
+
+var (
+	// 爬虫的名称只出现在信息指标中，名称变化时不会产生新的状态序列，查询时通过`crawler_id`关联。
+	crawlerInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "crawler_info",
+		Help:      "Information of crawler, always 1.",
+	}, []string{"crawler_id", "crawler_name", "carrier_code"})
+
+	crawlerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "crawler_health_state",
+		Help:      "Health state of crawler: 0=UNKNOWN, 1=OK, 2=DEGRADED, 3=DOWN, 4=RECOVERING.",
+	}, []string{"crawler_id", "carrier_code"})
+
+	crawlerHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "crawler_healthy",
+		Help:      "Whether crawler is available (1) or not (0).",
+	}, []string{"crawler_id", "carrier_code"})
+
+	checkTiming = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "check_timing_seconds",
+		Help:      "Time from pushing tracking-search to agent returning, per crawler.",
+		Buckets:   []float64{.5, 1, 2, 5, 10, 15, 20, 30, 45, 60},
+	}, []string{"crawler_id", "carrier_code"})
+
+	checkResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "check_results_total",
+		Help:      "Count of check results by agent code.",
+	}, []string{"carrier_code", "agent_code"})
+
+	roundDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "round_duration_seconds",
+		Help:      "Duration of check rounds.",
+		Buckets:   []float64{1, 5, 10, 20, 30, 45, 60, 90, 120, 300},
+	}, []string{"schedule"})
+
+	roundSkipped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rounds_skipped_total",
+		Help:      "Count of check rounds skipped because previous round was still running.",
+	}, []string{"schedule"})
+
+	cacheVanished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_vanished_total",
+		Help:      "Count of tracking-search cache keys vanished before agent returned.",
+	}, []string{"priority"})
+)
+
+// 记录已经输出指标的爬虫的标签，用于删除名称或者运输商变化之后的旧序列，以及已经删除的爬虫的序列。
+var (
+	crawlerLabels     = make(map[int64]crawlerLabel)
+	crawlerLabelsLock sync.Mutex
+)
+
+type crawlerLabel struct {
+	name        string // 爬虫名称。
+	carrierCode string // 运输商编号。
+}
+
+func init() {
+	prometheus.MustRegister(crawlerInfo, crawlerState, crawlerHealthy, checkTiming, checkResults, roundDuration, roundSkipped, cacheVanished)
+}
+
+// 记录一次监控结果。
+// crawlerId 爬虫ID。
+// carrierCode 运输商编号。
+// timing 监控耗时。
+// agentCode 查询代理的返回码。
+func ObserveCheck(crawlerId int64, carrierCode string, timing time.Duration, agentCode int) {
+	touchCrawler(crawlerId, carrierCode)
+
+	checkTiming.WithLabelValues(strconv.FormatInt(crawlerId, 10), carrierCode).Observe(timing.Seconds())
+	checkResults.WithLabelValues(carrierCode, strconv.Itoa(agentCode)).Inc()
+}
+
+// 记录爬虫的健康状态。
+// crawlerId 爬虫ID。
+// carrierCode 运输商编号。
+// state 健康状态。
+// healthy 是否可用。
+func SetCrawlerState(crawlerId int64, carrierCode string, state int, healthy bool) {
+	touchCrawler(crawlerId, carrierCode)
+
+	labels := []string{strconv.FormatInt(crawlerId, 10), carrierCode}
+
+	crawlerState.WithLabelValues(labels...).Set(float64(state))
+	if healthy {
+		crawlerHealthy.WithLabelValues(labels...).Set(1)
+	} else {
+		crawlerHealthy.WithLabelValues(labels...).Set(0)
+	}
+}
+
+// 记录爬虫的信息，如果爬虫的名称或者运输商发生了变化，那么删除旧的序列。
+// crawlerId 爬虫ID。
+// crawlerName 爬虫名称。
+// carrierCode 运输商编号。
+func SetCrawlerInfo(crawlerId int64, crawlerName, carrierCode string) {
+	crawlerLabelsLock.Lock()
+	defer crawlerLabelsLock.Unlock()
+
+	l := crawlerLabel{name: crawlerName, carrierCode: carrierCode}
+	if old, ok := crawlerLabels[crawlerId]; ok && old != l {
+		deleteCrawlerSeries(crawlerId, old)
+	}
+	crawlerLabels[crawlerId] = l
+
+	crawlerInfo.WithLabelValues(strconv.FormatInt(crawlerId, 10), crawlerName, carrierCode).Set(1)
+}
+
+// 删除不在指定集合中的爬虫的所有序列，用于清理已经删除或者停用的爬虫。
+// crawlerIds 仍然存在的爬虫ID。
+func RetainCrawlers(crawlerIds map[int64]bool) {
+	crawlerLabelsLock.Lock()
+	defer crawlerLabelsLock.Unlock()
+
+	for crawlerId, l := range crawlerLabels {
+		if !crawlerIds[crawlerId] {
+			deleteCrawlerSeries(crawlerId, l)
+			delete(crawlerLabels, crawlerId)
+		}
+	}
+}
+
+// 记录爬虫的运输商，如果和之前不同，那么删除旧的序列。
+func touchCrawler(crawlerId int64, carrierCode string) {
+	crawlerLabelsLock.Lock()
+	defer crawlerLabelsLock.Unlock()
+
+	if old, ok := crawlerLabels[crawlerId]; !ok {
+		crawlerLabels[crawlerId] = crawlerLabel{carrierCode: carrierCode}
+	} else if old.carrierCode != carrierCode {
+		deleteCrawlerSeries(crawlerId, old)
+		crawlerLabels[crawlerId] = crawlerLabel{carrierCode: carrierCode}
+	}
+}
+
+func deleteCrawlerSeries(crawlerId int64, l crawlerLabel) {
+	id := strconv.FormatInt(crawlerId, 10)
+	crawlerInfo.DeleteLabelValues(id, l.name, l.carrierCode)
+	crawlerState.DeleteLabelValues(id, l.carrierCode)
+	crawlerHealthy.DeleteLabelValues(id, l.carrierCode)
+	checkTiming.DeleteLabelValues(id, l.carrierCode)
+}
+
+// 记录一个轮次的耗时。
+// schedule 轮询计划的名字。
+// d 耗时。
+func ObserveRound(schedule string, d time.Duration) {
+	roundDuration.WithLabelValues(schedule).Observe(d.Seconds())
+}
+
+// 记录一个被跳过的轮次。
+// schedule 轮询计划的名字。
+func IncRoundSkipped(schedule string) {
+	roundSkipped.WithLabelValues(schedule).Inc()
+}
+
+// 记录一个已消失的缓存。
+// priority 查询对象的优先级。
+func IncCacheVanished(priority string) {
+	cacheVanished.WithLabelValues(priority).Inc()
+}
+
+// 注册队列长度指标。
+// priority 队列的优先级。
+// f 获取队列长度的函数，在每次采集指标时调用。
+func RegisterQueueDepth(priority string, f func() float64) {
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "queue_depth",
+		Help:        "Length of tracking queue.",
+		ConstLabels: prometheus.Labels{"priority": priority},
+	}, f))
+}
+
+// 获取输出所有指标的HTTP Handler。
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRenamedCrawlerKeepsOneSeries(t *testing.T) {
+	defer RetainCrawlers(nil)
+
+	SetCrawlerInfo(1, "HB1", "DHL")
+	SetCrawlerState(1, "DHL", 1, true)
+	SetCrawlerInfo(1, "HB1-renamed", "DHL")
+	SetCrawlerState(1, "DHL", 3, false)
+
+	if n := testutil.CollectAndCount(crawlerInfo); n != 1 {
+		t.Errorf("expected 1 info series but got %d", n)
+	}
+	if n := testutil.CollectAndCount(crawlerState); n != 1 {
+		t.Errorf("expected 1 state series but got %d", n)
+	}
+	if v := testutil.ToFloat64(crawlerState.WithLabelValues("1", "DHL")); v != 3 {
+		t.Errorf("expected state 3 but got %v", v)
+	}
+}
+
+func TestRetainCrawlersDeletesStaleSeries(t *testing.T) {
+	defer RetainCrawlers(nil)
+
+	SetCrawlerInfo(1, "HB1", "DHL")
+	SetCrawlerState(1, "DHL", 1, true)
+	ObserveCheck(1, "DHL", time.Second, 200)
+	SetCrawlerInfo(2, "HB2", "UPS")
+	SetCrawlerState(2, "UPS", 3, false)
+	ObserveCheck(2, "UPS", time.Second, 200)
+
+	RetainCrawlers(map[int64]bool{2: true})
+
+	for name, n := range map[string]int{
+		"info":    testutil.CollectAndCount(crawlerInfo),
+		"state":   testutil.CollectAndCount(crawlerState),
+		"healthy": testutil.CollectAndCount(crawlerHealthy),
+		"timing":  testutil.CollectAndCount(checkTiming),
+	} {
+		if n != 1 {
+			t.Errorf("expected 1 %s series but got %d", name, n)
+		}
+	}
+
+	if v := testutil.ToFloat64(crawlerHealthy.WithLabelValues("2", "UPS")); v != 0 {
+		t.Errorf("expected crawler 2 unhealthy but got %v", v)
+	}
+}
+
+func TestChangedCarrierDeletesOldSeries(t *testing.T) {
+	defer RetainCrawlers(nil)
+
+	SetCrawlerInfo(1, "HB1", "DHL")
+	SetCrawlerState(1, "DHL", 1, true)
+	SetCrawlerInfo(1, "HB1", "UPS")
+	SetCrawlerState(1, "UPS", 1, true)
+
+	if n := testutil.CollectAndCount(crawlerHealthy); n != 1 {
+		t.Errorf("expected 1 healthy series but got %d", n)
+	}
+}
@@ -0,0 +1,218 @@
+// 该模块实现了兼容Nagios/Icinga插件规范的监控模式。
+// @Author: Haart
+// @Created: 2026-10-18
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	_agent "com.cne/ai-tracking-monitor/agent"
+	_db "com.cne/ai-tracking-monitor/db"
+	_rpcclient "com.cne/ai-tracking-monitor/rpcclient"
+)
+
+// 表示Nagios插件的状态，也是插件的退出码。
+type nagiosStatus int
+
+const (
+	NagiosOk       nagiosStatus = 0 // 正常。
+	NagiosWarning  nagiosStatus = 1 // 警告。
+	NagiosCritical nagiosStatus = 2 // 严重。
+	NagiosUnknown  nagiosStatus = 3 // 未知。
+)
+
+func (s nagiosStatus) String() string {
+	if s == NagiosOk {
+		return "OK"
+	} else if s == NagiosWarning {
+		return "WARNING"
+	} else if s == NagiosCritical {
+		return "CRITICAL"
+	} else {
+		return "UNKNOWN"
+	}
+}
+
+// 根据查询代理的返回码判断Nagios插件的状态。
+// 单号未查询到视为警告，超时、解析失败和其它错误视为严重。
+// agentCode 查询代理的返回码。
+func classifyNagiosStatus(agentCode _agent.AgCode) nagiosStatus {
+	if agentCode == _agent.AcSuccess || agentCode == _agent.AcSuccess2 {
+		return NagiosOk
+	} else if agentCode == _agent.AcNoTracking {
+		return NagiosWarning
+	} else {
+		return NagiosCritical
+	}
+}
+
+// 根据耗时判断Nagios插件的状态。
+// timing 耗时。
+// warning 警告阈值，0表示不检查。
+// critical 严重阈值，0表示不检查。
+func classifyNagiosTiming(timing, warning, critical time.Duration) nagiosStatus {
+	if critical > 0 && timing >= critical {
+		return NagiosCritical
+	} else if warning > 0 && timing >= warning {
+		return NagiosWarning
+	} else {
+		return NagiosOk
+	}
+}
+
+// 格式化Nagios插件的结果行。
+// status 插件的状态。
+// message 结果的说明。
+// perfData 性能数据。
+func formatNagiosResult(status nagiosStatus, message string, perfData []string) string {
+	message = strings.ReplaceAll(strings.ReplaceAll(message, "\n", " "), "|", "/")
+	if len(perfData) == 0 {
+		return fmt.Sprintf("CRAWLER %s - %s", status, message)
+	} else {
+		return fmt.Sprintf("CRAWLER %s - %s | %s", status, message, strings.Join(perfData, " "))
+	}
+}
+
+// 输出Nagios插件的结果行。
+// status 插件的状态。
+// message 结果的说明。
+// perfData 性能数据。
+func printNagiosResult(status nagiosStatus, message string, perfData []string) {
+	fmt.Println(formatNagiosResult(status, message, perfData))
+}
+
+// 将Nagios插件的状态转换为命令的退出状态，正常时返回nil。
+func nagiosExitStatus(status nagiosStatus) error {
+	if status != NagiosOk {
+		return exitStatus(status)
+	}
+
+	return nil
+}
+
+// 将阈值格式化为性能数据中的毫秒数，0表示未设置。
+func formatNagiosThreshold(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	} else {
+		return fmt.Sprintf("%d", d.Milliseconds())
+	}
+}
+
+// nagios [-crawler ID | -carrier CODE] [-warning DURATION] [-critical DURATION] [-record] [-timeout DURATION] [CONFIG_FILE]
+// 按照Nagios/Icinga插件规范监控一个爬虫或者一个运输商的所有爬虫。
+// 以0/1/2/3（OK/WARNING/CRITICAL/UNKNOWN）退出，输出的结果行包含性能数据（耗时和事件数）。
+func cmdNagios(args []string) (err error) {
+	fs := newFlagSet(findCommand("nagios"))
+	crawler := fs.String("crawler", "", "Crawler id")
+	carrier := fs.String("carrier", "", "Carrier code")
+	warning := fs.Duration("warning", 0, "Timing to report WARNING, disabled if 0")
+	critical := fs.Duration("critical", 0, "Timing to report CRITICAL, disabled if 0")
+	record := fs.Bool("record", false, "Save results into health log, a running daemon counts them only after restart")
+	timeout := fs.Duration("timeout", _rpcclient.DefaultPullTimeout, "Time to wait for result")
+
+	// 插件的任何错误都报告为UNKNOWN。
+	defer func() {
+		if r := recover(); r != nil {
+			printNagiosResult(NagiosUnknown, fmt.Sprintf("%v", r), nil)
+			err = exitStatus(NagiosUnknown)
+		}
+	}()
+
+	unknown := func(format string, args ...interface{}) error {
+		printNagiosResult(NagiosUnknown, fmt.Sprintf(format, args...), nil)
+		return exitStatus(NagiosUnknown)
+	}
+
+	fs.Init(fs.Name(), flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return unknown("%s", err)
+	}
+
+	if (*crawler == "") == (*carrier == "") {
+		return unknown("either -crawler or -carrier should be specified")
+	}
+	if strings.Contains(*crawler, ",") || strings.Contains(*carrier, ",") {
+		return unknown("only one crawler or carrier can be checked")
+	}
+
+	crawlerIds, carrierCodes, err := parseCrawlerFilter(*crawler, *carrier)
+	if err != nil {
+		return unknown("%s", err)
+	}
+
+	if err := initWithDBAndQueue(fs.Arg(0)); err != nil {
+		return unknown("%s", err)
+	}
+
+	crawlerInfoList, err := selectCrawlerInfos(crawlerIds, carrierCodes)
+	if err != nil {
+		return unknown("%s", err)
+	}
+
+	ctx, cancel := newCommandContext(*timeout)
+	defer cancel()
+
+	results, err := checkNow(ctx, crawlerInfoList, *record)
+	if err != nil {
+		return unknown("%s", err)
+	}
+
+	status, message, perfData := summarizeNagiosResults(crawlerInfoList, results, *warning, *critical)
+	printNagiosResult(status, message, perfData)
+
+	return nagiosExitStatus(status)
+}
+
+// 汇总监控结果，得到Nagios插件的状态、结果的说明和性能数据。
+// 插件的状态是所有爬虫中最严重的状态，监控多个爬虫时性能数据的标签附加爬虫ID。
+// crawlerInfoList 需要监控的爬虫。
+// results 监控结果。
+// warning 耗时的警告阈值，0表示不检查。
+// critical 耗时的严重阈值，0表示不检查。
+func summarizeNagiosResults(crawlerInfoList []*_db.CrawlerInfoPo, results []*checkNowResultVo, warning, critical time.Duration) (nagiosStatus, string, []string) {
+	returned := make(map[int64]bool)
+	status := NagiosOk
+	messages := make([]string, 0)
+	perfData := make([]string, 0)
+	for _, r := range results {
+		returned[r.CrawlerId] = true
+
+		s := classifyNagiosStatus(r.AgentCode)
+		if ts := classifyNagiosTiming(time.Duration(r.Timing)*time.Millisecond, warning, critical); ts > s {
+			s = ts
+		}
+		if s > status {
+			status = s
+		}
+
+		message := fmt.Sprintf("%s(id=%d) %s %dms", r.CrawlerName, r.CrawlerId, s, r.Timing)
+		if r.ResultNote != "" {
+			message += ": " + r.ResultNote
+		}
+		messages = append(messages, message)
+
+		suffix := ""
+		if len(crawlerInfoList) > 1 {
+			suffix = fmt.Sprintf("_%d", r.CrawlerId)
+		}
+		perfData = append(perfData,
+			fmt.Sprintf("'timing%s'=%dms;%s;%s;0;", suffix, r.Timing, formatNagiosThreshold(warning), formatNagiosThreshold(critical)),
+			fmt.Sprintf("'events%s'=%d;;;0;", suffix, len(r.Events)))
+	}
+
+	// 缓存已消失的查询对象无法判断结果。
+	for _, crawlerInfo := range crawlerInfoList {
+		if !returned[crawlerInfo.Id] {
+			if status == NagiosOk {
+				status = NagiosUnknown
+			}
+			messages = append(messages, fmt.Sprintf("%s(id=%d) %s: no result returned", crawlerInfo.Name, crawlerInfo.Id, NagiosUnknown))
+		}
+	}
+
+	return status, strings.Join(messages, ", "), perfData
+}
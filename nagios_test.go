@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	_agent "com.cne/ai-tracking-monitor/agent"
+	_db "com.cne/ai-tracking-monitor/db"
+	_rpcclient "com.cne/ai-tracking-monitor/rpcclient"
+)
+
+func TestNagiosExitCodes(t *testing.T) {
+	crawler1 := &_db.CrawlerInfoPo{Id: 1, Name: "c1"}
+	crawler2 := &_db.CrawlerInfoPo{Id: 2, Name: "c2"}
+
+	tests := []struct {
+		name     string
+		crawlers []*_db.CrawlerInfoPo
+		results  []*checkNowResultVo
+		warning  time.Duration
+		critical time.Duration
+		code     int
+	}{
+		{"ok", []*_db.CrawlerInfoPo{crawler1},
+			[]*checkNowResultVo{{CrawlerId: 1, AgentCode: _agent.AcSuccess, Timing: 100}}, 0, 0, 0},
+		{"ok2", []*_db.CrawlerInfoPo{crawler1},
+			[]*checkNowResultVo{{CrawlerId: 1, AgentCode: _agent.AcSuccess2, Timing: 100}}, 0, 0, 0},
+		{"no tracking", []*_db.CrawlerInfoPo{crawler1},
+			[]*checkNowResultVo{{CrawlerId: 1, AgentCode: _agent.AcNoTracking, Timing: 100}}, 0, 0, 1},
+		{"timeout", []*_db.CrawlerInfoPo{crawler1},
+			[]*checkNowResultVo{{CrawlerId: 1, AgentCode: _agent.AcTimeout, Timing: 100}}, 0, 0, 2},
+		{"parse failed", []*_db.CrawlerInfoPo{crawler1},
+			[]*checkNowResultVo{{CrawlerId: 1, AgentCode: _agent.AcParseFailed, Timing: 100}}, 0, 0, 2},
+		{"slow warning", []*_db.CrawlerInfoPo{crawler1},
+			[]*checkNowResultVo{{CrawlerId: 1, AgentCode: _agent.AcSuccess, Timing: 1500}}, time.Second, 2 * time.Second, 1},
+		{"slow critical", []*_db.CrawlerInfoPo{crawler1},
+			[]*checkNowResultVo{{CrawlerId: 1, AgentCode: _agent.AcNoTracking, Timing: 2000}}, time.Second, 2 * time.Second, 2},
+		{"no result", []*_db.CrawlerInfoPo{crawler1}, nil, 0, 0, 3},
+		{"worst of all", []*_db.CrawlerInfoPo{crawler1, crawler2},
+			[]*checkNowResultVo{
+				{CrawlerId: 1, AgentCode: _agent.AcSuccess, Timing: 100},
+				{CrawlerId: 2, AgentCode: _agent.AcNoTracking, Timing: 100},
+			}, 0, 0, 1},
+		{"critical over missing", []*_db.CrawlerInfoPo{crawler1, crawler2},
+			[]*checkNowResultVo{{CrawlerId: 1, AgentCode: _agent.AcOther, Timing: 100}}, 0, 0, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, _, _ := summarizeNagiosResults(tt.crawlers, tt.results, tt.warning, tt.critical)
+			if int(status) != tt.code {
+				t.Fatalf("status = %s(%d), want %d", status, status, tt.code)
+			}
+
+			err := nagiosExitStatus(status)
+			if tt.code == 0 {
+				if err != nil {
+					t.Fatalf("exit error = %v, want nil", err)
+				}
+			} else {
+				var es exitStatus
+				if !errors.As(err, &es) || int(es) != tt.code {
+					t.Fatalf("exit error = %v, want exit status %d", err, tt.code)
+				}
+			}
+		})
+	}
+}
+
+func TestNagiosPerfData(t *testing.T) {
+	events := _rpcclient.TrackingEvents{{Details: "e1"}, {Details: "e2"}}
+
+	tests := []struct {
+		name     string
+		crawlers []*_db.CrawlerInfoPo
+		results  []*checkNowResultVo
+		warning  time.Duration
+		critical time.Duration
+		line     string
+	}{
+		{"single crawler",
+			[]*_db.CrawlerInfoPo{{Id: 7, Name: "c7"}},
+			[]*checkNowResultVo{{CrawlerId: 7, CrawlerName: "c7", AgentCode: _agent.AcSuccess, Timing: 120, Events: events}},
+			time.Second, 2 * time.Second,
+			"CRAWLER OK - c7(id=7) OK 120ms | 'timing'=120ms;1000;2000;0; 'events'=2;;;0;"},
+		{"without thresholds",
+			[]*_db.CrawlerInfoPo{{Id: 7, Name: "c7"}},
+			[]*checkNowResultVo{{CrawlerId: 7, CrawlerName: "c7", AgentCode: _agent.AcNoTracking, Timing: 80, ResultNote: "a|b\nc"}},
+			0, 0,
+			"CRAWLER WARNING - c7(id=7) WARNING 80ms: a/b c | 'timing'=80ms;;;0; 'events'=0;;;0;"},
+		{"multiple crawlers",
+			[]*_db.CrawlerInfoPo{{Id: 1, Name: "c1"}, {Id: 2, Name: "c2"}},
+			[]*checkNowResultVo{
+				{CrawlerId: 1, CrawlerName: "c1", AgentCode: _agent.AcSuccess, Timing: 10, Events: events},
+				{CrawlerId: 2, CrawlerName: "c2", AgentCode: _agent.AcTimeout, Timing: 20},
+			},
+			0, 0,
+			"CRAWLER CRITICAL - c1(id=1) OK 10ms, c2(id=2) CRITICAL 20ms | 'timing_1'=10ms;;;0; 'events_1'=2;;;0; 'timing_2'=20ms;;;0; 'events_2'=0;;;0;"},
+		{"no result",
+			[]*_db.CrawlerInfoPo{{Id: 3, Name: "c3"}},
+			nil,
+			0, 0,
+			"CRAWLER UNKNOWN - c3(id=3) UNKNOWN: no result returned"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, message, perfData := summarizeNagiosResults(tt.crawlers, tt.results, tt.warning, tt.critical)
+			if line := formatNagiosResult(status, message, perfData); line != tt.line {
+				t.Fatalf("line = %q, want %q", line, tt.line)
+			}
+		})
+	}
+}
@@ -5,79 +5,153 @@ package queue
 
 import (
 	"context"
-	"fmt"
-	"strconv"
+	"sync"
 
 	"github.com/go-redis/redis/v8"
 )
 
-var (
-	redisHost     string
-	redisPort     int
-	redisPassword string
-	redisDB       int
+const (
+	DriverRedis  string = "redis"  // 基于Redis的队列。
+	DriverMemory string = "memory" // 基于进程内存的队列，用于测试和单机运行。
+
+	Nil = redis.Nil // 表示队列为空。
 
-	redisClient *redis.Client
-	redisCtx    context.Context
+	subscriptionBufferSize int = 256 // 订阅的消息缓冲区大小。
 )
 
-func init() {
-	redisCtx = context.Background()
+// 表示先进先出的消息队列，每个主题对应一个独立的队列。
+// 所有的方法都在 `ctx` 被取消时尽快返回 `ctx.Err()`。
+type Queue interface {
+	// 获取队列的长度。
+	Length(ctx context.Context, topic string) (int64, error)
+
+	// 将值入队，返回队列的新长度。
+	Push(ctx context.Context, topic string, value string) (int64, error)
+
+	// 将值出队，如果队列为空则返回`Nil`。
+	Pop(ctx context.Context, topic string) (string, error)
+
+	// 从队列中删除所有等于指定值的元素，返回删除的个数。
+	Remove(ctx context.Context, topic string, value string) (int64, error)
+
+	// 向频道发布消息，没有订阅者时消息被丢弃。
+	Publish(ctx context.Context, channel string, message string) error
+
+	// 订阅频道，此方法返回时订阅已经生效。
+	Subscribe(ctx context.Context, channel string) (Subscription, error)
+
+	// 关闭队列，释放连接。
+	Close() error
+}
+
+// 表示对某个频道的订阅。
+type Subscription interface {
+	// 获取接收消息的通道，订阅关闭之后此通道也被关闭。
+	Channel() <-chan string
+
+	// 关闭订阅。
+	Close() error
 }
 
+var (
+	queue     Queue        // 当前使用的队列。
+	queueLock sync.RWMutex // 保护当前使用的队列，重新加载配置时会被替换。
+)
+
 // 初始化Redis队列配置。
 // host Redis主机。
 // port Redis端口号。
 // password Redis口令。
 // db Redis队列使用的数据库。
 func InitRedisQueue(host string, port int, password string, db int) error {
-	redisHost = host
-	redisPort = port
-	redisPassword = password
-	redisDB = db
-
-	client := redis.NewClient(&redis.Options{
-		Addr:     redisHost + ":" + strconv.Itoa(redisPort),
-		Password: redisPassword,
-		DB:       redisDB,
-	})
-	if client == nil {
-		return fmt.Errorf("cannot create redis client")
-	}
-	if _, err := client.Ping(redisCtx).Result(); err != nil {
+	if q, err := NewRedisQueue(host, port, password, db); err != nil {
 		return err
 	} else {
-		redisClient = client
+		SetDefault(q)
 		return nil
 	}
 }
 
+// 初始化进程内存队列。
+func InitMemoryQueue() {
+	SetDefault(NewMemoryQueue())
+}
+
+// 获取当前使用的队列。
+func Default() Queue {
+	queueLock.RLock()
+	defer queueLock.RUnlock()
+
+	return queue
+}
+
+// 替换当前使用的队列。
+// q 新的队列。
+// 返回被替换的队列，调用者负责关闭，如果之前没有初始化则返回nil。
+func SetDefault(q Queue) Queue {
+	queueLock.Lock()
+	defer queueLock.Unlock()
+
+	old := queue
+	queue = q
+	return old
+}
+
 // 获取队列的长度。
+// ctx 上下文。
 // topic 主题。
 // 返回队列的当前长度。
-func Length(topic string) (int64, error) {
-	return redisClient.LLen(redisCtx, topic).Result()
+func Length(ctx context.Context, topic string) (int64, error) {
+	return Default().Length(ctx, topic)
 }
 
 // 将值入队。
+// ctx 上下文。
 // topic 主题。
 // 待入队的值。
 // 返回队列的新长度。
-func Push(topic string, value string) (int64, error) {
-	return redisClient.LPush(redisCtx, topic, value).Result()
+func Push(ctx context.Context, topic string, value string) (int64, error) {
+	return Default().Push(ctx, topic, value)
 }
 
 // 将值出队。
+// ctx 上下文。
 // topic 主题。
 // 返回出队的值。
-func Pop(topic string) (string, error) {
-	return redisClient.RPop(redisCtx, topic).Result()
-
-	// if v, err := redisClient.BRPop(1*time.Second, topic).Result(); err != nil {
-	// 	return "", err
-	// } else if len(v) < 2 {
-	// 	return "", redis.Nil
-	// } else {
-	// 	return v[1], nil
-	// }
+func Pop(ctx context.Context, topic string) (string, error) {
+	return Default().Pop(ctx, topic)
+}
+
+// 从队列中删除值。
+// ctx 上下文。
+// topic 主题。
+// value 待删除的值。
+// 返回删除的个数。
+func Remove(ctx context.Context, topic string, value string) (int64, error) {
+	return Default().Remove(ctx, topic, value)
+}
+
+// 向频道发布消息。
+// ctx 上下文。
+// channel 频道。
+// message 消息。
+func Publish(ctx context.Context, channel string, message string) error {
+	return Default().Publish(ctx, channel, message)
+}
+
+// 订阅频道。
+// ctx 上下文。
+// channel 频道。
+// 返回订阅，调用者负责关闭。
+func Subscribe(ctx context.Context, channel string) (Subscription, error) {
+	return Default().Subscribe(ctx, channel)
+}
+
+// 关闭当前使用的队列。
+func Close() error {
+	if q := Default(); q == nil {
+		return nil
+	} else {
+		return q.Close()
+	}
 }
@@ -0,0 +1,163 @@
+// 该模块实现了基于进程内存的消息队列，用于测试和单机运行。
+// @Author: Haart
+// @Created: 2026-10-18
+package queue
+
+import (
+	"context"
+	"sync"
+)
+
+// 基于进程内存的队列，语义和Redis的List保持一致，队列为空时删除主题。
+type memoryQueue struct {
+	mu            sync.Mutex
+	topics        map[string][]string
+	subscriptions map[string]map[*memorySubscription]struct{}
+}
+
+// 创建进程内存队列。
+func NewMemoryQueue() Queue {
+	return &memoryQueue{topics: make(map[string][]string), subscriptions: make(map[string]map[*memorySubscription]struct{})}
+}
+
+func (q *memoryQueue) Length(ctx context.Context, topic string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return int64(len(q.topics[topic])), nil
+}
+
+func (q *memoryQueue) Push(ctx context.Context, topic string, value string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	l := append(q.topics[topic], value)
+	q.topics[topic] = l
+
+	return int64(len(l)), nil
+}
+
+func (q *memoryQueue) Pop(ctx context.Context, topic string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	l := q.topics[topic]
+	if len(l) == 0 {
+		return "", Nil
+	}
+
+	value := l[0]
+	l[0] = ""
+	if len(l) == 1 {
+		delete(q.topics, topic)
+	} else {
+		q.topics[topic] = l[1:]
+	}
+
+	return value, nil
+}
+
+func (q *memoryQueue) Remove(ctx context.Context, topic string, value string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	l := q.topics[topic]
+	r := make([]string, 0, len(l))
+	for _, v := range l {
+		if v != value {
+			r = append(r, v)
+		}
+	}
+
+	if len(r) == 0 {
+		delete(q.topics, topic)
+	} else {
+		q.topics[topic] = r
+	}
+
+	return int64(len(l) - len(r)), nil
+}
+
+func (q *memoryQueue) Close() error {
+	return nil
+}
+
+func (q *memoryQueue) Publish(ctx context.Context, channel string, message string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for s := range q.subscriptions[channel] {
+		// 订阅者来不及接收时丢弃消息，避免阻塞发布者。
+		select {
+		case s.ch <- message:
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (q *memoryQueue) Subscribe(ctx context.Context, channel string) (Subscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	s := &memorySubscription{q: q, channel: channel, ch: make(chan string, subscriptionBufferSize)}
+	if q.subscriptions[channel] == nil {
+		q.subscriptions[channel] = make(map[*memorySubscription]struct{})
+	}
+	q.subscriptions[channel][s] = struct{}{}
+
+	return s, nil
+}
+
+// 基于进程内存的订阅。
+type memorySubscription struct {
+	q       *memoryQueue
+	channel string
+	ch      chan string
+}
+
+func (s *memorySubscription) Channel() <-chan string {
+	return s.ch
+}
+
+func (s *memorySubscription) Close() error {
+	s.q.mu.Lock()
+	defer s.q.mu.Unlock()
+
+	if subs, ok := s.q.subscriptions[s.channel]; ok {
+		if _, ok := subs[s]; ok {
+			delete(subs, s)
+			close(s.ch)
+		}
+		if len(subs) == 0 {
+			delete(s.q.subscriptions, s.channel)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryQueueFifo(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+
+	for i, v := range []string{"a", "b", "c"} {
+		if n, err := q.Push(ctx, "t", v); err != nil || n != int64(i+1) {
+			t.Fatalf("unexpected push result: %d, %v", n, err)
+		}
+	}
+	if n, err := q.Length(ctx, "t"); err != nil || n != 3 {
+		t.Fatalf("expected length 3 but got %d, %v", n, err)
+	}
+	if n, _ := q.Length(ctx, "other"); n != 0 {
+		t.Errorf("topics should be independent, but got length %d", n)
+	}
+
+	for _, want := range []string{"a", "b", "c"} {
+		if v, err := q.Pop(ctx, "t"); err != nil || v != want {
+			t.Fatalf("expected %s but got %s, %v", want, v, err)
+		}
+	}
+	if _, err := q.Pop(ctx, "t"); !errors.Is(err, Nil) {
+		t.Errorf("expected Nil for empty queue but got %v", err)
+	}
+}
+
+func TestMemoryQueueRemove(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+
+	for _, v := range []string{"a", "b", "a", "c"} {
+		q.Push(ctx, "t", v)
+	}
+
+	if n, err := q.Remove(ctx, "t", "a"); err != nil || n != 2 {
+		t.Fatalf("expected 2 removed but got %d, %v", n, err)
+	}
+	if n, err := q.Remove(ctx, "t", "x"); err != nil || n != 0 {
+		t.Fatalf("expected nothing removed but got %d, %v", n, err)
+	}
+
+	for _, want := range []string{"b", "c"} {
+		if v, err := q.Pop(ctx, "t"); err != nil || v != want {
+			t.Fatalf("expected %s but got %s, %v", want, v, err)
+		}
+	}
+	if n, _ := q.Length(ctx, "t"); n != 0 {
+		t.Errorf("expected empty queue but got length %d", n)
+	}
+}
+
+func TestMemoryQueuePublishAndSubscribe(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+
+	// 没有订阅者时消息被丢弃。
+	if err := q.Publish(ctx, "ch", "lost"); err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := q.Subscribe(ctx, "ch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Publish(ctx, "ch", "m1"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case m := <-sub.Channel():
+		if m != "m1" {
+			t.Errorf("expected m1 but got %s", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+	}
+
+	sub.Close()
+	if _, ok := <-sub.Channel(); ok {
+		t.Error("channel should be closed after subscription closed")
+	}
+	if err := q.Publish(ctx, "ch", "m2"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemoryQueueCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	q := NewMemoryQueue()
+	if _, err := q.Push(ctx, "t", "a"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context canceled but got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+// 该模块实现了基于Redis的消息队列。
+// @Author: Haart
+// @Created: 2026-10-18
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 基于Redis的队列，每个主题保存为一个List，从左侧入队，从右侧出队。
+type redisQueue struct {
+	client *redis.Client
+}
+
+// 创建Redis队列。
+// host Redis主机。
+// port Redis端口号。
+// password Redis口令。
+// db Redis队列使用的数据库。
+func NewRedisQueue(host string, port int, password string, db int) (Queue, error) {
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{
+		Addr:     host + ":" + strconv.Itoa(port),
+		Password: password,
+		DB:       db,
+	})
+	if client == nil {
+		return nil, fmt.Errorf("cannot create redis client")
+	}
+	if _, err := client.Ping(ctx).Result(); err != nil {
+		client.Close()
+		return nil, err
+	} else {
+		return &redisQueue{client: client}, nil
+	}
+}
+
+func (q *redisQueue) Length(ctx context.Context, topic string) (int64, error) {
+	return q.client.LLen(ctx, topic).Result()
+}
+
+func (q *redisQueue) Push(ctx context.Context, topic string, value string) (int64, error) {
+	return q.client.LPush(ctx, topic, value).Result()
+}
+
+func (q *redisQueue) Pop(ctx context.Context, topic string) (string, error) {
+	return q.client.RPop(ctx, topic).Result()
+
+	// if v, err := q.client.BRPop(ctx, 1*time.Second, topic).Result(); err != nil {
+	// 	return "", err
+	// } else if len(v) < 2 {
+	// 	return "", redis.Nil
+	// } else {
+	// 	return v[1], nil
+	// }
+}
+
+func (q *redisQueue) Remove(ctx context.Context, topic string, value string) (int64, error) {
+	return q.client.LRem(ctx, topic, 0, value).Result()
+}
+
+func (q *redisQueue) Close() error {
+	return q.client.Close()
+}
+
+func (q *redisQueue) Publish(ctx context.Context, channel string, message string) error {
+	return q.client.Publish(ctx, channel, message).Err()
+}
+
+func (q *redisQueue) Subscribe(ctx context.Context, channel string) (Subscription, error) {
+	ps := q.client.Subscribe(ctx, channel)
+
+	// 等待订阅确认，确保返回之后发布的消息都能收到。
+	if _, err := ps.Receive(ctx); err != nil {
+		ps.Close()
+		return nil, err
+	}
+
+	s := &redisSubscription{ps: ps, ch: make(chan string, subscriptionBufferSize)}
+	go func() {
+		defer close(s.ch)
+		for m := range ps.Channel() {
+			// 订阅者来不及接收时丢弃消息，避免订阅关闭之后阻塞。
+			select {
+			case s.ch <- m.Payload:
+			default:
+			}
+		}
+	}()
+
+	return s, nil
+}
+
+// 基于Redis的订阅。
+type redisSubscription struct {
+	ps *redis.PubSub
+	ch chan string
+}
+
+func (s *redisSubscription) Channel() <-chan string {
+	return s.ch
+}
+
+func (s *redisSubscription) Close() error {
+	return s.ps.Close()
+}
@@ -0,0 +1,246 @@
+// 该模块实现了守护进程运行期间重新加载配置。
+// @Author: Haart
+// @Created: 2026-10-18
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	_alert "com.cne/ai-tracking-monitor/alert"
+	_cache "com.cne/ai-tracking-monitor/cache"
+	_db "com.cne/ai-tracking-monitor/db"
+	_health "com.cne/ai-tracking-monitor/health"
+	_queue "com.cne/ai-tracking-monitor/queue"
+	_rpcclient "com.cne/ai-tracking-monitor/rpcclient"
+	_scheduler "com.cne/ai-tracking-monitor/scheduler"
+	_utils "com.cne/ai-tracking-monitor/utils"
+)
+
+var (
+	configFilePath string                // 配置文件的绝对路径，重新加载配置时使用。
+	checkScheduler *_scheduler.Scheduler // 守护进程使用的调度器。
+
+	configLock sync.RWMutex // 保护配置以及重新加载配置时被替换的全局对象。
+	reloadLock sync.Mutex   // 避免同时重新加载配置，以及重新加载配置时退出。
+	connLock   sync.RWMutex // 重新加载配置时替换数据库和Redis连接期间暂停HTTP接口。
+)
+
+// 获取当前使用的配置。
+func currentConfiguration() *Configuration {
+	configLock.RLock()
+	defer configLock.RUnlock()
+
+	return configuration
+}
+
+// 获取当前使用的健康评估策略。
+func currentHealthPolicies() *_health.Resolver {
+	configLock.RLock()
+	defer configLock.RUnlock()
+
+	return healthPolicies
+}
+
+// 包装使用数据库或者Redis连接的HTTP接口，替换连接期间暂停处理，避免使用已经关闭的连接。
+// f 被包装的HTTP接口。
+func guardConnections(f func(*http.Request) (interface{}, error)) func(*http.Request) (interface{}, error) {
+	return func(r *http.Request) (interface{}, error) {
+		connLock.RLock()
+		defer connLock.RUnlock()
+
+		return f(r)
+	}
+}
+
+// 通过当前使用的告警分发器分发告警事件。
+// 分发期间不会替换告警分发器，保证被替换的告警分发器关闭之后不会再收到告警事件。
+// ev 告警事件。
+func dispatchAlert(ev *_alert.Event) {
+	configLock.RLock()
+	defer configLock.RUnlock()
+
+	alertDispatcher.Dispatch(ev)
+}
+
+// 重新加载配置文件。
+// 如果新的配置有错误，那么继续使用原来的配置，并记录错误的原因。
+// 否则替换轮询计划、健康评估策略和阈值、告警设置以及日志设置，如果数据库或者Redis的连接参数发生变化，那么重新创建对应的连接。
+// 替换连接之前等待正在执行的轮次结束，如果超过退出等待时间仍未结束，那么放弃新的配置。替换连接期间暂停处理HTTP请求。
+// 各个任务沿用原来的执行计划，不会因为重新加载配置而提前或者推迟执行。
+// 爬虫的健康状态、监控结果的计数、告警的发送记录和静默规则都被保留。
+func reloadConfig() error {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	if checkScheduler == nil {
+		return fmt.Errorf("not running as daemon")
+	}
+
+	if err := doReloadConfig(checkScheduler); err != nil {
+		log.Printf("[ERROR] Cannot reload configuration from %s, keep the old one: %s\n", configFilePath, err)
+		return err
+	}
+
+	log.Printf("[INFO] Configuration reloaded from %s\n", configFilePath)
+	return nil
+}
+
+// POST /api/reload
+// 重新加载配置文件，如果新的配置有错误则返回错误的原因，并继续使用原来的配置。
+func apiReload(r *http.Request) (interface{}, error) {
+	if r.Method != http.MethodPost {
+		return nil, newApiError(http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+	}
+
+	log.Printf("[INFO] Reload configuration requested from %s\n", _utils.GetRemoteAddr(r))
+	if err := reloadConfig(); err != nil {
+		return nil, newApiError(http.StatusBadRequest, "cannot reload configuration: %s", err)
+	}
+
+	return map[string]interface{}{
+		"configFile": configFilePath,
+		"reloaded":   true,
+	}, nil
+}
+
+func doReloadConfig(s *_scheduler.Scheduler) error {
+	c, cc, err := readConfig(configFilePath)
+	if err != nil {
+		return err
+	}
+
+	old := currentConfiguration()
+	if c.Http != old.Http {
+		log.Printf("[WARN] HTTP configuration is changed, restart to apply it\n")
+	}
+
+	// 先创建新的连接，失败时继续使用原来的配置。
+	var newStore _db.Store
+	if c.DB != old.DB {
+		if st, err := _db.Open(c.DB.Driver, c.DB.DSN); err != nil {
+			cc.alertDispatcher.Close()
+			return fmt.Errorf("cannot open database: %w", err)
+		} else if err := checkSchemaVersion(st); err != nil {
+			st.Close()
+			cc.alertDispatcher.Close()
+			return err
+		} else {
+			newStore = st
+		}
+	}
+
+	var newCache _cache.Cache
+	var newQueue _queue.Queue
+	if c.Redis != old.Redis {
+		if nc, nq, err := newCacheAndQueue(&c.Redis); err != nil {
+			if newStore != nil {
+				newStore.Close()
+			}
+			cc.alertDispatcher.Close()
+			return fmt.Errorf("cannot connect to redis: %w", err)
+		} else {
+			newCache, newQueue = nc, nq
+		}
+	}
+
+	// 放弃新的配置时关闭已经创建的连接。
+	discard := func() {
+		if newStore != nil {
+			newStore.Close()
+		}
+		if newCache != nil {
+			newCache.Close()
+			newQueue.Close()
+		}
+		cc.alertDispatcher.Close()
+	}
+
+	// 暂停调度，替换任务之后恢复。
+	s.Stop()
+	defer s.Start()
+
+	if newStore != nil || newCache != nil {
+		// 等待正在执行的轮次结束之后再替换连接，如果超时则继续使用原来的配置，避免正在执行的轮次使用已经关闭的连接。
+		gracePeriod := old.Schedule.GracePeriod.Duration()
+		ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+		err := s.Wait(ctx)
+		cancel()
+		if err != nil {
+			discard()
+			return fmt.Errorf("running rounds are not finished in %s, connections are not replaced", gracePeriod)
+		}
+
+		healthUpdates.Wait()
+	}
+
+	replaceConnections(c, newStore, newCache, newQueue)
+
+	// 有状态的对象只替换配置，保留其中的状态。
+	healthMachine.Adopt(cc.healthMachine)
+	healthCounter.SetWindow(cc.healthPolicies.MaxWindow())
+	alertSuppressor.Adopt(cc.alertSuppressor)
+
+	configLock.Lock()
+	oldDispatcher := alertDispatcher
+	configuration = c
+	healthPolicies = cc.healthPolicies
+	alertDispatcher = cc.alertDispatcher
+	configLock.Unlock()
+
+	// 发送旧的告警分发器中尚未发送的汇总通知，并等待投递结束。
+	oldDispatcher.Close()
+
+	applyLogConfiguration(&c.Log)
+
+	if jobs, err := newJobs(c); err != nil {
+		// 配置已经检查过，不应当发生。
+		log.Printf("[ERROR] Cannot create jobs, keep the old ones: %s\n", err)
+	} else {
+		s.Reschedule(jobs)
+	}
+
+	return nil
+}
+
+// 替换数据库和Redis连接，并关闭原来的连接。
+// 替换期间暂停处理HTTP请求，并等待正在处理的请求结束。
+// c 新的配置。
+// newStore 新的存储，如果为nil则不替换。
+// newCache 新的缓存，如果为nil则不替换缓存和队列。
+// newQueue 新的队列。
+func replaceConnections(c *Configuration, newStore _db.Store, newCache _cache.Cache, newQueue _queue.Queue) {
+	if newStore == nil && newCache == nil {
+		return
+	}
+
+	connLock.Lock()
+	defer connLock.Unlock()
+
+	if newStore != nil {
+		if oldStore := _db.SetDefault(newStore); oldStore != nil {
+			if err := oldStore.Close(); err != nil {
+				log.Printf("[WARN] Cannot close old database: %s\n", err)
+			}
+		}
+		// 计数器中的监控结果来自原来的数据库，需要从新的数据库重新加载。
+		seedHealthCounter(healthCounter, time.Now())
+		log.Printf("[INFO] Database is reconnected (%s)\n", c.DB.Driver)
+	}
+
+	if newCache != nil {
+		oldCache, oldQueue := _cache.SetDefault(newCache), _queue.SetDefault(newQueue)
+		_rpcclient.InitClient(newCache, newQueue)
+		if err := oldCache.Close(); err != nil {
+			log.Printf("[WARN] Cannot close old cache: %s\n", err)
+		}
+		if err := oldQueue.Close(); err != nil {
+			log.Printf("[WARN] Cannot close old queue: %s\n", err)
+		}
+		log.Printf("[INFO] Redis is reconnected (%s)\n", c.Redis.Driver)
+	}
+}
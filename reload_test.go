@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_db "com.cne/ai-tracking-monitor/db"
+	_scheduler "com.cne/ai-tracking-monitor/scheduler"
+)
+
+// 写入使用指定内存数据库的配置文件。
+// configFile 配置文件路径。
+// name 内存数据库的名字。
+func writeTestReloadConfig(t *testing.T, configFile, name string) {
+	t.Helper()
+
+	config := fmt.Sprintf(`{
+	"DB": {"Driver": "sqlite", "DSN": "file:%s?mode=memory&cache=shared"},
+	"Redis": {"Driver": "memory"},
+	"Schedule": {"Interval": "1h", "InitialDelay": "1h", "GracePeriod": "100ms"}
+}`, name)
+	if err := ioutil.WriteFile(configFile, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 按照配置文件初始化配置、数据库、缓存和队列，并创建调度器。
+// job 调度器中的任务，如果为nil则不添加任务。
+func initTestReload(t *testing.T, name string, job *_scheduler.Job) (string, *_scheduler.Scheduler) {
+	t.Helper()
+
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeTestReloadConfig(t, configFile, name)
+	if err := loadConfig(configFile); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { alertDispatcher.Close() })
+
+	if err := _db.InitDB(configuration.DB.Driver, configuration.DB.DSN); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _db.Close() })
+	seedHealthCounter(healthCounter, time.Now())
+
+	if err := initCacheAndQueue(&configuration.Redis); err != nil {
+		t.Fatal(err)
+	}
+
+	s := _scheduler.NewScheduler()
+	if job != nil {
+		s.Add(job)
+	}
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	return configFile, s
+}
+
+func TestReloadReplacesDatabase(t *testing.T) {
+	configFile, s := initTestReload(t, t.Name()+"_old", nil)
+	oldStore := _db.Default()
+
+	writeTestReloadConfig(t, configFile, t.Name()+"_new")
+	if err := doReloadConfig(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if _db.Default() == oldStore {
+		t.Error("database should be replaced")
+	}
+	if currentConfiguration().DB.DSN != fmt.Sprintf("file:%s_new?mode=memory&cache=shared", t.Name()) {
+		t.Errorf("configuration should be replaced, but got %s", currentConfiguration().DB.DSN)
+	}
+	if current, latest, err := _db.QuerySchemaVersion(); err != nil || current != latest {
+		t.Errorf("new database should be migrated, but got %d/%d, %v", current, latest, err)
+	}
+}
+
+func TestReloadKeepsConnectionsWhenRoundsAreRunning(t *testing.T) {
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	trigger, _ := _scheduler.NewTrigger(time.Hour, "")
+	job := &_scheduler.Job{
+		Name:         "blocking",
+		Trigger:      trigger,
+		InitialDelay: time.Millisecond,
+		Run: func() {
+			started <- struct{}{}
+			<-release
+		},
+	}
+
+	configFile, s := initTestReload(t, t.Name()+"_old", job)
+	defer close(release)
+	oldStore, oldConfiguration := _db.Default(), currentConfiguration()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job is not started")
+	}
+
+	writeTestReloadConfig(t, configFile, t.Name()+"_new")
+	if err := doReloadConfig(s); err == nil {
+		t.Fatal("reload should fail while rounds are running")
+	}
+
+	if _db.Default() != oldStore {
+		t.Error("database should not be replaced")
+	}
+	if currentConfiguration() != oldConfiguration {
+		t.Error("configuration should not be replaced")
+	}
+	if _, _, err := _db.QuerySchemaVersion(); err != nil {
+		t.Errorf("old database should still be usable, but got %v", err)
+	}
+
+	// 恢复调度之后沿用原来的执行计划，不会立即再次执行。
+	select {
+	case <-started:
+		t.Error("job should not be run again after reload")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestGuardConnectionsPausesDuringReplacement(t *testing.T) {
+	f := guardConnections(func(r *http.Request) (interface{}, error) { return "ok", nil })
+
+	connLock.Lock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f(httptest.NewRequest(http.MethodGet, "/api/crawlers", nil))
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("request should wait for replacement")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	connLock.Unlock()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request should continue after replacement")
+	}
+}
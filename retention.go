@@ -0,0 +1,295 @@
+// 该模块实现了监控日志的汇总和清理。
+// @Author: Haart
+// @Created: 2026-10-18
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	_db "com.cne/ai-tracking-monitor/db"
+	_rpcclient "com.cne/ai-tracking-monitor/rpcclient"
+	_scheduler "com.cne/ai-tracking-monitor/scheduler"
+	_utils "com.cne/ai-tracking-monitor/utils"
+)
+
+const (
+	// 汇总的延迟。监控日志的时间是查询代理返回的时间，但是在整轮拉取结束之后才保存，最多晚于拉取的超时时间，
+	// 所以只汇总结束时间早于此延迟的时间段，避免汇总之后才写入的监控日志被遗漏。
+	rollupDelay time.Duration = _rpcclient.DefaultPullTimeout + time.Minute
+)
+
+var (
+	retentionCoordinator *_scheduler.Coordinator // 汇总和清理任务的轮次协调器。
+)
+
+// 检查监控日志保留配置是否正确。
+// rc 监控日志保留配置。
+func verifyRetentionConfiguration(rc *RetentionConfiguration) error {
+	if _, err := _scheduler.NewTrigger(rc.Interval.Duration(), rc.Cron); err != nil {
+		return fmt.Errorf("%s: illegal schedule of retention: %w", triggerConfigPath("Retention", rc.Cron), err)
+	}
+	if rc.RawDays < 0 {
+		return fmt.Errorf("Retention.RawDays: days of retention should not be negative")
+	}
+	if rc.HourlyDays < 0 {
+		return fmt.Errorf("Retention.HourlyDays: days of retention should not be negative")
+	}
+	if rc.DailyDays < 0 {
+		return fmt.Errorf("Retention.DailyDays: days of retention should not be negative")
+	}
+	// 按天汇总需要读取前一整天的原始监控日志。
+	if rc.RawDays == 1 {
+		return fmt.Errorf("Retention.RawDays: raw days of retention should be 0 or at least 2")
+	}
+	if rc.BatchSize <= 0 {
+		return fmt.Errorf("Retention.BatchSize: batch size of retention should be positive")
+	}
+
+	return nil
+}
+
+// 创建执行汇总和清理的任务。
+// rc 监控日志保留配置。
+// 返回新创建的任务。
+func newRetentionJob(rc *RetentionConfiguration) (*_scheduler.Job, error) {
+	trigger, err := _scheduler.NewTrigger(rc.Interval.Duration(), rc.Cron)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重新加载配置时沿用原来的轮次协调器。
+	if retentionCoordinator == nil {
+		retentionCoordinator = _scheduler.NewCoordinator("retention", _scheduler.PolicySkip)
+	}
+	coordinator := retentionCoordinator
+	return &_scheduler.Job{
+		Name:    "retention",
+		Trigger: trigger,
+		Run: func() {
+			defer _utils.RecoverPanic()
+
+			coordinator.Run(func(ctx context.Context) {
+				doRetention(ctx, rc, time.Now())
+			})
+		},
+	}, nil
+}
+
+// 解析汇总的周期。
+// s 待解析的字符串，可以是`hour`或者`day`，不区分大小写，如果为空则表示`hour`。
+func parseStatPeriod(s string) (string, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" || s == _db.StatPeriodHour {
+		return _db.StatPeriodHour, nil
+	} else if s == _db.StatPeriodDay {
+		return _db.StatPeriodDay, nil
+	} else {
+		return "", fmt.Errorf("unknown period: %s", s)
+	}
+}
+
+// 将小时的开始时间作为按小时汇总的时间段。
+func truncateHour(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+}
+
+// 将一天的开始时间作为按天汇总的时间段。
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func nextHour(t time.Time) time.Time {
+	return t.Add(time.Hour)
+}
+
+func nextDay(t time.Time) time.Time {
+	return t.AddDate(0, 0, 1)
+}
+
+// 执行一次汇总和清理。
+// 先将已经结束超过`rollupDelay`的小时和天汇总，然后分批删除过期的、并且已经汇总的原始监控日志，最后删除过期的汇总。
+// ctx 上下文，如果被取消则在下一个检查点退出。
+// rc 监控日志保留配置。
+// now 当前时间。
+func doRetention(ctx context.Context, rc *RetentionConfiguration, now time.Time) {
+	settled := now.Add(-rollupDelay)
+	hourWatermark := rollupHealthLogs(ctx, _db.StatPeriodHour, truncateHour(settled), truncateHour, nextHour)
+	dayWatermark := rollupHealthLogs(ctx, _db.StatPeriodDay, truncateDay(settled), truncateDay, nextDay)
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	// 只删除已经汇总的原始监控日志。
+	if rc.RawDays > 0 {
+		cutoff := truncateHour(now.AddDate(0, 0, -rc.RawDays))
+		if hourWatermark.Before(cutoff) {
+			cutoff = hourWatermark
+		}
+		if dayWatermark.Before(cutoff) {
+			cutoff = dayWatermark
+		}
+
+		if c := pruneInBatches(ctx, rc.BatchSize, func(limit int) int64 { return _db.DeleteHealthLogsBefore(cutoff, limit) }); c > 0 {
+			log.Printf("[INFO] %d health log(s) before %s pruned\n", c, _utils.FormatTime(cutoff))
+		}
+	}
+
+	if rc.HourlyDays > 0 {
+		cutoff := truncateHour(now.AddDate(0, 0, -rc.HourlyDays))
+		if c := pruneInBatches(ctx, rc.BatchSize, func(limit int) int64 { return _db.DeleteHealthStatsBefore(_db.StatPeriodHour, cutoff, limit) }); c > 0 {
+			log.Printf("[INFO] %d hourly health stat(s) before %s pruned\n", c, _utils.FormatTime(cutoff))
+		}
+	}
+
+	if rc.DailyDays > 0 {
+		cutoff := truncateDay(now.AddDate(0, 0, -rc.DailyDays))
+		if c := pruneInBatches(ctx, rc.BatchSize, func(limit int) int64 { return _db.DeleteHealthStatsBefore(_db.StatPeriodDay, cutoff, limit) }); c > 0 {
+			log.Printf("[INFO] %d daily health stat(s) before %s pruned\n", c, _utils.FormatTime(cutoff))
+		}
+	}
+}
+
+// 将原始监控日志汇总到指定周期，从最后一个汇总之后的时间段开始，直到 `end` 为止。
+// ctx 上下文，如果被取消则在下一个检查点退出。
+// period 汇总的周期。
+// end 结束时间（不包含），即尚未结束或者结束不久的时间段的开始时间。
+// truncate 计算指定时间所在时间段的开始时间。
+// next 计算下一个时间段的开始时间。
+// 返回汇总的水位线，在此之前的原始监控日志都已经汇总。
+func rollupHealthLogs(ctx context.Context, period string, end time.Time, truncate, next func(time.Time) time.Time) time.Time {
+	start := _db.QueryLatestHealthStatTime(period)
+	if _utils.IsZeroTime(start) {
+		earliest := _db.QueryEarliestHealthLogTime()
+		if _utils.IsZeroTime(earliest) {
+			return end
+		}
+		start = truncate(earliest.In(end.Location()))
+	} else {
+		start = next(start.In(end.Location()))
+	}
+
+	count := 0
+	for ; start.Before(end); start = next(start) {
+		if ctx.Err() != nil {
+			break
+		}
+
+		now := time.Now()
+		stop := next(start)
+		for _, po := range aggregateHealthLogs(period, start, _db.QueryHealthLogTimings(start, stop)) {
+			_db.SaveHealthStat(po, now)
+			count++
+		}
+	}
+
+	if count > 0 {
+		log.Printf("[INFO] %d %s health stat(s) rolled up until %s\n", count, period, _utils.FormatTime(start))
+	}
+
+	return start
+}
+
+// 查询原始监控日志已经被清理的时间范围内的指定爬虫的监控汇总，按照开始时间倒序排列。
+// 原始监控日志之前的时间使用按小时的汇总，按小时的汇总也已经被清理的那些天使用按天的汇总。
+// crawlerId 爬虫ID。
+// startTime 开始时间。
+// limit 最多返回的条数。
+func queryHealthStatsBeforeRawLogs(crawlerId int64, startTime time.Time, limit int) []*_db.CrawlerHealthStatPo {
+	result := make([]*_db.CrawlerHealthStatPo, 0)
+
+	// 原始监控日志按整点清理，所以最早的原始监控日志所在的小时仍然使用原始监控日志。
+	rawCutoff := truncateHour(time.Now())
+	if earliest := _db.QueryEarliestHealthLogTime(); !_utils.IsZeroTime(earliest) {
+		rawCutoff = truncateHour(earliest.In(time.Local))
+	}
+	if limit <= 0 || !startTime.Before(rawCutoff) {
+		return result
+	}
+
+	// 按小时的汇总没有覆盖的那些天使用按天的汇总，按小时的汇总不完整的那一天也使用按天的汇总。
+	hourlyCutoff := rawCutoff
+	if earliest := _db.QueryEarliestHealthStatTime(_db.StatPeriodHour); !_utils.IsZeroTime(earliest) {
+		earliest = earliest.In(time.Local)
+		if day := truncateDay(earliest); day.Equal(earliest) {
+			hourlyCutoff = day
+		} else {
+			hourlyCutoff = nextDay(day)
+		}
+		if hourlyCutoff.After(rawCutoff) {
+			hourlyCutoff = rawCutoff
+		}
+	}
+
+	if hourlyStart := truncateHour(startTime); hourlyStart.Before(rawCutoff) {
+		if hourlyStart.Before(hourlyCutoff) {
+			hourlyStart = hourlyCutoff
+		}
+		result = append(result, _db.QueryHealthStatsByCrawler(crawlerId, _db.StatPeriodHour, hourlyStart, rawCutoff, limit)...)
+	}
+
+	if len(result) < limit && startTime.Before(hourlyCutoff) {
+		result = append(result, _db.QueryHealthStatsByCrawler(crawlerId, _db.StatPeriodDay, truncateDay(startTime), hourlyCutoff, limit-len(result))...)
+	}
+
+	return result
+}
+
+// 按照爬虫汇总一个时间段内的监控日志。
+// period 汇总的周期。
+// startTime 时间段的开始时间。
+// recs 时间段内的监控日志。
+// 返回每个爬虫的汇总。
+func aggregateHealthLogs(period string, startTime time.Time, recs []*_db.CrawlerHealthTimingRec) []*_db.CrawlerHealthStatPo {
+	stats := make(map[int64]*_db.CrawlerHealthStatPo)
+	timings := make(map[int64][]int)
+	for _, rec := range recs {
+		po := stats[rec.CrawlerId]
+		if po == nil {
+			po = &_db.CrawlerHealthStatPo{CrawlerId: rec.CrawlerId, Period: period, StartTime: startTime}
+			stats[rec.CrawlerId] = po
+		}
+
+		if rec.ResultStatus == 0 {
+			po.CountOfOk++
+		} else {
+			po.CountOfError++
+		}
+		timings[rec.CrawlerId] = append(timings[rec.CrawlerId], rec.Timing)
+	}
+
+	result := make([]*_db.CrawlerHealthStatPo, 0, len(stats))
+	for crawlerId, po := range stats {
+		t := timings[crawlerId]
+		sort.Ints(t)
+		po.TimingP50 = _utils.Percentile(t, .5)
+		po.TimingP95 = _utils.Percentile(t, .95)
+
+		result = append(result, po)
+	}
+
+	return result
+}
+
+// 分批删除，直到某一批删除的条数少于批大小。
+// ctx 上下文，如果被取消则在下一批之前退出。
+// batchSize 每批删除的条数。
+// f 执行一批删除，返回实际删除的条数。
+// 返回删除的总条数。
+func pruneInBatches(ctx context.Context, batchSize int, f func(limit int) int64) int64 {
+	var total int64
+	for ctx.Err() == nil {
+		c := f(batchSize)
+		total += c
+		if c < int64(batchSize) {
+			break
+		}
+	}
+
+	return total
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_db "com.cne/ai-tracking-monitor/db"
+)
+
+func initTestDB(t *testing.T) {
+	t.Helper()
+
+	if err := _db.InitDB(_db.DriverSQLite, ":memory:"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _db.Close() })
+}
+
+func TestRollupWaitsForLateHealthLogs(t *testing.T) {
+	initTestDB(t)
+
+	rc := &RetentionConfiguration{BatchSize: 100}
+	hour := time.Date(2026, 10, 18, 9, 0, 0, 0, time.Local)
+
+	_db.SaveHealthLog(1, "JD0001", 100, 0, hour.Add(10*time.Minute), "", "")
+
+	// 小时刚刚结束，监控日志可能尚未全部写入，所以不汇总。
+	doRetention(context.Background(), rc, nextHour(hour).Add(10*time.Second))
+	if stats := _db.QueryHealthStatsByCrawler(1, _db.StatPeriodHour, hour, nextHour(hour), 10); len(stats) != 0 {
+		t.Fatalf("hour should not be rolled up before delay, but got %d stat(s)", len(stats))
+	}
+
+	// 拉取超时之后才写入的监控日志，时间仍然位于上一个小时。
+	_db.SaveHealthLog(1, "JD0001", 300, 1, nextHour(hour).Add(-time.Second), "", "")
+
+	doRetention(context.Background(), rc, nextHour(hour).Add(rollupDelay))
+	stats := _db.QueryHealthStatsByCrawler(1, _db.StatPeriodHour, hour, nextHour(hour), 10)
+	if len(stats) != 1 {
+		t.Fatalf("expected 1 hourly stat but got %d", len(stats))
+	}
+	if stats[0].CountOfOk != 1 || stats[0].CountOfError != 1 {
+		t.Errorf("expected 1 ok and 1 error but got %d and %d", stats[0].CountOfOk, stats[0].CountOfError)
+	}
+}
+
+func TestHistoryFallsBackToStats(t *testing.T) {
+	initTestDB(t)
+
+	now := time.Now()
+	rawStart := truncateHour(now.Add(-2 * time.Hour))
+	_db.SaveHealthLog(1, "JD0001", 100, 0, rawStart.Add(time.Minute), "", "")
+
+	// 按小时的汇总从三天前的中午开始，那一天使用按天的汇总。
+	hourlyStart := truncateDay(now.AddDate(0, 0, -3)).Add(12 * time.Hour)
+	for st := hourlyStart; st.Before(rawStart); st = nextHour(st) {
+		_db.SaveHealthStat(&_db.CrawlerHealthStatPo{CrawlerId: 1, Period: _db.StatPeriodHour, StartTime: st, CountOfOk: 1}, now)
+	}
+	for st := truncateDay(now.AddDate(0, 0, -5)); st.Before(truncateDay(now)); st = nextDay(st) {
+		_db.SaveHealthStat(&_db.CrawlerHealthStatPo{CrawlerId: 1, Period: _db.StatPeriodDay, StartTime: st, CountOfOk: 24}, now)
+	}
+
+	if stats := queryHealthStatsBeforeRawLogs(1, rawStart, 100); len(stats) != 0 {
+		t.Errorf("no stats expected within raw logs, but got %d", len(stats))
+	}
+
+	stats := queryHealthStatsBeforeRawLogs(1, rawStart.Add(-5*time.Hour), 100)
+	if len(stats) != 5 {
+		t.Fatalf("expected 5 hourly stats but got %d", len(stats))
+	}
+	for i, po := range stats {
+		if po.Period != _db.StatPeriodHour || !po.StartTime.Equal(rawStart.Add(-time.Duration(i+1)*time.Hour)) {
+			t.Errorf("unexpected stat #%d: %s %s", i, po.Period, po.StartTime)
+		}
+	}
+
+	stats = queryHealthStatsBeforeRawLogs(1, now.AddDate(0, 0, -5), 1000)
+	hourlyCutoff := nextDay(truncateDay(hourlyStart))
+	days := 0
+	for i, po := range stats {
+		if po.Period == _db.StatPeriodHour && po.StartTime.Before(hourlyCutoff) {
+			t.Errorf("stat #%d should be daily: %s", i, po.StartTime)
+		} else if po.Period == _db.StatPeriodDay {
+			if !po.StartTime.Before(hourlyCutoff) {
+				t.Errorf("stat #%d should be hourly: %s", i, po.StartTime)
+			}
+			days++
+		}
+		if i > 0 && !po.StartTime.Before(stats[i-1].StartTime) {
+			t.Errorf("stats should be in descending order: %s after %s", po.StartTime, stats[i-1].StartTime)
+		}
+	}
+	if days != 3 {
+		t.Errorf("expected 3 daily stats but got %d", days)
+	}
+
+	if stats := queryHealthStatsBeforeRawLogs(1, now.AddDate(0, 0, -5), 3); len(stats) != 3 {
+		t.Errorf("expected 3 stats because of limit but got %d", len(stats))
+	}
+}
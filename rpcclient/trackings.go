@@ -1,30 +1,75 @@
 package rpcclient
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	_agent "com.cne/ai-tracking-monitor/agent"
 	_cache "com.cne/ai-tracking-monitor/cache"
+	_metrics "com.cne/ai-tracking-monitor/metrics"
 	_queue "com.cne/ai-tracking-monitor/queue"
 	_types "com.cne/ai-tracking-monitor/types"
 	_utils "com.cne/ai-tracking-monitor/utils"
-	"github.com/go-redis/redis/v8"
 )
 
 const (
 	trackingSearchKeyPrefix string = "TRACKING_SEARCH" // 缓存中的查询记录的Key的前缀。
 	trackingQueueKey        string = "TRACKING_QUEUE"  // 查询记录队列Key。
+	trackingNotifyKeyPrefix string = "TRACKING_NOTIFY" // 查询完成通知频道的前缀。
 
 	maxSearchQueueSize int64 = 10000 // 查询队列的最大长度。
-	maxPullCount       int   = 70    // 轮询缓存的最大次数。
+
+	DefaultPullTimeout time.Duration = 20 * time.Second // 上下文没有截止时间时，拉取查询对象的超时时间。
+	finalPullTimeout   time.Duration = 5 * time.Second  // 超时之后最后一次拉取查询对象的超时时间。
+)
+
+var (
+	searchCache   _cache.Cache // 保存查询对象的缓存。
+	searchQueue   _queue.Queue // 查询队列。
+	clientLock    sync.RWMutex // 保护缓存和队列，重新加载配置时会被替换。
+	notifyChannel string       // 本进程的查询完成通知频道，查询代理向此频道发布已完成的查询对象的键。
 )
 
+func init() {
+	host, _ := os.Hostname()
+	notifyChannel = fmt.Sprintf("%s$%s-%d-%d", trackingNotifyKeyPrefix, host, os.Getpid(), time.Now().UnixNano())
+}
+
+// 初始化查询使用的缓存和队列。
+// c 保存查询对象的缓存。
+// q 查询队列。
+func InitClient(c _cache.Cache, q _queue.Queue) {
+	clientLock.Lock()
+	defer clientLock.Unlock()
+
+	searchCache = c
+	searchQueue = q
+}
+
+// 获取查询使用的缓存。
+func currentCache() _cache.Cache {
+	clientLock.RLock()
+	defer clientLock.RUnlock()
+
+	return searchCache
+}
+
+// 获取查询使用的队列。
+func currentQueue() _queue.Queue {
+	clientLock.RLock()
+	defer clientLock.RUnlock()
+
+	return searchQueue
+}
+
 // 表示针对一个运单的查询，同时包含查询条件和查询结果。
 type TrackingSearch struct {
 	Src            _types.TrackingResultSrc // 来源。可以是 DB或者API或者CRAWLER
@@ -62,16 +107,31 @@ func (s TrackingEvents) Len() int           { return len(s) }
 func (s TrackingEvents) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 func (s TrackingEvents) Less(i, j int) bool { return s[i].Date.After(s[j].Date) } // 时间上越晚的事件越小。
 
+// 获取指定优先级的查询队列的主题。
+// priority 优先级。
+func QueueTopic(priority _types.Priority) string {
+	return trackingQueueKey + "$" + priority.String()
+}
+
+// 获取指定优先级的查询队列的长度。
+// ctx 上下文。
+// priority 优先级。
+// 返回队列的当前长度。
+func QueueLength(ctx context.Context, priority _types.Priority) (int64, error) {
+	return currentQueue().Length(ctx, QueueTopic(priority))
+}
+
 // 将查询对象推送到缓存和队列。
+// ctx 上下文。
 // priority 优先级。
 // trackingSearchList 待推送到缓存和队列的查询对象。
-func PushTrackingSearchToQueue(priority _types.Priority, trackingSearchList []*TrackingSearch) ([]string, error) {
+func PushTrackingSearchToQueue(ctx context.Context, priority _types.Priority, trackingSearchList []*TrackingSearch) ([]string, error) {
 	keys := make([]string, 0)
 
-	queueTopic := trackingQueueKey + "$" + priority.String()
+	queueTopic := QueueTopic(priority)
 
 	// 检查查询队列是否已经超长。
-	if cl, err := _queue.Length(queueTopic); err != nil {
+	if cl, err := currentQueue().Length(ctx, queueTopic); err != nil {
 		return nil, err
 	} else {
 		if cl+int64(len(trackingSearchList)) > maxSearchQueueSize {
@@ -109,158 +169,292 @@ func PushTrackingSearchToQueue(priority _types.Priority, trackingSearchList []*T
 		key := trackingSearchKeyPrefix + "$" + ts.SeqNo
 
 		// 如果20秒内该查询对象尚未被查询代理执行则放弃。
-		if err := _cache.SetAndExpire(key, map[string]interface{}{"reqTime": _utils.AsString(ts.ReqTime), "carrierCode": ts.CarrierCode, "language": ts.Language.String(), "trackingNo": ts.TrackingNo, "clientAddr": ts.ClientAddr, "status": -1}, 60*time.Second); err != nil {
-			panic(err)
+		if err := currentCache().SetAndExpire(ctx, key, map[string]interface{}{"reqTime": _utils.AsString(ts.ReqTime), "carrierCode": ts.CarrierCode, "language": ts.Language.String(), "trackingNo": ts.TrackingNo, "clientAddr": ts.ClientAddr, "notifyChannel": notifyChannel, "status": -1}, 60*time.Second); err != nil {
+			// 放弃已经推送的查询对象，避免查询代理执行没有人拉取的查询。
+			discardTrackingSearches(priority, keys)
+			return nil, err
 		}
 
 		// 推送到队列。
-		_queue.Push(queueTopic, key)
 		keys = append(keys, key)
+		if _, err := currentQueue().Push(ctx, queueTopic, key); err != nil {
+			discardTrackingSearches(priority, keys)
+			return nil, err
+		}
 	}
 
 	return keys, nil
 }
 
 // 从缓存中拉取已完成的查询对象。
-// 此方法会阻塞，并不断轮询查询对象。直到所有的查询对象状态都变为已有结果或者超时。
+// 此方法会阻塞，直到所有的查询对象状态都变为已有结果，或者 `ctx` 超时或者被取消。
+// 超时的时候，尚未返回结果的查询对象也被返回，其返回码是超时；被取消的时候，返回已经拉取的查询对象和 `ctx.Err()`。
+// 超时、被取消或者拉取失败的时候，都从队列和缓存中删除尚未返回结果的查询对象；拉取失败时返回已经拉取的查询对象和错误。
+// 查询代理如果在写回结果之后向缓存中的`notifyChannel`频道发布查询对象的键，那么可以立即拉取该查询对象，否则依靠轮询拉取。
+// ctx 上下文，如果没有截止时间，那么最多等待`DefaultPullTimeout`。
 // priority 查询对象的优先级。
 // keys 查询对象的键集合。
 // 返回缓存中的查询对象。
-func PullTrackingSearchFromCache(priority _types.Priority, keys []string) ([]*TrackingSearch, error) {
+func PullTrackingSearchFromCache(ctx context.Context, priority _types.Priority, keys []string) ([]*TrackingSearch, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultPullTimeout)
+		defer cancel()
+	}
+
 	result := make([]*TrackingSearch, 0, len(keys))
 
-	// 全部查询成功或者重试次数太多则停止重试。
+	// 必须在首次轮询之前订阅，这样首次轮询之后完成的查询对象都会收到通知。
+	var notifications <-chan string
+	if sub, err := currentQueue().Subscribe(ctx, notifyChannel); err != nil {
+		log.Printf("[WARN] Cannot subscribe %s, fallback to polling: %s\n", notifyChannel, err)
+	} else {
+		defer sub.Close()
+		notifications = sub.Channel()
+	}
+
+	// 全部查询成功或者超时则停止重试。
 	c := 0
+	var pullErr error
+poll:
 	for {
-		// 收集已完成的响应。
+		// 轮询所有未完成的查询对象。
 		pc := 0
-		for _, key := range keys {
-			if os, err := _cache.Get(key, "status", "reqTime", "carrierCode", "language", "trackingNo", "clientAddr", "agentSrc", "agentErr", "agentResult", "agentName", "agentStartTime", "agentEndTime"); err != nil {
-				if errors.Is(err, redis.Nil) {
-					// 缓存已消失，说明查询超时。
-					fmt.Printf("!!!!!! %d ----> %s\n", c, key)
-					continue
-				} else {
-					return nil, fmt.Errorf("cannot get tracking-search(key=%s) from cache. cause=%w", key, err)
+		for i, key := range keys {
+			if ts, done, err := fetchTrackingSearch(ctx, priority, key, c, false); err != nil {
+				keys = append(keys[:pc], keys[i:]...)
+				if ctx.Err() == nil {
+					pullErr = err
 				}
-			} else {
-				// 查询代理执行状态，该值由查询代理调度程序写入，和数据库中的`status`字段无关。
-				status := _utils.AsInt(os[0], -1)
-				if status < 1 && c < maxPullCount {
-					// 如果返回码是-1或者0，说明查询代理尚未返回结果。
-					keys[pc] = key
-					pc++
+				break poll
+			} else if !done {
+				keys[pc] = key
+				pc++
+			} else if ts != nil {
+				result = append(result, ts)
+			}
+		}
+
+		// 删除这些已完成的响应。
+		keys = keys[:pc]
+
+		if len(keys) == 0 {
+			break
+		}
+
+		c++
+		interval := time.Millisecond * 300
+		if c <= 7 {
+			interval = time.Millisecond * 500
+		}
+
+		// 等待下一次轮询，期间立即拉取收到完成通知的查询对象。
+		timer := time.NewTimer(interval)
+	wait:
+		for len(keys) != 0 {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				break poll
+			case <-timer.C:
+				break wait
+			case key, ok := <-notifications:
+				if !ok {
+					// 订阅已断开，此后只依靠轮询。
+					notifications = nil
 					continue
 				}
 
-				_cache.Del(key)
-
-				reqTime := _utils.AsTime(os[1])
-				carrierCode := _utils.AsString(os[2])
-				language, _ := _types.ParseLangId(_utils.AsString(os[3]))
-				trackingNo := _utils.AsString(os[4])
-				clientAddr := _utils.AsString(os[5])
-				agentSrc := _types.TrackingResultSrc(_utils.AsInt(os[6], int(_types.SrcUnknown)))
-				agentErr := _utils.AsString(os[7])
-				agentRspJson := strings.TrimSpace(_utils.AsString(os[8]))
-				agentName := _utils.AsString(os[9])
-				agentStartTime := _utils.AsTime(os[10])
-				agentEndTime := _utils.AsTime(os[11])
-
-				trackingResult := _agent.TrackingResult{Code: _agent.AcTimeout}
-				agentCode := _agent.AcTimeout
-				message := ""
-				events := make([]*TrackingEvent, 0)
-				if agentRspJson == "" {
-					log.Printf("[WARN] Cannot parse empty crawler result json\n")
-				} else {
-					crawlerRspJsonBytes := []byte(agentRspJson)
-					if err := json.Unmarshal(crawlerRspJsonBytes, &trackingResult); err != nil {
-						// 首先尝试将查询代理返回的json反序列化为跟踪结果对象。
-						// 如果失败，那么尝试反序列化为批量跟踪结果对象。
-						// 如果仍然失败则报错。
-						// 如果反序列化的批量跟踪结果对象包含的运单记录超过1个，也报错。
-						crawlerRsp := _agent.ResponseWrapper{}
-						if err := json.Unmarshal(crawlerRspJsonBytes, &crawlerRsp); err != nil {
-							agentCode = _agent.AcParseFailed
-							log.Printf("[WARN] Cannot parse crawler result json: %v. cause=%s\n", _utils.AbbrText(agentRspJson, 255), err)
-						} else if len(crawlerRsp.Items) != 1 {
-							agentCode = _agent.AcOther
-							log.Printf("[WARN] Length of crawler result should be just 1, but %#v\n", crawlerRsp)
-						} else {
-							trackingResult = crawlerRsp.Items[0]
-							if v, err := strconv.Atoi(crawlerRsp.Code); err != nil {
-								agentCode = _agent.AcParseFailed
-							} else {
-								agentCode = _agent.AgCode(v)
-							}
-							message = crawlerRsp.Message
-						}
-					} else {
-						agentCode = trackingResult.Code
-						message = trackingResult.CMess
+				for i, k := range keys {
+					if k != key {
+						continue
 					}
 
-					// 将查询代理的事件列表映射为待匹配的事件。
-					for _, te := range trackingResult.TrackingEventList {
-						events = append(events, &TrackingEvent{
-							Date:    _utils.ParseTime(te.Date), // TODO: 此处是否应当使用ParseUTCTime。
-							Details: te.Details,
-							Place:   te.Place,
-							State:   0,
-						})
+					if ts, done, err := fetchTrackingSearch(ctx, priority, key, c, false); err != nil {
+						timer.Stop()
+						if ctx.Err() == nil {
+							pullErr = err
+						}
+						break poll
+					} else if done {
+						keys = append(keys[:i], keys[i+1:]...)
+						if ts != nil {
+							result = append(result, ts)
+						}
 					}
+					break
 				}
+			}
+		}
+		timer.Stop()
 
-				// 此处忽略trackingResult.CodeMg，该字段似乎已经弃用。
+		if len(keys) == 0 {
+			break
+		}
+	}
 
-				if agentErr == "" {
-					// 如果调用代理时没有出现错误，那么从代理的响应结果中获取错误信息。
-					agentErr = message
-				}
+	if pullErr != nil {
+		// 拉取失败，同样清理尚未完成的查询对象。
+		discardTrackingSearches(priority, keys)
+		return result, pullErr
+	}
 
-				trackingSearch := TrackingSearch{
-					SeqNo:          key[len(trackingSearchKeyPrefix)+1:],
-					ReqTime:        reqTime,
-					Src:            agentSrc,
-					CarrierCode:    carrierCode,
-					Language:       language,
-					TrackingNo:     trackingNo,
-					ClientAddr:     clientAddr,
-					AgentName:      agentName,
-					AgentStartTime: agentStartTime,
-					AgentEndTime:   agentEndTime,
-					Events:         events,
-					AgentCode:      agentCode,
-					Err:            agentErr,
-					AgentRawText:   agentRspJson,
-				}
+	if err := ctx.Err(); err == nil || len(keys) == 0 {
+		return result, nil
+	} else if !errors.Is(err, context.DeadlineExceeded) {
+		// 已经被取消，清理尚未完成的查询对象，避免查询代理继续执行以及缓存遗留。
+		discardTrackingSearches(priority, keys)
+		return result, err
+	}
 
-				result = append(result, &trackingSearch)
-			}
-		} // end of for-key
+	// 已经超时，最后拉取一次尚未返回结果的查询对象，这些查询对象的返回码是超时。
+	finalCtx, cancel := context.WithTimeout(context.Background(), finalPullTimeout)
+	defer cancel()
+	for _, key := range keys {
+		if ts, _, err := fetchTrackingSearch(finalCtx, priority, key, c, true); err != nil {
+			pullErr = err
+			break
+		} else if ts != nil {
+			result = append(result, ts)
+		}
+	}
 
-		// 删除这些已完成的响应。
-		keys = keys[:pc]
+	// 已经拉取的查询对象的缓存已被删除，但是尚未被查询代理取出的查询对象仍在队列中，需要和被取消时一样清理。
+	discardTrackingSearches(priority, keys)
 
-		if len(keys) == 0 || c >= maxPullCount {
-			break
+	return result, pullErr
+}
+
+// 从队列和缓存中删除查询对象。
+// 此方法使用独立的上下文，因为调用者的上下文通常已经被取消。
+// priority 查询对象的优先级。
+// keys 查询对象的键集合。
+func discardTrackingSearches(priority _types.Priority, keys []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), finalPullTimeout)
+	defer cancel()
+
+	queueTopic := QueueTopic(priority)
+	for _, key := range keys {
+		if _, err := currentQueue().Remove(ctx, queueTopic, key); err != nil {
+			log.Printf("[WARN] Cannot remove tracking-search(key=%s) from queue: %s\n", key, err)
+		}
+		if _, err := currentCache().Del(ctx, key); err != nil {
+			log.Printf("[WARN] Cannot delete tracking-search(key=%s) from cache: %s\n", key, err)
 		}
+	}
 
-		c++
-		// if c <= 1 {
-		// 	time.Sleep(time.Second * 4)
-		// } else if c <= 3 {
-		// 	time.Sleep(time.Second * 3)
-		// } else if c <= 5 {
-		// 	time.Sleep(time.Second * 2)
-		// } else if c <= 7 {
-		if c <= 7 {
-			time.Sleep(time.Millisecond * 500)
+	log.Printf("[INFO] %d outstanding tracking-search(es) discarded\n", len(keys))
+}
+
+// 从缓存中拉取一个查询对象。
+// ctx 上下文。
+// priority 查询对象的优先级。
+// key 查询对象的键。
+// c 已经轮询的次数，仅用于日志。
+// final 是否是最后一次拉取，如果是，那么即使查询代理尚未返回结果也会返回查询对象。
+// 返回查询对象，以及是否不再需要拉取该查询对象。如果缓存已消失，那么查询对象为nil。
+func fetchTrackingSearch(ctx context.Context, priority _types.Priority, key string, c int, final bool) (*TrackingSearch, bool, error) {
+	vs, err := currentCache().Get(ctx, key, "status", "reqTime", "carrierCode", "language", "trackingNo", "clientAddr", "agentSrc", "agentErr", "agentResult", "agentName", "agentStartTime", "agentEndTime")
+	if err != nil {
+		if errors.Is(err, _cache.Nil) {
+			// 缓存已消失，说明查询超时。
+			log.Printf("[WARN] Tracking-search(key=%s) vanished from cache after %d poll(s)\n", key, c)
+			_metrics.IncCacheVanished(priority.String())
+			return nil, true, nil
+		} else {
+			return nil, false, fmt.Errorf("cannot get tracking-search(key=%s) from cache. cause=%w", key, err)
+		}
+	}
+
+	// 查询代理执行状态，该值由查询代理调度程序写入，和数据库中的`status`字段无关。
+	status := _utils.AsInt(vs[0], -1)
+	if status < 1 && !final {
+		// 如果返回码是-1或者0，说明查询代理尚未返回结果。
+		return nil, false, nil
+	}
+
+	currentCache().Del(ctx, key)
+
+	reqTime := _utils.AsTime(vs[1])
+	carrierCode := _utils.AsString(vs[2])
+	language, _ := _types.ParseLangId(_utils.AsString(vs[3]))
+	trackingNo := _utils.AsString(vs[4])
+	clientAddr := _utils.AsString(vs[5])
+	agentSrc := _types.TrackingResultSrc(_utils.AsInt(vs[6], int(_types.SrcUnknown)))
+	agentErr := _utils.AsString(vs[7])
+	agentRspJson := strings.TrimSpace(_utils.AsString(vs[8]))
+	agentName := _utils.AsString(vs[9])
+	agentStartTime := _utils.AsTime(vs[10])
+	agentEndTime := _utils.AsTime(vs[11])
+
+	trackingResult := _agent.TrackingResult{Code: _agent.AcTimeout}
+	agentCode := _agent.AcTimeout
+	message := ""
+	events := make([]*TrackingEvent, 0)
+	if agentRspJson == "" {
+		log.Printf("[WARN] Cannot parse empty crawler result json\n")
+	} else {
+		crawlerRspJsonBytes := []byte(agentRspJson)
+		if err := json.Unmarshal(crawlerRspJsonBytes, &trackingResult); err != nil {
+			// 首先尝试将查询代理返回的json反序列化为跟踪结果对象。
+			// 如果失败，那么尝试反序列化为批量跟踪结果对象。
+			// 如果仍然失败则报错。
+			// 如果反序列化的批量跟踪结果对象包含的运单记录超过1个，也报错。
+			crawlerRsp := _agent.ResponseWrapper{}
+			if err := json.Unmarshal(crawlerRspJsonBytes, &crawlerRsp); err != nil {
+				agentCode = _agent.AcParseFailed
+				log.Printf("[WARN] Cannot parse crawler result json: %v. cause=%s\n", _utils.AbbrText(agentRspJson, 255), err)
+			} else if len(crawlerRsp.Items) != 1 {
+				agentCode = _agent.AcOther
+				log.Printf("[WARN] Length of crawler result should be just 1, but %#v\n", crawlerRsp)
+			} else {
+				trackingResult = crawlerRsp.Items[0]
+				if v, err := strconv.Atoi(crawlerRsp.Code); err != nil {
+					agentCode = _agent.AcParseFailed
+				} else {
+					agentCode = _agent.AgCode(v)
+				}
+				message = crawlerRsp.Message
+			}
 		} else {
-			time.Sleep(time.Millisecond * 300)
+			agentCode = trackingResult.Code
+			message = trackingResult.CMess
+		}
+
+		// 将查询代理的事件列表映射为待匹配的事件。
+		for _, te := range trackingResult.TrackingEventList {
+			events = append(events, &TrackingEvent{
+				Date:    _utils.ParseTime(te.Date), // TODO: 此处是否应当使用ParseUTCTime。
+				Details: te.Details,
+				Place:   te.Place,
+				State:   0,
+			})
 		}
 	}
 
-	return result, nil
+	// 此处忽略trackingResult.CodeMg，该字段似乎已经弃用。
+
+	if agentErr == "" {
+		// 如果调用代理时没有出现错误，那么从代理的响应结果中获取错误信息。
+		agentErr = message
+	}
+
+	trackingSearch := TrackingSearch{
+		SeqNo:          key[len(trackingSearchKeyPrefix)+1:],
+		ReqTime:        reqTime,
+		Src:            agentSrc,
+		CarrierCode:    carrierCode,
+		Language:       language,
+		TrackingNo:     trackingNo,
+		ClientAddr:     clientAddr,
+		AgentName:      agentName,
+		AgentStartTime: agentStartTime,
+		AgentEndTime:   agentEndTime,
+		Events:         events,
+		AgentCode:      agentCode,
+		Err:            agentErr,
+		AgentRawText:   agentRspJson,
+	}
+
+	return &trackingSearch, true, nil
 }
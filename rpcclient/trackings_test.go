@@ -0,0 +1,282 @@
+package rpcclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	_agent "com.cne/ai-tracking-monitor/agent"
+	_cache "com.cne/ai-tracking-monitor/cache"
+	_queue "com.cne/ai-tracking-monitor/queue"
+	_types "com.cne/ai-tracking-monitor/types"
+)
+
+func initTestClient(t *testing.T) (_cache.Cache, _queue.Queue) {
+	t.Helper()
+
+	c, q := _cache.NewMemoryCache(), _queue.NewMemoryQueue()
+	InitClient(c, q)
+
+	return c, q
+}
+
+func newTestSearches(trackingNos ...string) []*TrackingSearch {
+	result := make([]*TrackingSearch, 0, len(trackingNos))
+	for i, trackingNo := range trackingNos {
+		result = append(result, &TrackingSearch{
+			ReqTime:     time.Now(),
+			SeqNo:       "SEQ" + string(rune('0'+i)),
+			CarrierCode: "DHL",
+			Language:    _types.LangEN,
+			TrackingNo:  trackingNo,
+		})
+	}
+
+	return result
+}
+
+// 模拟查询代理取出所有的查询对象。
+// done 是否写回结果并发布完成通知，否则只标记为正在执行。
+func runTestAgent(t *testing.T, c _cache.Cache, q _queue.Queue, done bool) []string {
+
+	ctx := context.Background()
+	keys := make([]string, 0)
+	for {
+		key, err := q.Pop(ctx, QueueTopic(_types.PriorityHighest))
+		if errors.Is(err, _queue.Nil) {
+			return keys
+		} else if err != nil {
+			// 可能在其它协程中执行，所以不能调用Fatal。
+			t.Error(err)
+			return keys
+		}
+		keys = append(keys, key)
+
+		if !done {
+			c.Update(ctx, key, map[string]interface{}{"status": 0, "agentName": "test"})
+			continue
+		}
+
+		vs, _ := c.Get(ctx, key, "trackingNo", "notifyChannel")
+		result, _ := json.Marshal(&_agent.TrackingResult{
+			Code:       _agent.AcSuccess,
+			TrackingNo: vs[0].(string),
+			TrackingEventList: []_agent.TrackingEvent{
+				{Date: "2026-10-18 08:00:00", Place: "SHENZHEN", Details: "Departed from facility"},
+			},
+		})
+		c.Update(ctx, key, map[string]interface{}{"status": 1, "agentName": "test", "agentResult": string(result), "agentEndTime": time.Now()})
+		q.Publish(ctx, vs[1].(string), key)
+	}
+}
+
+func TestPushAndPull(t *testing.T) {
+	c, q := initTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	keys, err := PushTrackingSearchToQueue(ctx, _types.PriorityHighest, newTestSearches("JD0001", "", "JD0002"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 空单号被跳过。
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 keys but got %d", len(keys))
+	}
+	if n, _ := QueueLength(ctx, _types.PriorityHighest); n != 2 {
+		t.Fatalf("expected queue length 2 but got %d", n)
+	}
+	if vs, err := c.Get(ctx, keys[0], "status", "trackingNo"); err != nil || vs[0] != "-1" || vs[1] != "JD0001" {
+		t.Fatalf("unexpected cache: %#v, %v", vs, err)
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		runTestAgent(t, c, q, true)
+	}()
+
+	start := time.Now()
+	result, err := PullTrackingSearchFromCache(ctx, _types.PriorityHighest, append([]string{}, keys...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 results but got %d", len(result))
+	}
+	// 收到完成通知之后立即拉取，不需要等待轮询。
+	if d := time.Since(start); d > 450*time.Millisecond {
+		t.Errorf("pull should be woken up by notification, but took %s", d)
+	}
+
+	for _, ts := range result {
+		if ts.AgentCode != _agent.AcSuccess || ts.AgentName != "test" || len(ts.Events) != 1 || ts.Events[0].Place != "SHENZHEN" {
+			t.Errorf("unexpected result: %+v", ts)
+		}
+		if ts.TrackingNo != "JD0001" && ts.TrackingNo != "JD0002" {
+			t.Errorf("unexpected tracking no: %s", ts.TrackingNo)
+		}
+	}
+	for _, key := range keys {
+		if _, err := c.Get(ctx, key, "status"); !errors.Is(err, _cache.Nil) {
+			t.Errorf("cache of %s should be deleted after pulled, but got %v", key, err)
+		}
+	}
+}
+
+func TestPullVanishedKey(t *testing.T) {
+	c, _ := initTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	keys, err := PushTrackingSearchToQueue(ctx, _types.PriorityHighest, newTestSearches("JD0001"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Del(ctx, keys[0])
+
+	result, err := PullTrackingSearchFromCache(ctx, _types.PriorityHighest, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 0 {
+		t.Errorf("vanished key should be skipped, but got %d result(s)", len(result))
+	}
+}
+
+func TestPullTimeout(t *testing.T) {
+	c, q := initTestClient(t)
+
+	keys, err := PushTrackingSearchToQueue(context.Background(), _types.PriorityHighest, newTestSearches("JD0001"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 查询代理已经开始执行，但是永不返回。
+	runTestAgent(t, c, q, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	result, err := PullTrackingSearchFromCache(ctx, _types.PriorityHighest, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 {
+		t.Fatalf("expected 1 result but got %d", len(result))
+	}
+	if result[0].AgentCode != _agent.AcTimeout || result[0].AgentName != "test" || result[0].TrackingNo != "JD0001" {
+		t.Errorf("unexpected result: %+v", result[0])
+	}
+	if _, err := c.Get(context.Background(), keys[0], "status"); !errors.Is(err, _cache.Nil) {
+		t.Errorf("cache should be deleted after timeout, but got %v", err)
+	}
+}
+
+func TestPullCanceled(t *testing.T) {
+	c, q := initTestClient(t)
+
+	keys, err := PushTrackingSearchToQueue(context.Background(), _types.PriorityHighest, newTestSearches("JD0001", "JD0002"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	result, err := PullTrackingSearchFromCache(ctx, _types.PriorityHighest, append([]string{}, keys...))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled but got %v", err)
+	}
+	if len(result) != 0 {
+		t.Errorf("expected no result but got %d", len(result))
+	}
+
+	// 尚未完成的查询对象从队列和缓存中删除，查询代理不会再执行。
+	if n, _ := q.Length(context.Background(), QueueTopic(_types.PriorityHighest)); n != 0 {
+		t.Errorf("outstanding keys should be removed from queue, but got length %d", n)
+	}
+	for _, key := range keys {
+		if _, err := c.Get(context.Background(), key, "status"); !errors.Is(err, _cache.Nil) {
+			t.Errorf("cache of %s should be discarded, but got %v", key, err)
+		}
+	}
+}
+
+func TestPullTimeoutDiscardsOutstanding(t *testing.T) {
+	c, q := initTestClient(t)
+
+	// 查询代理没有取出查询对象。
+	keys, err := PushTrackingSearchToQueue(context.Background(), _types.PriorityHighest, newTestSearches("JD0001", "JD0002"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	result, err := PullTrackingSearchFromCache(ctx, _types.PriorityHighest, append([]string{}, keys...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 results but got %d", len(result))
+	}
+
+	// 超时的查询对象和被取消时一样从队列和缓存中删除，查询代理不会再执行。
+	if n, _ := q.Length(context.Background(), QueueTopic(_types.PriorityHighest)); n != 0 {
+		t.Errorf("timed-out keys should be removed from queue, but got length %d", n)
+	}
+	for _, key := range keys {
+		if _, err := c.Get(context.Background(), key, "status"); !errors.Is(err, _cache.Nil) {
+			t.Errorf("cache of %s should be discarded, but got %v", key, err)
+		}
+	}
+}
+
+// 最后一次拉取时读取指定的查询对象失败的缓存。
+type finalPullFailingCache struct {
+	_cache.Cache
+	failKey  string    // 读取失败的查询对象的键。
+	deadline time.Time // 调用者的截止时间，截止时间晚于此时间的上下文是最后一次拉取使用的上下文。
+}
+
+func (c *finalPullFailingCache) Get(ctx context.Context, key string, fields ...string) ([]interface{}, error) {
+	if deadline, ok := ctx.Deadline(); ok && deadline.After(c.deadline) && key == c.failKey {
+		return nil, errors.New("connection reset")
+	}
+
+	return c.Cache.Get(ctx, key, fields...)
+}
+
+func TestPullFinalFailureKeepsPartialResults(t *testing.T) {
+	c, q := initTestClient(t)
+
+	keys, err := PushTrackingSearchToQueue(context.Background(), _types.PriorityHighest, newTestSearches("JD0001", "JD0002"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	deadline, _ := ctx.Deadline()
+	InitClient(&finalPullFailingCache{Cache: c, failKey: keys[1], deadline: deadline}, q)
+
+	result, err := PullTrackingSearchFromCache(ctx, _types.PriorityHighest, append([]string{}, keys...))
+	if err == nil {
+		t.Fatal("expected error of final pull")
+	}
+	if len(result) != 1 || result[0].TrackingNo != "JD0001" {
+		t.Fatalf("expected partial result of JD0001 but got %+v", result)
+	}
+
+	if n, _ := q.Length(context.Background(), QueueTopic(_types.PriorityHighest)); n != 0 {
+		t.Errorf("outstanding keys should be removed from queue, but got length %d", n)
+	}
+	for _, key := range keys {
+		if _, err := c.Get(context.Background(), key, "status"); !errors.Is(err, _cache.Nil) {
+			t.Errorf("cache of %s should be discarded, but got %v", key, err)
+		}
+	}
+}
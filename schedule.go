@@ -0,0 +1,177 @@
+// 该模块实现了根据配置创建轮询计划。
+// @Author: Haart
+// @Created: 2026-10-18
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	_db "com.cne/ai-tracking-monitor/db"
+	_metrics "com.cne/ai-tracking-monitor/metrics"
+	_scheduler "com.cne/ai-tracking-monitor/scheduler"
+	_utils "com.cne/ai-tracking-monitor/utils"
+)
+
+var (
+	checkCoordinators []*_scheduler.Coordinator // 所有轮询计划的轮次协调器。
+)
+
+// 检查轮询计划配置是否正确。
+// sc 轮询计划配置。
+func verifyScheduleConfiguration(sc *ScheduleConfiguration) error {
+	if _, err := _scheduler.NewTrigger(sc.Interval.Duration(), sc.Cron); err != nil {
+		return fmt.Errorf("%s: illegal schedule: %w", triggerConfigPath("Schedule", sc.Cron), err)
+	}
+	if sc.InitialDelay < 0 {
+		return fmt.Errorf("Schedule.InitialDelay: initial delay of schedule should not be negative")
+	}
+	if sc.Jitter < 0 {
+		return fmt.Errorf("Schedule.Jitter: jitter of schedule should not be negative")
+	}
+	if sc.GracePeriod < 0 {
+		return fmt.Errorf("Schedule.GracePeriod: grace period of schedule should not be negative")
+	}
+
+	for i, so := range sc.Overrides {
+		path := fmt.Sprintf("Schedule.Overrides[%d]", i)
+		if len(so.CrawlerIds) == 0 && len(so.CarrierCodes) == 0 {
+			return fmt.Errorf("%s.CrawlerIds: schedule override should contains crawler ids or carrier codes", path)
+		}
+		if _, err := _scheduler.NewTrigger(so.Interval.Duration(), so.Cron); err != nil {
+			return fmt.Errorf("%s: illegal schedule override: %w", triggerConfigPath(path, so.Cron), err)
+		}
+		if so.Jitter < 0 {
+			return fmt.Errorf("%s.Jitter: jitter of schedule override should not be negative", path)
+		}
+	}
+
+	return nil
+}
+
+// 计算触发器对应的配置项的路径，设置了cron表达式时使用`Cron`，否则使用`Interval`。
+// path 包含触发器的配置项的路径。
+// cronExpr cron表达式。
+func triggerConfigPath(path, cronExpr string) string {
+	if strings.TrimSpace(cronExpr) != "" {
+		return joinConfigPath(path, "Cron")
+	}
+
+	return joinConfigPath(path, "Interval")
+}
+
+// 查找适用于指定爬虫的轮询计划。
+// sc 轮询计划配置。
+// crawlerInfo 爬虫。
+// 返回第一个匹配的轮询计划的序号，如果不存在匹配的轮询计划则返回-1，表示使用全局轮询计划。
+func matchScheduleOverride(sc *ScheduleConfiguration, crawlerInfo *_db.CrawlerInfoPo) int {
+	for i, so := range sc.Overrides {
+		for _, id := range so.CrawlerIds {
+			if id == crawlerInfo.Id {
+				return i
+			}
+		}
+		for _, carrierCode := range so.CarrierCodes {
+			if strings.EqualFold(strings.TrimSpace(carrierCode), crawlerInfo.CarrierCode) {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// 根据配置创建所有轮询计划的任务。
+// 全局轮询计划负责所有未被特定轮询计划匹配的爬虫，每个特定轮询计划负责各自匹配的爬虫。
+// 重新加载配置时，同名的轮询计划沿用原来的轮次协调器，避免新旧轮次重叠执行。
+// sc 轮询计划配置。
+// 返回新创建的任务。
+func newCheckJobs(sc *ScheduleConfiguration) ([]*_scheduler.Job, error) {
+	jobs := make([]*_scheduler.Job, 0, len(sc.Overrides)+1)
+	coordinators := make([]*_scheduler.Coordinator, 0, len(sc.Overrides)+1)
+
+	if trigger, err := _scheduler.NewTrigger(sc.Interval.Duration(), sc.Cron); err != nil {
+		return nil, err
+	} else {
+		coordinator := findOrNewCoordinator(checkCoordinators, "default", sc.Overlap)
+		coordinators = append(coordinators, coordinator)
+		jobs = append(jobs, &_scheduler.Job{
+			Name:         "default",
+			Trigger:      trigger,
+			InitialDelay: sc.InitialDelay.Duration(),
+			Jitter:       sc.Jitter.Duration(),
+			Run:          newCheckJob(sc, -1, coordinator),
+		})
+	}
+
+	for i, so := range sc.Overrides {
+		name := so.Name
+		if name == "" {
+			name = fmt.Sprintf("override#%d", i)
+		}
+
+		jitter := so.Jitter
+		if jitter == 0 {
+			jitter = sc.Jitter
+		}
+
+		if trigger, err := _scheduler.NewTrigger(so.Interval.Duration(), so.Cron); err != nil {
+			return nil, err
+		} else {
+			coordinator := findOrNewCoordinator(checkCoordinators, name, sc.Overlap)
+			coordinators = append(coordinators, coordinator)
+			jobs = append(jobs, &_scheduler.Job{
+				Name:         name,
+				Trigger:      trigger,
+				InitialDelay: sc.InitialDelay.Duration(),
+				Jitter:       jitter.Duration(),
+				Run:          newCheckJob(sc, i, coordinator),
+			})
+		}
+	}
+
+	checkCoordinators = coordinators
+
+	return jobs, nil
+}
+
+// 查找指定名字的轮次协调器，如果不存在则创建新的轮次协调器。
+// coordinators 已有的轮次协调器。
+// name 轮次协调器的名字。
+// policy 轮次重叠时的处理策略，找到的轮次协调器也会使用此策略。
+func findOrNewCoordinator(coordinators []*_scheduler.Coordinator, name string, policy _scheduler.Policy) *_scheduler.Coordinator {
+	for _, c := range coordinators {
+		if c.Name() == name {
+			c.SetPolicy(policy)
+			return c
+		}
+	}
+
+	return _scheduler.NewCoordinator(name, policy)
+}
+
+// 创建执行爬虫监控的任务。
+// sc 轮询计划配置。
+// overrideIndex 任务对应的轮询计划序号，-1表示全局轮询计划。
+// coordinator 任务使用的轮次协调器，用于避免同一轮询计划的多个轮次重叠执行。
+func newCheckJob(sc *ScheduleConfiguration, overrideIndex int, coordinator *_scheduler.Coordinator) func() {
+	return func() {
+		defer _utils.RecoverPanic()
+
+		ran := coordinator.Run(func(ctx context.Context) {
+			startTime := time.Now()
+			defer func() {
+				_metrics.ObserveRound(coordinator.Name(), time.Since(startTime))
+			}()
+
+			doCheck(ctx, func(crawlerInfo *_db.CrawlerInfoPo) bool {
+				return matchScheduleOverride(sc, crawlerInfo) == overrideIndex
+			})
+		})
+		if !ran {
+			_metrics.IncRoundSkipped(coordinator.Name())
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// 该模块实现了轮次协调器，避免同一任务的多个轮次重叠执行。
+// @Author: Haart
+// @Created: 2026-10-18
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 表示前一轮次尚未结束时，如何处理新的轮次。
+type Policy int
+
+const (
+	PolicySkip   Policy = 0 // 跳过新的轮次。
+	PolicyQueue  Policy = 1 // 等待前一轮次结束之后再执行新的轮次，最多只排队一个轮次。
+	PolicyCancel Policy = 2 // 取消前一轮次，等待其退出之后执行新的轮次。
+)
+
+func (p *Policy) String() string {
+	if *p == PolicySkip {
+		return "SKIP"
+	} else if *p == PolicyQueue {
+		return "QUEUE"
+	} else if *p == PolicyCancel {
+		return "CANCEL"
+	} else {
+		return ""
+	}
+}
+
+// 将字符串解析为Policy
+// s 待解析的字符串，会被自动去除首尾空格，然后变为大写。
+// 返回解析结果。
+func ParsePolicy(s string) (Policy, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+
+	if s == "SKIP" || s == "" {
+		return PolicySkip, nil
+	} else if s == "QUEUE" {
+		return PolicyQueue, nil
+	} else if s == "CANCEL" {
+		return PolicyCancel, nil
+	} else {
+		return 0, fmt.Errorf("unkown overlap policy: %s", s)
+	}
+}
+
+func (p *Policy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+func (p *Policy) UnmarshalJSON(b []byte) error {
+	s := ""
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	} else if pp, err := ParsePolicy(s); err != nil {
+		return err
+	} else {
+		*p = pp
+		return nil
+	}
+}
+
+// 表示轮次的统计信息。
+type RoundStats struct {
+	Started       int64         // 已开始的轮次数。
+	Finished      int64         // 已结束的轮次数，包括被取消的轮次。
+	Skipped       int64         // 被跳过的轮次数。
+	Canceled      int64         // 被取消的轮次数。
+	Running       bool          // 当前是否有轮次正在执行。
+	LastStartTime time.Time     // 最后一个轮次的开始时间。
+	LastDuration  time.Duration // 最后一个已结束轮次的耗时。
+}
+
+// 表示轮次协调器。
+type Coordinator struct {
+	name    string             // 协调器的名字，仅用于日志。
+	policy  Policy             // 轮次重叠时的处理策略。
+	lock    sync.Mutex         // 同步锁。
+	cancel  context.CancelFunc // 用于取消正在执行的轮次。
+	done    chan struct{}      // 正在执行的轮次结束时关闭。
+	waiting bool               // 是否已经有轮次在排队。
+	closed  bool               // 是否已经关闭，关闭之后不再执行新的轮次。
+	stats   RoundStats         // 统计信息。
+}
+
+// 创建轮次协调器。
+// name 协调器的名字，仅用于日志。
+// policy 轮次重叠时的处理策略。
+func NewCoordinator(name string, policy Policy) *Coordinator {
+	return &Coordinator{
+		name:   name,
+		policy: policy,
+	}
+}
+
+// 执行一个轮次。
+// 此方法会阻塞，直到该轮次结束或者被跳过。
+// f 轮次的执行体，应当在 `ctx` 被取消时尽快退出。
+// 返回该轮次是否被执行。
+func (c *Coordinator) Run(f func(ctx context.Context)) bool {
+	c.lock.Lock()
+	for c.closed || c.done != nil {
+		// 已经关闭，或者等待前一轮次结束期间被关闭。
+		if c.closed {
+			c.lock.Unlock()
+			return false
+		}
+
+		done := c.done
+
+		if c.policy == PolicyQueue && !c.waiting {
+			c.waiting = true
+			c.lock.Unlock()
+			<-done
+			c.lock.Lock()
+			c.waiting = false
+			continue
+		} else if c.policy == PolicyCancel {
+			c.cancel()
+			c.stats.Canceled++
+			log.Printf("[WARN] Round of %s is still running, cancel it\n", c.name)
+			c.lock.Unlock()
+			<-done
+			c.lock.Lock()
+			continue
+		}
+
+		c.stats.Skipped++
+		log.Printf("[WARN] Round of %s is still running, skip this round (%d skipped)\n", c.name, c.stats.Skipped)
+		c.lock.Unlock()
+		return false
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	startTime := time.Now()
+
+	c.cancel = cancel
+	c.done = done
+	c.stats.Started++
+	c.stats.Running = true
+	c.stats.LastStartTime = startTime
+	c.lock.Unlock()
+
+	defer func() {
+		duration := time.Since(startTime)
+
+		c.lock.Lock()
+		cancel()
+		c.cancel = nil
+		c.done = nil
+		c.stats.Finished++
+		c.stats.Running = false
+		c.stats.LastDuration = duration
+		c.lock.Unlock()
+
+		close(done)
+
+		log.Printf("[INFO] Round of %s finished in %s\n", c.name, duration)
+	}()
+
+	f(ctx)
+
+	return true
+}
+
+// 关闭协调器，取消正在执行的轮次，并且不再执行新的轮次。
+// 此方法不等待被取消的轮次退出。
+func (c *Coordinator) Close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.closed = true
+	if c.cancel != nil {
+		c.cancel()
+		c.stats.Canceled++
+		log.Printf("[WARN] Round of %s is canceled by closing\n", c.name)
+	}
+}
+
+// 修改轮次重叠时的处理策略，对正在排队的轮次无效。
+// policy 新的处理策略。
+func (c *Coordinator) SetPolicy(policy Policy) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.policy = policy
+}
+
+// 获取协调器的名字。
+func (c *Coordinator) Name() string {
+	return c.name
+}
+
+// 获取统计信息的快照。
+func (c *Coordinator) Stats() RoundStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.stats
+}
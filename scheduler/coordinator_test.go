@@ -0,0 +1,147 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCoordinatorPolicies(t *testing.T) {
+	cases := []struct {
+		policy    Policy
+		ranSecond bool // 第二个轮次是否被执行。
+		canceled  bool // 第一个轮次是否被取消。
+		stats     RoundStats
+	}{
+		{PolicySkip, false, false, RoundStats{Started: 1, Finished: 1, Skipped: 1}},
+		{PolicyQueue, true, false, RoundStats{Started: 2, Finished: 2}},
+		{PolicyCancel, true, true, RoundStats{Started: 2, Finished: 2, Canceled: 1}},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.policy.String(), func(t *testing.T) {
+			coordinator := NewCoordinator("test", c.policy)
+
+			started, release := make(chan struct{}), make(chan struct{})
+			firstDone := make(chan bool)
+			go func() {
+				canceled := false
+				coordinator.Run(func(ctx context.Context) {
+					close(started)
+					select {
+					case <-ctx.Done():
+						canceled = true
+					case <-release:
+					}
+				})
+				firstDone <- canceled
+			}()
+			<-started
+
+			secondDone := make(chan bool)
+			go func() {
+				secondDone <- coordinator.Run(func(ctx context.Context) {})
+			}()
+
+			// 排队的轮次在前一轮次结束之前不会执行。
+			select {
+			case ran := <-secondDone:
+				if c.policy == PolicyQueue {
+					t.Fatalf("queued round should wait, but returned %v", ran)
+				}
+				if ran != c.ranSecond {
+					t.Errorf("second round should be run: %v, but got %v", c.ranSecond, ran)
+				}
+				close(release)
+			case <-time.After(50 * time.Millisecond):
+				if c.policy != PolicyQueue {
+					t.Fatal("second round should not wait")
+				}
+				close(release)
+				if ran := <-secondDone; ran != c.ranSecond {
+					t.Errorf("second round should be run: %v, but got %v", c.ranSecond, ran)
+				}
+			}
+
+			if canceled := <-firstDone; canceled != c.canceled {
+				t.Errorf("first round should be canceled: %v, but got %v", c.canceled, canceled)
+			}
+
+			stats := coordinator.Stats()
+			stats.LastStartTime, stats.LastDuration = time.Time{}, 0
+			if stats != c.stats {
+				t.Errorf("expected stats %+v, but got %+v", c.stats, stats)
+			}
+		})
+	}
+}
+
+func TestCoordinatorQueuesOnlyOneRound(t *testing.T) {
+	coordinator := NewCoordinator("test", PolicyQueue)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	go coordinator.Run(func(ctx context.Context) {
+		close(started)
+		<-release
+	})
+	<-started
+
+	queued := make(chan bool)
+	go func() { queued <- coordinator.Run(func(ctx context.Context) {}) }()
+	time.Sleep(20 * time.Millisecond)
+
+	// 已经有轮次在排队，新的轮次被跳过。
+	if coordinator.Run(func(ctx context.Context) { t.Error("third round should be skipped") }) {
+		t.Error("third round should be skipped")
+	}
+
+	close(release)
+	if !<-queued {
+		t.Error("queued round should be run")
+	}
+	if stats := coordinator.Stats(); stats.Skipped != 1 || stats.Started != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestCoordinatorClose(t *testing.T) {
+	coordinator := NewCoordinator("test", PolicyQueue)
+
+	started := make(chan struct{})
+	canceled := make(chan bool)
+	go func() {
+		coordinator.Run(func(ctx context.Context) {
+			close(started)
+			select {
+			case <-ctx.Done():
+				canceled <- true
+			case <-time.After(5 * time.Second):
+				canceled <- false
+			}
+		})
+	}()
+	<-started
+
+	// 排队等待的轮次在关闭之后不再执行。
+	queued := make(chan bool)
+	go func() {
+		queued <- coordinator.Run(func(ctx context.Context) {})
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	coordinator.Close()
+	if !<-canceled {
+		t.Fatal("running round should be canceled by closing")
+	}
+	if <-queued {
+		t.Error("queued round should not be run after closing")
+	}
+	if coordinator.Run(func(ctx context.Context) {}) {
+		t.Error("new round should not be run after closing")
+	}
+
+	if stats := coordinator.Stats(); stats.Canceled != 1 || stats.Started != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,288 @@
+// 该模块实现了轮询计划。
+// @Author: Haart
+// @Created: 2026-10-18
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	cronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+	rnd     = rand.New(rand.NewSource(time.Now().UnixNano()))
+	rndLock sync.Mutex
+)
+
+// 表示任务的触发器。
+type Trigger interface {
+	// 计算晚于指定时间的下一次触发时间。
+	// t 基准时间。
+	// 返回下一次触发时间。
+	Next(t time.Time) time.Time
+}
+
+// 按固定周期触发的触发器。
+type intervalTrigger struct {
+	interval time.Duration // 触发周期。
+}
+
+func (it *intervalTrigger) Next(t time.Time) time.Time {
+	return t.Add(it.interval)
+}
+
+// 按cron表达式触发的触发器。
+type cronTrigger struct {
+	schedule cron.Schedule // 已解析的cron表达式。
+}
+
+func (ct *cronTrigger) Next(t time.Time) time.Time {
+	return ct.schedule.Next(t)
+}
+
+// 创建触发器。
+// interval 触发周期。
+// cronExpr cron表达式，支持可选的秒字段以及`@hourly`、`@every 5m`等描述符。如果不为空则忽略 `interval`。
+// 返回新创建的触发器。
+func NewTrigger(interval time.Duration, cronExpr string) (Trigger, error) {
+	cronExpr = strings.TrimSpace(cronExpr)
+	if cronExpr != "" {
+		if schedule, err := cronParser.Parse(cronExpr); err != nil {
+			return nil, fmt.Errorf("illegal cron expression %q: %w", cronExpr, err)
+		} else {
+			return &cronTrigger{schedule: schedule}, nil
+		}
+	} else if interval <= 0 {
+		return nil, fmt.Errorf("interval should be positive, but %s", interval)
+	} else {
+		return &intervalTrigger{interval: interval}, nil
+	}
+}
+
+// 表示一个定时任务。
+type Job struct {
+	Name         string        // 任务名，仅用于日志。
+	Trigger      Trigger       // 触发器。
+	InitialDelay time.Duration // 启动后首次执行前的延迟，如果为0则按照触发器计算首次执行时间。
+	Jitter       time.Duration // 每次执行附加的随机延迟上限，用于错开多个任务。
+	Run          func()        // 任务的执行体，每次执行都在新的routine中调用。
+}
+
+// 表示一个任务的执行计划，重新调度时沿用。
+type jobSchedule struct {
+	lastRun time.Time // 最后一次执行的计划时间，如果尚未执行则为零值。
+	next    time.Time // 下一次执行的计划时间，不包含随机延迟。
+}
+
+// 表示调度器，按照各个任务的触发器定时执行任务。
+type Scheduler struct {
+	jobs      []*Job                  // 所有任务。
+	schedules map[string]*jobSchedule // 按照任务名记录的执行计划。
+	quit      chan struct{}           // 用于通知调度routine退出。
+	wg        sync.WaitGroup          // 用于等待调度routine退出。
+	running   sync.WaitGroup          // 用于等待正在执行的任务结束。
+	lock      sync.Mutex              // 同步锁。
+}
+
+// 创建调度器。
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		jobs:      make([]*Job, 0),
+		schedules: make(map[string]*jobSchedule),
+	}
+}
+
+// 添加任务，必须在 `Start` 之前调用。
+// job 待添加的任务。
+func (s *Scheduler) Add(job *Job) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.jobs = append(s.jobs, job)
+}
+
+// 启动调度器，为每个任务启动一个调度routine。
+func (s *Scheduler) Start() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.quit != nil {
+		return
+	}
+
+	s.quit = make(chan struct{})
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(job, s.quit)
+	}
+}
+
+// 停止调度器，不再执行新的任务。已经开始执行的任务不受影响。
+func (s *Scheduler) Stop() {
+	s.lock.Lock()
+	quit := s.quit
+	s.quit = nil
+	s.lock.Unlock()
+
+	if quit != nil {
+		close(quit)
+		s.wg.Wait()
+	}
+}
+
+// 替换所有任务。如果调度器已经启动，那么按照新的任务重新开始调度，已经开始执行的任务不受影响。
+// 和原来的任务同名的任务沿用原来的执行计划，不再等待首次执行前的延迟：如果原来的任务已经执行过，那么按照新的触发器从最后一次执行的时间计算下一次执行的时间，否则仍然在原来计划的时间首次执行。
+// jobs 新的任务。
+func (s *Scheduler) Reschedule(jobs []*Job) {
+	s.lock.Lock()
+	started := s.quit != nil
+	s.lock.Unlock()
+
+	s.Stop()
+
+	s.lock.Lock()
+	s.jobs = append(make([]*Job, 0, len(jobs)), jobs...)
+	names := make(map[string]bool)
+	for _, job := range jobs {
+		names[job.Name] = true
+	}
+	for name := range s.schedules {
+		if !names[name] {
+			delete(s.schedules, name)
+		}
+	}
+	s.lock.Unlock()
+
+	if started {
+		s.Start()
+	}
+}
+
+// 停止调度器，并等待所有已经开始执行的任务结束。
+// ctx 上下文，如果在所有任务结束之前超时或者被取消，那么返回 `ctx.Err()`，尚未结束的任务不受影响。
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.Stop()
+
+	return s.Wait(ctx)
+}
+
+// 等待所有已经开始执行的任务结束，不影响调度。
+// ctx 上下文，如果在所有任务结束之前超时或者被取消，那么返回 `ctx.Err()`。
+func (s *Scheduler) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) loop(job *Job, quit chan struct{}) {
+	defer s.wg.Done()
+
+	next := s.firstRun(job, time.Now())
+
+	for {
+		s.setNext(job.Name, next)
+
+		delay := time.Until(next)
+		if job.Jitter > 0 {
+			delay += randomDuration(job.Jitter)
+		}
+		if delay < 0 {
+			delay = 0
+		}
+
+		log.Printf("[INFO] Next run of job %s at %s\n", job.Name, time.Now().Add(delay).Format(time.RFC3339))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-quit:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.setLastRun(job.Name, next)
+
+		s.running.Add(1)
+		go func() {
+			defer s.running.Done()
+
+			job.Run()
+		}()
+
+		// 如果执行已经落后，那么从当前时间重新计算，避免连续触发。
+		next = job.Trigger.Next(next)
+		if now := time.Now(); next.Before(now) {
+			next = job.Trigger.Next(now)
+		}
+	}
+}
+
+// 计算任务首次执行的时间。
+// 如果同名的任务已经被调度过，说明是重新调度，那么沿用原来的执行计划，否则按照首次执行前的延迟或者触发器计算。
+// job 任务。
+// now 当前时间。
+func (s *Scheduler) firstRun(job *Job, now time.Time) time.Time {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if js, ok := s.schedules[job.Name]; ok {
+		if js.lastRun.IsZero() {
+			// 尚未执行过，仍然在等待首次执行。
+			return js.next
+		}
+		// 停止调度期间错过的执行会立即补上。
+		return job.Trigger.Next(js.lastRun)
+	}
+
+	if job.InitialDelay > 0 {
+		return now.Add(job.InitialDelay)
+	} else {
+		return job.Trigger.Next(now)
+	}
+}
+
+func (s *Scheduler) setNext(name string, next time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if js, ok := s.schedules[name]; ok {
+		js.next = next
+	} else {
+		s.schedules[name] = &jobSchedule{next: next}
+	}
+}
+
+func (s *Scheduler) setLastRun(name string, lastRun time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if js, ok := s.schedules[name]; ok {
+		js.lastRun = lastRun
+	}
+}
+
+// 生成随机的时间间隔。
+// max 时间间隔的上限（不包含）。
+func randomDuration(max time.Duration) time.Duration {
+	rndLock.Lock()
+	defer rndLock.Unlock()
+
+	return time.Duration(rnd.Int63n(int64(max)))
+}
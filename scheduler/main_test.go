@@ -0,0 +1,162 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestShutdownDrainsRunningRound(t *testing.T) {
+	cases := []struct {
+		name        string
+		roundTime   time.Duration // 轮次的执行时间。
+		gracePeriod time.Duration // 等待轮次结束的时间。
+		err         error
+	}{
+		{"drained", 50 * time.Millisecond, 5 * time.Second, nil},
+		{"grace period expired", time.Second, 20 * time.Millisecond, context.DeadlineExceeded},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			started, finished := make(chan struct{}, 10), make(chan struct{}, 10)
+			trigger, _ := NewTrigger(time.Hour, "")
+
+			s := NewScheduler()
+			s.Add(&Job{Name: c.name, Trigger: trigger, InitialDelay: time.Millisecond, Run: func() {
+				started <- struct{}{}
+				time.Sleep(c.roundTime)
+				finished <- struct{}{}
+			}})
+			s.Start()
+
+			select {
+			case <-started:
+			case <-time.After(5 * time.Second):
+				t.Fatal("round is not started")
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), c.gracePeriod)
+			defer cancel()
+			if err := s.Shutdown(ctx); err != c.err {
+				t.Fatalf("shutdown should return %v, but got %v", c.err, err)
+			}
+
+			if c.err == nil {
+				// 正在执行的轮次已经结束。
+				select {
+				case <-finished:
+				default:
+					t.Fatal("running round should be finished before shutdown returns")
+				}
+			} else {
+				// 超过宽限期时轮次仍在执行，再次等待时可以等到其结束。
+				select {
+				case <-finished:
+					t.Fatal("running round should not be finished yet")
+				default:
+				}
+				if err := s.Shutdown(context.Background()); err != nil {
+					t.Fatalf("second shutdown should wait for the round, but got %v", err)
+				}
+				<-finished
+			}
+
+			if len(started) != 0 {
+				t.Error("no new round should be started after shutdown")
+			}
+		})
+	}
+}
+
+// 等待任务的执行计划被记录。
+func waitSchedule(t *testing.T, s *Scheduler, name string) jobSchedule {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		s.lock.Lock()
+		js, ok := s.schedules[name]
+		s.lock.Unlock()
+		if ok {
+			return *js
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("job %s is not scheduled", name)
+	return jobSchedule{}
+}
+
+func TestRescheduleKeepsPendingFirstRun(t *testing.T) {
+	trigger, _ := NewTrigger(time.Hour, "")
+	newJob := func() *Job {
+		return &Job{Name: "pending", Trigger: trigger, InitialDelay: time.Minute, Run: func() {}}
+	}
+
+	s := NewScheduler()
+	s.Add(newJob())
+	s.Start()
+	defer s.Stop()
+
+	before := waitSchedule(t, s, "pending")
+	time.Sleep(10 * time.Millisecond)
+	s.Reschedule([]*Job{newJob()})
+
+	if after := waitSchedule(t, s, "pending"); !after.next.Equal(before.next) {
+		t.Errorf("first run should be kept at %s, but got %s", before.next, after.next)
+	}
+}
+
+func TestRescheduleDoesNotRerunJob(t *testing.T) {
+	runs := make(chan struct{}, 10)
+	trigger, _ := NewTrigger(time.Hour, "")
+	newJob := func() *Job {
+		return &Job{Name: "ran", Trigger: trigger, InitialDelay: time.Millisecond, Run: func() { runs <- struct{}{} }}
+	}
+
+	s := NewScheduler()
+	s.Add(newJob())
+	s.Start()
+	defer s.Stop()
+
+	select {
+	case <-runs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job is not run")
+	}
+
+	s.Reschedule([]*Job{newJob()})
+
+	select {
+	case <-runs:
+		t.Error("job should not be run again after reschedule")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	js := waitSchedule(t, s, "ran")
+	if d := js.next.Sub(js.lastRun); d != time.Hour {
+		t.Errorf("next run should be one hour after last run, but got %s", d)
+	}
+}
+
+func TestRescheduleDropsRemovedJobs(t *testing.T) {
+	trigger, _ := NewTrigger(time.Hour, "")
+
+	s := NewScheduler()
+	s.Add(&Job{Name: "removed", Trigger: trigger, InitialDelay: time.Minute, Run: func() {}})
+	s.Start()
+	defer s.Stop()
+
+	waitSchedule(t, s, "removed")
+	s.Reschedule([]*Job{{Name: "added", Trigger: trigger, Run: func() {}}})
+	waitSchedule(t, s, "added")
+
+	s.lock.Lock()
+	_, ok := s.schedules["removed"]
+	s.lock.Unlock()
+	if ok {
+		t.Error("schedule of removed job should be dropped")
+	}
+}
@@ -0,0 +1,83 @@
+// 该模块实现了守护进程的优雅退出。
+// @Author: Haart
+// @Created: 2026-10-18
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	_cache "com.cne/ai-tracking-monitor/cache"
+	_db "com.cne/ai-tracking-monitor/db"
+	_queue "com.cne/ai-tracking-monitor/queue"
+	_scheduler "com.cne/ai-tracking-monitor/scheduler"
+)
+
+const (
+	shutdownCancelTimeout = 10 * time.Second // 取消轮次之后等待其退出的最长时间。
+)
+
+// 优雅地退出守护进程。
+// 首先停止调度新的轮次，并等待正在执行的轮次结束，超过宽限期之后取消这些轮次（被取消的轮次会清理尚未完成的查询对象）。
+// 然后等待健康状态的更新和告警通知的发送，最后关闭HTTP服务、数据库、缓存和队列。
+// s 调度器。
+func shutdown(s *_scheduler.Scheduler) {
+	// 等待正在进行的重新加载配置结束，并且不再重新加载配置。
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+	checkScheduler = nil
+
+	gracePeriod := configuration.Schedule.GracePeriod.Duration()
+	log.Printf("[INFO] Shutting down, wait up to %s for running rounds\n", gracePeriod)
+
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+
+	// HTTP服务不再接受新的请求，正在处理的请求（比如立即监控）和轮次共用宽限期。
+	httpStopped := make(chan error, 1)
+	go func() {
+		httpStopped <- stopHttpServer(ctx)
+	}()
+
+	if err := s.Shutdown(ctx); err != nil {
+		log.Printf("[WARN] Running rounds are not finished in %s, cancel them\n", gracePeriod)
+
+		for _, c := range checkCoordinators {
+			c.Close()
+		}
+		if retentionCoordinator != nil {
+			retentionCoordinator.Close()
+		}
+
+		cancelCtx, cancelCancel := context.WithTimeout(context.Background(), shutdownCancelTimeout)
+		defer cancelCancel()
+		if err := s.Shutdown(cancelCtx); err != nil {
+			log.Printf("[WARN] Canceled rounds are not finished in %s\n", shutdownCancelTimeout)
+		}
+	}
+
+	if err := <-httpStopped; err != nil {
+		log.Printf("[WARN] Cannot stop HTTP server gracefully: %s\n", err)
+	}
+
+	// 等待健康状态的更新，其中会保存状态变化并分发告警事件。
+	healthUpdates.Wait()
+
+	// 发送所有尚未发送的告警通知。
+	if alertDispatcher != nil {
+		alertDispatcher.Close()
+	}
+
+	if err := _db.Close(); err != nil {
+		log.Printf("[WARN] Cannot close database: %s\n", err)
+	}
+	if err := _cache.Close(); err != nil {
+		log.Printf("[WARN] Cannot close cache: %s\n", err)
+	}
+	if err := _queue.Close(); err != nil {
+		log.Printf("[WARN] Cannot close queue: %s\n", err)
+	}
+
+	log.Printf("[INFO] Shutdown completed\n")
+}
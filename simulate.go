@@ -0,0 +1,58 @@
+// 该模块实现了运行模拟查询代理的子命令。
+// @Author: Haart
+// @Created: 2026-10-18
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	_cache "com.cne/ai-tracking-monitor/cache"
+	_queue "com.cne/ai-tracking-monitor/queue"
+	_simulator "com.cne/ai-tracking-monitor/simulator"
+)
+
+// simulate [-script CODE=BEHAVIOUR,...] [-default BEHAVIOUR] [-delay DURATION] [-slow DURATION] [-name NAME] [-no-notify] [CONFIG_FILE]
+// 作为模拟查询代理运行，按照运输商编号对应的行为响应查询队列中的查询对象，直到收到退出信号。
+func cmdSimulate(args []string) error {
+	fs := newFlagSet(findCommand("simulate"))
+	script := fs.String("script", "", "Comma separated CARRIER_CODE=BEHAVIOUR, behaviour can be SUCCESS, NO_TRACKING, MALFORMED, BATCH, SLOW or SILENT")
+	defaultBehaviour := fs.String("default", "SUCCESS", "Behaviour of carriers not in script")
+	delay := fs.Duration("delay", _simulator.DefaultDelay, "Delay before responding")
+	slowDelay := fs.Duration("slow", _simulator.DefaultSlowDelay, "Delay before responding of SLOW behaviour")
+	name := fs.String("name", _simulator.DefaultName, "Agent name written into results")
+	noNotify := fs.Bool("no-notify", false, "Do not publish completion notifications, like legacy agents")
+	fs.Parse(args)
+
+	options := _simulator.Options{Name: *name, Delay: *delay, SlowDelay: *slowDelay, NoNotify: *noNotify}
+	if s, err := _simulator.ParseScript(*script); err != nil {
+		return err
+	} else {
+		options.Script = s
+	}
+	if b, err := _simulator.ParseBehaviour(*defaultBehaviour); err != nil {
+		return err
+	} else {
+		options.Default = b
+	}
+
+	if err := loadConfig(strings.TrimSpace(fs.Arg(0))); err != nil {
+		return fmt.Errorf("cannot load configuration: %w", err)
+	}
+
+	// 进程内存中的缓存和队列无法和监控进程共享。
+	if configuration.Redis.Driver == _cache.DriverMemory {
+		return fmt.Errorf("simulator cannot share memory cache and queue with other process, use redis instead")
+	}
+
+	if err := initCacheAndQueue(&configuration.Redis); err != nil {
+		return err
+	}
+
+	ctx, cancel := newCommandContext(0)
+	defer cancel()
+
+	fmt.Fprintf(os.Stderr, "Simulating query agent %s ...\n", options.Name)
+	return _simulator.New(_cache.Default(), _queue.Default(), options).Run(ctx)
+}
@@ -0,0 +1,106 @@
+// 该模块定义了模拟查询代理的行为。
+// @Author: Haart
+// @Created: 2026-10-18
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 表示模拟查询代理针对某个运输商的行为。
+type Behaviour int
+
+const (
+	BehaviourSuccess    Behaviour = 0 // 成功返回若干事件。
+	BehaviourNoTracking Behaviour = 1 // 返回单号未查询到。
+	BehaviourMalformed  Behaviour = 2 // 返回无法解析的JSON。
+	BehaviourBatch      Behaviour = 3 // 以批量跟踪结果的格式成功返回若干事件。
+	BehaviourSlow       Behaviour = 4 // 超过查询对象在缓存中的有效期之后才返回。
+	BehaviourSilent     Behaviour = 5 // 取出查询对象之后永不返回。
+)
+
+func (b *Behaviour) String() string {
+	if *b == BehaviourSuccess {
+		return "SUCCESS"
+	} else if *b == BehaviourNoTracking {
+		return "NO_TRACKING"
+	} else if *b == BehaviourMalformed {
+		return "MALFORMED"
+	} else if *b == BehaviourBatch {
+		return "BATCH"
+	} else if *b == BehaviourSlow {
+		return "SLOW"
+	} else if *b == BehaviourSilent {
+		return "SILENT"
+	} else {
+		return ""
+	}
+}
+
+// 将字符串解析为Behaviour
+// s 待解析的字符串，会被自动去除首尾空格，然后变为大写。
+// 返回解析结果。
+func ParseBehaviour(s string) (Behaviour, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+
+	if s == "SUCCESS" || s == "" {
+		return BehaviourSuccess, nil
+	} else if s == "NO_TRACKING" {
+		return BehaviourNoTracking, nil
+	} else if s == "MALFORMED" {
+		return BehaviourMalformed, nil
+	} else if s == "BATCH" {
+		return BehaviourBatch, nil
+	} else if s == "SLOW" {
+		return BehaviourSlow, nil
+	} else if s == "SILENT" {
+		return BehaviourSilent, nil
+	} else {
+		return 0, fmt.Errorf("unknown simulator behaviour: %s", s)
+	}
+}
+
+func (b *Behaviour) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+func (b *Behaviour) UnmarshalJSON(bs []byte) error {
+	s := ""
+	if err := json.Unmarshal(bs, &s); err != nil {
+		return err
+	} else if bb, err := ParseBehaviour(s); err != nil {
+		return err
+	} else {
+		*b = bb
+		return nil
+	}
+}
+
+// 解析按照运输商编号指定的行为。
+// s 待解析的字符串，格式是`CODE=BEHAVIOUR,CODE=BEHAVIOUR`。
+// 返回运输商编号到行为的映射，运输商编号都被转换为大写。
+func ParseScript(s string) (map[string]Behaviour, error) {
+	result := make(map[string]Behaviour)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		i := strings.Index(item, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("illegal simulator script: %s", item)
+		}
+
+		code := strings.ToUpper(strings.TrimSpace(item[:i]))
+		if b, err := ParseBehaviour(item[i+1:]); err != nil {
+			return nil, err
+		} else {
+			result[code] = b
+		}
+	}
+
+	return result, nil
+}
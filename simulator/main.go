@@ -0,0 +1,252 @@
+// 该模块实现了模拟查询代理，用于在没有真实爬虫的情况下端到端地测试监控。
+// 模拟查询代理从查询队列中取出查询对象的键，然后按照运输商编号对应的行为将结果写回缓存。
+// @Author: Haart
+// @Created: 2026-10-18
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	_agent "com.cne/ai-tracking-monitor/agent"
+	_cache "com.cne/ai-tracking-monitor/cache"
+	_queue "com.cne/ai-tracking-monitor/queue"
+	_rpcclient "com.cne/ai-tracking-monitor/rpcclient"
+	_types "com.cne/ai-tracking-monitor/types"
+	_utils "com.cne/ai-tracking-monitor/utils"
+)
+
+const (
+	DefaultName         string        = "simulator"            // 默认的查询代理名字。
+	DefaultDelay        time.Duration = 200 * time.Millisecond // 默认的正常响应前的延迟。
+	DefaultSlowDelay    time.Duration = 90 * time.Second       // 默认的慢响应的延迟，超过查询对象在缓存中的有效期（60秒）。
+	DefaultPollInterval time.Duration = 100 * time.Millisecond // 默认的队列为空时轮询的间隔。
+)
+
+var (
+	priorities = []_types.Priority{_types.PriorityHighest, _types.PriorityHigh, _types.PriorityLow} // 按照优先级从高到低排列的查询队列。
+)
+
+// 表示模拟查询代理的选项。
+type Options struct {
+	Name         string               // 查询代理的名字，写入缓存的`agentName`字段。
+	Script       map[string]Behaviour // 按照运输商编号（大写）指定的行为。
+	Default      Behaviour            // 没有指定行为的运输商使用的行为。
+	Delay        time.Duration        // 正常响应前的延迟。
+	SlowDelay    time.Duration        // 慢响应的延迟。
+	PollInterval time.Duration        // 队列为空时轮询的间隔。
+	NoNotify     bool                 // 是否不发布完成通知，用于模拟不支持通知的查询代理。
+}
+
+// 模拟查询代理。
+type Simulator struct {
+	cache   _cache.Cache
+	queue   _queue.Queue
+	options Options
+	wg      sync.WaitGroup // 等待正在处理的查询对象。
+}
+
+// 创建模拟查询代理。
+// c 保存查询对象的缓存。
+// q 查询队列。
+// options 选项，未设置的选项使用默认值。
+func New(c _cache.Cache, q _queue.Queue, options Options) *Simulator {
+	if options.Name == "" {
+		options.Name = DefaultName
+	}
+	if options.Delay <= 0 {
+		options.Delay = DefaultDelay
+	}
+	if options.SlowDelay <= 0 {
+		options.SlowDelay = DefaultSlowDelay
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = DefaultPollInterval
+	}
+	if options.Script == nil {
+		options.Script = make(map[string]Behaviour)
+	}
+
+	return &Simulator{cache: c, queue: q, options: options}
+}
+
+// 获取指定运输商对应的行为。
+// carrierCode 运输商编号。
+func (s *Simulator) behaviourOf(carrierCode string) Behaviour {
+	if b, ok := s.options.Script[strings.ToUpper(strings.TrimSpace(carrierCode))]; ok {
+		return b
+	} else {
+		return s.options.Default
+	}
+}
+
+// 持续从查询队列中取出查询对象并处理。
+// 此方法会阻塞，直到 `ctx` 被取消，并且所有正在处理的查询对象都已结束。
+func (s *Simulator) Run(ctx context.Context) error {
+	defer s.wg.Wait()
+
+	for {
+		if key, err := s.pop(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		} else if key != "" {
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+
+				if err := s.Handle(ctx, key); err != nil {
+					log.Printf("[WARN] Simulator cannot handle %s: %s\n", key, err)
+				}
+			}()
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.options.PollInterval):
+		}
+	}
+}
+
+// 按照优先级从高到低从查询队列中取出一个查询对象的键。
+// ctx 上下文。
+// 返回查询对象的键，如果所有队列都为空则返回空字符串。
+func (s *Simulator) pop(ctx context.Context) (string, error) {
+	for _, priority := range priorities {
+		if key, err := s.queue.Pop(ctx, _rpcclient.QueueTopic(priority)); err == nil {
+			return key, nil
+		} else if !errors.Is(err, _queue.Nil) {
+			return "", err
+		}
+	}
+
+	return "", nil
+}
+
+// 处理一个查询对象，按照运输商对应的行为将结果写回缓存。
+// 此方法会阻塞，直到写回结果、`ctx` 被取消，或者确定不会写回结果。
+// ctx 上下文。
+// key 查询对象的键。
+func (s *Simulator) Handle(ctx context.Context, key string) error {
+	vs, err := s.cache.Get(ctx, key, "carrierCode", "trackingNo", "notifyChannel")
+	if errors.Is(err, _cache.Nil) {
+		log.Printf("[WARN] Simulator found %s vanished before handling\n", key)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	carrierCode := _utils.AsString(vs[0])
+	trackingNo := _utils.AsString(vs[1])
+	notifyChannel := _utils.AsString(vs[2])
+	behaviour := s.behaviourOf(carrierCode)
+	startTime := time.Now()
+
+	// 状态0表示查询代理已经开始执行，但是尚未返回结果。
+	// 只更新仍然存在的查询对象，避免重新创建已经过期的缓存，这样的缓存没有过期时间。
+	if ok, err := s.cache.UpdateIfExists(ctx, key, map[string]interface{}{"status": 0, "agentName": s.options.Name, "agentStartTime": startTime}); err != nil {
+		return err
+	} else if !ok {
+		log.Printf("[WARN] Simulator found %s vanished before handling\n", key)
+		return nil
+	}
+
+	if behaviour == BehaviourSilent {
+		return nil
+	}
+
+	delay := s.options.Delay
+	if behaviour == BehaviourSlow {
+		delay = s.options.SlowDelay
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+	}
+
+	agentResult, err := buildAgentResult(behaviour, trackingNo, startTime)
+	if err != nil {
+		return err
+	}
+
+	// 如果查询对象已经过期，那么不再写回结果，避免遗留没有过期时间的缓存。
+	if ok, err := s.cache.UpdateIfExists(ctx, key, map[string]interface{}{
+		"status":       1,
+		"agentSrc":     int(_types.SrcCrawler),
+		"agentErr":     "",
+		"agentResult":  agentResult,
+		"agentEndTime": time.Now(),
+	}); err != nil {
+		return err
+	} else if !ok {
+		log.Printf("[INFO] Simulator gave up %s, which expired after %s\n", key, time.Since(startTime))
+		return nil
+	}
+
+	// 通知监控查询对象已完成，旧的监控没有设置通知频道，只能依靠轮询。
+	if notifyChannel != "" && !s.options.NoNotify {
+		return s.queue.Publish(ctx, notifyChannel, key)
+	}
+
+	return nil
+}
+
+// 按照行为构造查询代理返回的原始文本。
+// behaviour 行为。
+// trackingNo 运单号。
+// now 当前时间，用于生成事件的时间。
+func buildAgentResult(behaviour Behaviour, trackingNo string, now time.Time) (string, error) {
+	var result interface{}
+	switch behaviour {
+	case BehaviourSuccess, BehaviourSlow:
+		result = newTrackingResult(trackingNo, now)
+	case BehaviourBatch:
+		result = &_agent.ResponseWrapper{
+			Code:    fmt.Sprint(int(_agent.AcSuccess2)),
+			Message: "",
+			Items:   []_agent.TrackingResult{*newTrackingResult(trackingNo, now)},
+		}
+	case BehaviourNoTracking:
+		result = &_agent.TrackingResult{
+			Code:       _agent.AcNoTracking,
+			CMess:      "no tracking information",
+			TrackingNo: trackingNo,
+		}
+	case BehaviourMalformed:
+		return `{"code": 1, "trackingEventList": [`, nil
+	default:
+		return "", fmt.Errorf("simulator behaviour %s has no result", behaviour.String())
+	}
+
+	if bs, err := json.Marshal(result); err != nil {
+		return "", err
+	} else {
+		return string(bs), nil
+	}
+}
+
+// 构造包含若干事件的成功结果。
+// trackingNo 运单号。
+// now 当前时间，最新的事件发生在此之前一小时。
+func newTrackingResult(trackingNo string, now time.Time) *_agent.TrackingResult {
+	const layout = "2006-01-02 15:04:05"
+	return &_agent.TrackingResult{
+		Code:       _agent.AcSuccess,
+		TrackingNo: trackingNo,
+		TrackingEventList: []_agent.TrackingEvent{
+			{Date: now.Add(-1 * time.Hour).Format(layout), Place: "SHENZHEN", Details: "Departed from facility"},
+			{Date: now.Add(-6 * time.Hour).Format(layout), Place: "SHENZHEN", Details: "Arrived at facility"},
+			{Date: now.Add(-24 * time.Hour).Format(layout), Place: "SHENZHEN", Details: "Shipment information received"},
+		},
+	}
+}
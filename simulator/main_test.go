@@ -0,0 +1,199 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	_agent "com.cne/ai-tracking-monitor/agent"
+	_cache "com.cne/ai-tracking-monitor/cache"
+	_queue "com.cne/ai-tracking-monitor/queue"
+	_rpcclient "com.cne/ai-tracking-monitor/rpcclient"
+	_types "com.cne/ai-tracking-monitor/types"
+)
+
+const testNotifyChannel = "TRACKING_NOTIFY$test"
+
+// 创建模拟查询代理，以及保存在缓存中的查询对象。
+// expiration 查询对象在缓存中的有效期。
+func newTestSimulator(t *testing.T, carrierCode string, expiration time.Duration, options Options) (*Simulator, _cache.Cache, _queue.Subscription) {
+	t.Helper()
+
+	c, q := _cache.NewMemoryCache(), _queue.NewMemoryQueue()
+	if err := c.SetAndExpire(context.Background(), "k", map[string]interface{}{"carrierCode": carrierCode, "trackingNo": "JD0001", "notifyChannel": testNotifyChannel, "status": -1}, expiration); err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := q.Subscribe(context.Background(), testNotifyChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sub.Close() })
+
+	if options.Delay == 0 {
+		options.Delay = 10 * time.Millisecond
+	}
+
+	return New(c, q, options), c, sub
+}
+
+func expectNotified(t *testing.T, sub _queue.Subscription, want bool) {
+	t.Helper()
+
+	select {
+	case key := <-sub.Channel():
+		if !want {
+			t.Errorf("unexpected notification: %s", key)
+		} else if key != "k" {
+			t.Errorf("expected notification of k but got %s", key)
+		}
+	default:
+		if want {
+			t.Error("expected notification")
+		}
+	}
+}
+
+func TestHandleBehaviours(t *testing.T) {
+	cases := []struct {
+		behaviour Behaviour
+		code      _agent.AgCode
+		parsed    bool
+	}{
+		{BehaviourSuccess, _agent.AcSuccess, true},
+		{BehaviourNoTracking, _agent.AcNoTracking, true},
+		{BehaviourMalformed, 0, false},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.behaviour.String(), func(t *testing.T) {
+			s, cache, sub := newTestSimulator(t, "dhl", time.Minute, Options{Name: "sim", Script: map[string]Behaviour{"DHL": c.behaviour}})
+
+			if err := s.Handle(context.Background(), "k"); err != nil {
+				t.Fatal(err)
+			}
+
+			vs, err := cache.Get(context.Background(), "k", "status", "agentName", "agentResult")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if vs[0] != "1" || vs[1] != "sim" {
+				t.Errorf("unexpected status or agent name: %#v", vs)
+			}
+
+			tr := _agent.TrackingResult{}
+			if err := json.Unmarshal([]byte(vs[2].(string)), &tr); (err == nil) != c.parsed {
+				t.Fatalf("unexpected result %s: %v", vs[2], err)
+			} else if c.parsed && (tr.Code != c.code || tr.TrackingNo != "JD0001") {
+				t.Errorf("unexpected result: %+v", tr)
+			}
+
+			expectNotified(t, sub, true)
+		})
+	}
+}
+
+func TestHandleBatch(t *testing.T) {
+	s, cache, _ := newTestSimulator(t, "UPS", time.Minute, Options{Default: BehaviourBatch})
+
+	if err := s.Handle(context.Background(), "k"); err != nil {
+		t.Fatal(err)
+	}
+
+	vs, _ := cache.Get(context.Background(), "k", "agentResult")
+	rw := _agent.ResponseWrapper{}
+	if err := json.Unmarshal([]byte(vs[0].(string)), &rw); err != nil {
+		t.Fatal(err)
+	}
+	if len(rw.Items) != 1 || rw.Items[0].TrackingNo != "JD0001" || len(rw.Items[0].TrackingEventList) == 0 {
+		t.Errorf("unexpected batch result: %+v", rw)
+	}
+}
+
+func TestHandleSilent(t *testing.T) {
+	s, cache, sub := newTestSimulator(t, "DHL", time.Minute, Options{Default: BehaviourSilent})
+
+	if err := s.Handle(context.Background(), "k"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 只标记为正在执行，永不返回结果。
+	vs, _ := cache.Get(context.Background(), "k", "status", "agentResult")
+	if vs[0] != "0" || vs[1] != nil {
+		t.Errorf("unexpected cache: %#v", vs)
+	}
+	expectNotified(t, sub, false)
+}
+
+func TestHandleSlowDoesNotRecreateExpiredKey(t *testing.T) {
+	s, cache, sub := newTestSimulator(t, "DHL", 50*time.Millisecond, Options{Default: BehaviourSlow, SlowDelay: 100 * time.Millisecond})
+
+	if err := s.Handle(context.Background(), "k"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cache.Get(context.Background(), "k", "status"); !errors.Is(err, _cache.Nil) {
+		t.Errorf("expired key should not be recreated, but got %v", err)
+	}
+	expectNotified(t, sub, false)
+}
+
+func TestHandleVanishedKey(t *testing.T) {
+	s, cache, sub := newTestSimulator(t, "DHL", time.Minute, Options{})
+	cache.Del(context.Background(), "k")
+
+	if err := s.Handle(context.Background(), "k"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cache.Get(context.Background(), "k", "status"); !errors.Is(err, _cache.Nil) {
+		t.Errorf("vanished key should not be recreated, but got %v", err)
+	}
+	expectNotified(t, sub, false)
+}
+
+func TestHandleNoNotify(t *testing.T) {
+	s, _, sub := newTestSimulator(t, "DHL", time.Minute, Options{NoNotify: true})
+
+	if err := s.Handle(context.Background(), "k"); err != nil {
+		t.Fatal(err)
+	}
+	expectNotified(t, sub, false)
+}
+
+func TestRunPopsHighestPriorityFirst(t *testing.T) {
+	ctx := context.Background()
+	c, q := _cache.NewMemoryCache(), _queue.NewMemoryQueue()
+	for _, key := range []string{"low", "highest"} {
+		c.SetAndExpire(ctx, key, map[string]interface{}{"carrierCode": "DHL", "trackingNo": "JD0001", "status": -1}, time.Minute)
+	}
+	q.Push(ctx, _rpcclient.QueueTopic(_types.PriorityLow), "low")
+	q.Push(ctx, _rpcclient.QueueTopic(_types.PriorityHighest), "highest")
+
+	s := New(c, q, Options{})
+	if key, err := s.pop(ctx); err != nil || key != "highest" {
+		t.Fatalf("expected highest but got %s, %v", key, err)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error)
+	go func() { done <- s.Run(runCtx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if vs, _ := c.Get(ctx, "low", "status"); vs != nil && vs[0] == "1" {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatal("low priority key is not handled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("run should stop without error, but got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+// 该模块定义了配置文件中使用的时间间隔类型。
+// @Author: Haart
+// @Created: 2026-10-18
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 时间间隔。
+// 注意：配置文件中的时间间隔以字符串形式书写（比如`5m`、`1h30m`），也可以写作整数，此时表示秒数。
+type Duration time.Duration
+
+// 获取对应的`time.Duration`。
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// 将字符串解析为Duration
+// s 待解析的字符串，会被自动去除首尾空格。
+// 返回解析结果。
+func ParseDuration(s string) (Duration, error) {
+	s = strings.TrimSpace(s)
+
+	if s == "" {
+		return 0, nil
+	} else if d, err := time.ParseDuration(s); err != nil {
+		return 0, fmt.Errorf("illegal duration: %s", s)
+	} else {
+		return Duration(d), nil
+	}
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	s := ""
+	if err := json.Unmarshal(b, &s); err != nil {
+		// 不是字符串，那么尝试按照秒数解析。
+		var seconds int64
+		if err := json.Unmarshal(b, &seconds); err != nil {
+			return fmt.Errorf("illegal duration: %s", string(b))
+		}
+		*d = Duration(time.Duration(seconds) * time.Second)
+		return nil
+	} else if dd, err := ParseDuration(s); err != nil {
+		return err
+	} else {
+		*d = dd
+		return nil
+	}
+}
@@ -0,0 +1,43 @@
+// 该模块定义了参数不正确的错误类型，用于定位配置文件中不正确的字段。
+// @Author: Haart
+// @Created: 2026-10-18
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 表示参数不正确的错误，包含不正确的参数对应的字段名。
+type FieldError struct {
+	Field string // 不正确的参数对应的字段名。
+	Err   error  // 错误的原因。
+}
+
+// 创建参数不正确的错误。
+// field 不正确的参数对应的字段名。
+// format 错误消息的格式。
+// args 错误消息的参数。
+func NewFieldError(field string, format string, args ...interface{}) *FieldError {
+	return &FieldError{Field: field, Err: fmt.Errorf(format, args...)}
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// 获取错误中包含的不正确的字段名。
+// err 错误。
+// 返回字段名，如果错误不是参数不正确的错误则返回空字符串。
+func ErrorField(err error) string {
+	var fe *FieldError
+	if errors.As(err, &fe) {
+		return fe.Field
+	}
+
+	return ""
+}
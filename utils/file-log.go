@@ -29,6 +29,12 @@ func (fl *RollingFileLoggerWriter) Write(p []byte) (n int, err error) {
 	return fl.file.Write(p)
 }
 
+// 关闭当前的写入文件，之后再写入时会重新打开。
+func (fl *RollingFileLoggerWriter) Close() error {
+	fl.closeFile()
+	return nil
+}
+
 func (fl *RollingFileLoggerWriter) closeFile() {
 	fl.lock.Lock()
 	defer fl.lock.Unlock()
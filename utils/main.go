@@ -3,6 +3,7 @@ package utils
 import (
 	"fmt"
 	"log"
+	"math"
 	"path/filepath"
 	"runtime/debug"
 	"strconv"
@@ -203,6 +204,25 @@ func AbbrText(s string, l int) string {
 	}
 }
 
+// 计算百分位数。
+// sorted 已经按照升序排列的样本。
+// p 百分位，取值范围是0到1，比如0.95表示95分位数。
+// 返回按照最近秩方法计算的百分位数，如果样本为空则返回0。
+func Percentile(sorted []int, p float64) int {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	} else if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+
+	return sorted[i]
+}
+
 func RecoverPanic() {
 	if err := recover(); err != nil {
 		log.Printf("[ERROR] %s\n%s\n", err, string(debug.Stack()))